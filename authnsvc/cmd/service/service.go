@@ -37,7 +37,7 @@ var httpAddr = fs.String("http-addr", "0.0.0.0:8081", "HTTP listen address")
 var securedMethods = []string{"ListAccount", "DeleteAccount"}
 
 // holds the name of all the endpoints that ther service supports
-var allMethods = []string{"Login", "CreateAccount", "ListAccount", "DeleteAccount"}
+var allMethods = []string{"Login", "CreateAccount", "ListAccount", "DeleteAccount", "ListEvents"}
 
 // holds the database table names that the service is dealing with
 var allResourceTypes = []string{"accounts", "roles"}
@@ -103,6 +103,14 @@ func Run() {
 	// initialise endpoint
 	eps := svcep.New(svc, getEndpointMW(confObj))
 
+	// project the policies granted before the ACL projection was deployed
+	n, err := svc.BackfillACL(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error backfilling the acl projection [%v]", err))
+		return
+	}
+	logger.Info(ctx, fmt.Sprintf("backfilled the acl projection with %d policies", n))
+
 	g := &run.Group{}
 	initEventHandler(logger, svc, nc, g)
 	initHttpHandler(logger, eps, g)
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateAccountRequest struct {
 	Name     string `json:"name,omitempty"`
 	Email    string `json:"email,omitempty"`
@@ -17,22 +19,18 @@ func (resp CreateAccountResponse) Failed() error {
 }
 
 type GetAccountResponse struct {
-	ID           uint   `json:"account_id"`
-	Name         string `json:"name"`
-	Email        string `json:"email"`
-	Role         string `json:"role"`
-	TotalRecords int    `json:"-"`
-}
-
-type Page struct {
-	Page         int `json:"current_page"`
-	PageSize     int `json:"page_size"`
-	TotalRecords int `json:"total_records"`
+	ID    uint   `json:"account_id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	// the accounts can be listed by these, the cursors of the pages carry them
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
 }
 
 type ListAccountResponse struct {
 	Accounts []GetAccountResponse `json:"accounts,omitempty"`
-	PageInfo *Page                `json:"page,omitempty"`
+	Page     *Page                `json:"page,omitempty"`
 	Err      error                `json:"error,omitempty"`
 }
 
@@ -2,9 +2,11 @@ package endpoint
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/service"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -26,6 +28,10 @@ func MakeDeleteAccountEndpoint(s service.IAuthNService) endpoint.Endpoint {
 func MakeListAccountEndpoint(s service.IAuthNService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		qp, _ := request.(*dto.BasicQueryParam)
-		return s.ListAccount(ctx, []uint{}, qp), nil
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.ListAccountResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+		return s.ListAccount(ctx, fmt.Sprint(claim.AccntID), qp), nil
 	}
 }
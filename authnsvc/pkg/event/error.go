@@ -43,3 +43,19 @@ type ErrNilVerifyFunc struct {
 func (e *ErrNilVerifyFunc) Error() string {
 	return fmt.Sprintf("verify func is not provided for event: %s", e.Name)
 }
+
+// ErrInvalidField is returned when a field of the event payload violates its validation rule
+type ErrInvalidField struct {
+	Name  EventName
+	Field string
+	Rule  string
+	Value interface{}
+}
+
+func (e *ErrInvalidField) Error() string {
+	return fmt.Sprintf("invalid payload for event: %s [field: %s, rule: %s, value: %v]", e.Name, e.Field, e.Rule, e.Value)
+}
+
+func (e *ErrInvalidField) Unwrap() error {
+	return ErrInvalidPayload
+}
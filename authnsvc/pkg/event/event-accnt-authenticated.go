@@ -6,6 +6,7 @@ const EventAccountAuthenticated EventName = "EventAccountAuthenticated"
 func init() {
 	Registry.register(EventAccountAuthenticated, EventInfo{
 		ReqChan: "authnsvc.EventAccountAuthenticated",
+		Payload: EventAccountAuthenticatedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventAccountAuthenticatedPayload)
 			return ok
@@ -14,5 +15,5 @@ func init() {
 }
 
 type EventAccountAuthenticatedPayload struct {
-	AccntID uint `json:"accnt_id"`
+	AccntID uint `json:"accnt_id" validate:"required"`
 }
@@ -6,6 +6,7 @@ const EventAccountDeleted EventName = "EventAccountDeleted"
 func init() {
 	Registry.register(EventAccountDeleted, EventInfo{
 		ReqChan: "authnsvc.EventAccountDeleted",
+		Payload: EventAccountDeletedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventAccountDeletedPayload)
 			return ok
@@ -14,5 +15,5 @@ func init() {
 }
 
 type EventAccountDeletedPayload struct {
-	AccntID uint `json:"accnt_id"`
+	AccntID uint `json:"accnt_id" validate:"required"`
 }
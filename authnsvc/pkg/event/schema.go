@@ -0,0 +1,118 @@
+package event
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+// Schema is the subset of JSON schema used to describe the event payloads
+type Schema struct {
+	Type       string             `json:"type"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Minimum    *float64           `json:"minimum,omitempty"`
+	Maximum    *float64           `json:"maximum,omitempty"`
+	MinItems   *float64           `json:"minItems,omitempty"`
+	MaxItems   *float64           `json:"maxItems,omitempty"`
+}
+
+// SchemaOf derives the JSON schema of a payload struct by reflection.
+// The validation rules of the fields are reflected in the schema as well.
+func SchemaOf(payload interface{}) *Schema {
+	t := reflect.TypeOf(payload)
+	if t == nil {
+		return nil
+	}
+	return schemaOf(t)
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == uuidType {
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addProperties(s, t)
+		return s
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+	return &Schema{Type: t.Kind().String()}
+}
+
+func addProperties(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		// fields of embedded structs are promoted to the payload
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			addProperties(s, ft)
+			continue
+		}
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		prop := schemaOf(sf.Type)
+		for _, rule := range strings.Split(sf.Tag.Get(ValidationTag), ",") {
+			kv := strings.SplitN(rule, "=", 2)
+			switch {
+			case kv[0] == "required":
+				s.Required = append(s.Required, name)
+			case kv[0] == "oneof" && len(kv) == 2:
+				s.Required = append(s.Required, name)
+				prop.Enum = strings.Split(kv[1], "|")
+			case kv[0] == "min" && len(kv) == 2:
+				if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					if prop.Type == "array" {
+						prop.MinItems = &v
+					} else {
+						prop.Minimum = &v
+					}
+				}
+			case kv[0] == "max" && len(kv) == 2:
+				if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					if prop.Type == "array" {
+						prop.MaxItems = &v
+					} else {
+						prop.Maximum = &v
+					}
+				}
+			}
+		}
+		s.Properties[name] = prop
+	}
+}
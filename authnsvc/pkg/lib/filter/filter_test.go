@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		field, expr string
+		want        Cond
+		wantErr     bool
+	}{
+		{"status", "paid", Cond{"status", OpEq, []string{"paid"}}, false},
+		{"status", "in:paid,failed", Cond{"status", OpIn, []string{"paid", "failed"}}, false},
+		{"price", "lt:20", Cond{"price", OpLt, []string{"20"}}, false},
+		// an unknown prefix is a part of the value
+		{"created_at", "2026-01-01T10:00:00Z", Cond{"created_at", OpEq, []string{"2026-01-01T10:00:00Z"}}, false},
+		{"created_at", "gte:2026-01-01T10:00:00Z", Cond{"created_at", OpGte, []string{"2026-01-01T10:00:00Z"}}, false},
+		{"status", "in:paid,", Cond{}, true},
+		{"price", "lt:", Cond{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.field, tt.expr)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("Parse(%s, %s): want ErrInvalidFilter, got %v", tt.field, tt.expr, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%s, %s): want %v, got %v [%v]", tt.field, tt.expr, tt.want, got, err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	fs := Fields{
+		"name":       {Column: "name", Kind: String},
+		"price":      {Column: "price", Kind: Number},
+		"created_at": {Column: "created_at", Kind: Time},
+		"is_credit":  {Column: "is_credit", Kind: Bool},
+	}
+
+	exprs, err := fs.build([]Cond{
+		{"name", OpContains, []string{"Shoe"}},
+		{"price", OpLt, []string{"20"}},
+		{"created_at", OpGte, []string{"2026-01-01"}},
+	})
+	if err != nil {
+		t.Fatalf("build: unexpected err [%v]", err)
+	}
+	queries := []string{}
+	for _, e := range exprs {
+		queries = append(queries, e.query)
+	}
+	want := []string{"LOWER(name) LIKE ? ESCAPE '\\'", "price < ?", "created_at >= ?"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("build: want %v, got %v", want, queries)
+	}
+	if exprs[0].arg != "%shoe%" {
+		t.Errorf("build: want %%shoe%%, got %v", exprs[0].arg)
+	}
+
+	// the wildcards are escaped to be matched literally
+	exprs, err = fs.build([]Cond{{"name", OpContains, []string{`50%_off\`}}})
+	if err != nil {
+		t.Fatalf("build: unexpected err [%v]", err)
+	}
+	if want := `%50\%\_off\\%`; exprs[0].arg != want {
+		t.Errorf("build: want %s, got %v", want, exprs[0].arg)
+	}
+
+	for _, conds := range [][]Cond{
+		{{"qty", OpEq, []string{"1"}}},          // not allowed
+		{{"price", OpContains, []string{"1"}}},  // not for numbers
+		{{"is_credit", OpGt, []string{"true"}}}, // not for bools
+		{{"price", OpLt, []string{"cheap"}}},    // not a number
+	} {
+		if _, err := fs.build(conds); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("build(%v): want ErrInvalidFilter, got %v", conds, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package sorting
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	fs := Fields{
+		"created_at": {Column: "o.created_at"},
+		"status":     {Column: "status", Dirs: []Dir{Asc}},
+	}
+
+	keys, err := fs.Parse("created_at__desc,status")
+	if err != nil {
+		t.Fatalf("Parse: unexpected err [%v]", err)
+	}
+	want := []Key{{Column: "o.created_at", Desc: true}, {Column: "status"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Parse: want %v, got %v", want, keys)
+	}
+
+	for _, orderby := range []string{
+		"password",                // not allowed
+		"status__desc",            // not in that direction
+		"created_at__sideways",    // not a direction
+		"created_at; drop orders", // no raw SQL
+		"",
+	} {
+		_, err := fs.Parse(orderby)
+		if !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("Parse(%q): want ErrInvalidSort, got %v", orderby, err)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), "allowed are created_at, created_at__desc, status") {
+			t.Errorf("Parse(%q): want the allowed fields listed, got %v", orderby, err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package model
+
+// ACLEntry is the local projection of a policy of authzsvc granting an action on
+// a single account. It is kept up to date from the policy events, so that the
+// accounts an account may get are listed by a join rather than a list of IDs.
+type ACLEntry struct {
+	Subject      string `gorm:"primaryKey"`
+	ResourceType string `gorm:"primaryKey"`
+	ResourceID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Action       string `gorm:"primaryKey"`
+}
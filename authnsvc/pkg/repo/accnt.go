@@ -2,12 +2,12 @@ package repo
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"gorm.io/gorm"
 
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/lib/paging"
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/model"
 )
 
@@ -15,15 +15,20 @@ import (
 type UserRepository interface {
 	CreateUser(ctx context.Context, name, email, hashedPswd string, role model.Role) (uint, error)
 	ListUser(ctx context.Context, qp *dto.BasicQueryParam) ([]dto.GetAccountResponse, *dto.Page, error)
-	ListAccountsByIDs(ctx context.Context, aids []uint, qp *dto.BasicQueryParam) ([]dto.GetAccountResponse, error)
+	// ListAccountsGrantedTo lists the accounts the subject may get as per the ACL projection
+	ListAccountsGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) ([]dto.GetAccountResponse, *dto.Page, error)
 	GetUserByEmail(ctx context.Context, email string) (model.User, error)
 	GetUserByID(ctx context.Context, uid uint) (model.User, error)
 	GetRoleByName(ctx context.Context, name string) (model.Role, error)
 	UpdateUser(ctx context.Context, uid uint, user map[string]interface{}) error
 	DeleteUser(ctx context.Context, uid uint) error
 	CreateRole(ctx context.Context, name string) (int8, error)
-	ListRole(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Role, error)
+	ListRole(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Role, *dto.Page, error)
 	DeleteRole(ctx context.Context, rid int8) error
+
+	// GrantACL and RevokeACL keep the ACL projection in step with the policy events
+	GrantACL(ctx context.Context, e model.ACLEntry) error
+	RevokeACL(ctx context.Context, e model.ACLEntry) error
 }
 
 type basicUserRepo struct {
@@ -36,138 +41,62 @@ func NewBasicUserRepo(db *gorm.DB) UserRepository {
 	}
 
 	// auto-migrate tables
-	db.AutoMigrate(&model.User{}, &model.Role{})
+	db.AutoMigrate(&model.User{}, &model.Role{}, &model.ACLEntry{})
 
 	return &basicUserRepo{
 		db: db,
 	}
 }
 
-func orderBy(orderBy []string) func(tx *gorm.DB) *gorm.DB {
-	return func(tx *gorm.DB) *gorm.DB {
-		for _, o := range orderBy {
-			tx = tx.Order(o)
-		}
-		return tx
-	}
-}
-
-func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		if page == 0 {
-			page = 1
-		}
-
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
-		}
-
-		offset := (page - 1) * pageSize
-		return db.Offset(offset).Limit(pageSize)
-	}
-}
-
 func (b *basicUserRepo) CreateUser(ctx context.Context, name, email, hashedPswd string, roleObj model.Role) (uint, error) {
 	u := model.User{Name: name, Email: email, Password: hashedPswd, Role: roleObj}
 	err := b.db.Create(&u).Error
 	return u.ID, err
 }
 
-func queryMerger(q ...string) string {
-	return strings.Join(q, " ")
-}
-
-func orderByQry(q ...string) string {
-	return fmt.Sprintf("order by %s", strings.Join(q, ", "))
+// accountFilters are the fields which the accounts can be filtered by
+var accountFilters = filter.Fields{
+	"id":         {Column: "u.id", Kind: filter.Number},
+	"name":       {Column: "u.name", Kind: filter.String},
+	"email":      {Column: "u.email", Kind: filter.String},
+	"role":       {Column: "r.name", Kind: filter.String},
+	"created_at": {Column: "u.created_at", Kind: filter.Time},
+	"updated_at": {Column: "u.updated_at", Kind: filter.Time},
 }
 
-func paginate(page, pageSize int) (string, *dto.Page) {
-	if page == 0 {
-		page = 1
-	}
-
-	switch {
-	case pageSize > 100:
-		pageSize = 100
-	case pageSize <= 0:
-		pageSize = 10
-	}
-
-	offset := (page - 1) * pageSize
-
-	return fmt.Sprintf("offset %d limit %d", offset, pageSize), &dto.Page{Page: page}
-}
-
-func (b *basicUserRepo) ListUser(ctx context.Context, qp *dto.BasicQueryParam) ([]dto.GetAccountResponse, *dto.Page, error) {
-	var (
-		q, pageQry string
-		accnts     []dto.GetAccountResponse
-		err        error
-		pageInfo   *dto.Page
-	)
-
-	selectQry := "select u.id, u.email, u.name, r.name as role, count(u.id) over() as total_records from users u"
-	joinQry := "join roles r on r.id = u.role_id"
-
-	// if qp != nil {
-	// 	err = b.db.Scopes(
-	// 		orderBy(qp.Filter.OrederBy),
-	// 		Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
-	// 	).Joins("Role").Find(&users).Error
-	// } else {
-	// 	err = b.db.Joins("Role").Find(&users).Error
-	// }
-	if qp != nil {
-		pageQry, pageInfo = paginate(qp.Paginator.Page, qp.Paginator.PageSize)
-		for i, o := range qp.Filter.OrederBy {
-			qp.Filter.OrederBy[i] = "u." + o
-		}
-
-		q = queryMerger(
-			selectQry,
-			joinQry,
-			orderByQry(qp.Filter.OrederBy...),
-			pageQry,
-		)
-	} else {
-		q = queryMerger(selectQry, joinQry)
+// filterBy applies the filters of the query params on the allowed fields
+func filterBy(fields filter.Fields, qp *dto.BasicQueryParam) func(tx *gorm.DB) *gorm.DB {
+	if qp == nil {
+		return func(tx *gorm.DB) *gorm.DB { return tx }
 	}
+	return fields.Scope(qp.Filter.Conds)
+}
 
-	err = b.db.Debug().Raw(q).Scan(&accnts).Error
+// accounts queries the filtered users along with the name of their role
+func (b *basicUserRepo) accounts(qp *dto.BasicQueryParam) *gorm.DB {
+	return b.db.Debug().Table("users u").
+		Select("u.id", "u.email", "u.name", "r.name as role", "u.created_at", "u.updated_at").
+		Joins("join roles r on r.id = u.role_id").
+		Scopes(filterBy(accountFilters, qp))
+}
 
-	// if records found is zero because of pagination, try filtering records with out
-	// pagination and set total records, so that client could set correct page number
-	if len(accnts) <= 0 {
-		q = queryMerger(selectQry, joinQry)
-		err = b.db.Debug().Raw(q).Scan(&accnts).Error
-		if len(accnts) > 0 {
-			pageInfo.TotalRecords = accnts[0].TotalRecords
-			accnts = []dto.GetAccountResponse{}
-		}
-	} else {
-		records := accnts[0].TotalRecords
-		pageInfo.TotalRecords = records
-		pageInfo.PageSize = len(accnts)
+func (b *basicUserRepo) ListUser(ctx context.Context, qp *dto.BasicQueryParam) (accnts []dto.GetAccountResponse, page *dto.Page, err error) {
+	if qp == nil {
+		err = b.accounts(qp).Scan(&accnts).Error
+		return
 	}
-
-	return accnts, pageInfo, err
+	page, err = paging.Find(b.accounts(qp), qp.PageQuery("u.id"), &accnts)
+	return
 }
 
-func (b *basicUserRepo) ListAccountsByIDs(ctx context.Context, aids []uint, qp *dto.BasicQueryParam) ([]dto.GetAccountResponse, error) {
-	var accnts []dto.GetAccountResponse
-	values := []string{}
-	for _, aid := range aids {
-		values = append(values, fmt.Sprintf("(%s)", fmt.Sprint(aid)))
+func (b *basicUserRepo) ListAccountsGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) (accnts []dto.GetAccountResponse, page *dto.Page, err error) {
+	tx := b.accounts(qp).Scopes(grantedTo(b.db, sub, "accounts", "u.id"))
+	if qp == nil {
+		err = tx.Scan(&accnts).Error
+		return
 	}
-	selectQry := "select u.id, u.email, u.name, r.name as role from users u"
-	joinQry := "join roles r on r.id = u.role_id"
-	filterQry := fmt.Sprintf("where u.id = any ( values %s )", strings.Join(values, ","))
-	q := queryMerger(selectQry, joinQry, filterQry)
-	err := b.db.Debug().Raw(q, values).Scan(&accnts).Error
-	return accnts, err
+	page, err = paging.Find(tx, qp.PageQuery("u.id"), &accnts)
+	return
 }
 
 func (b *basicUserRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
@@ -211,16 +140,13 @@ func (b *basicUserRepo) CreateRole(ctx context.Context, name string) (rid int8,
 	return r.ID, err
 }
 
-func (b *basicUserRepo) ListRole(ctx context.Context, qp *dto.BasicQueryParam) (roles []model.Role, err error) {
-	fields := []string{"id", "name"}
-	if qp != nil {
-		err = b.db.Scopes(
-			orderBy(qp.Filter.OrederBy),
-			Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
-		).Select([]string{"id", "name"}).Find(&roles).Error
-	} else {
-		err = b.db.Select(fields).Find(&roles).Error
+func (b *basicUserRepo) ListRole(ctx context.Context, qp *dto.BasicQueryParam) (roles []model.Role, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Role{}).Select("id", "name", "created_at")
+	if qp == nil {
+		err = tx.Find(&roles).Error
+		return
 	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &roles)
 	return
 }
 
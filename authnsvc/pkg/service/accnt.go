@@ -82,17 +82,17 @@ func (svc *basicAuthNService) DeleteAccount(ctx context.Context, aid uint) (err
 	return
 }
 
-func (svc *basicAuthNService) ListAccount(ctx context.Context, aids []uint, qp *dto.BasicQueryParam) dto.ListAccountResponse {
+func (svc *basicAuthNService) ListAccount(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListAccountResponse {
 	var (
-		accnts   []dto.GetAccountResponse
-		err      error
-		pageInfo *dto.Page
+		accnts []dto.GetAccountResponse
+		err    error
+		page   *dto.Page
 	)
 
-	if len(aids) > 0 {
-		accnts, err = svc.accntrepo.ListAccountsByIDs(ctx, aids, qp)
+	if sub != "" {
+		accnts, page, err = svc.accntrepo.ListAccountsGrantedTo(ctx, sub, qp)
 	} else {
-		accnts, pageInfo, err = svc.accntrepo.ListUser(ctx, qp)
+		accnts, page, err = svc.accntrepo.ListUser(ctx, qp)
 	}
 
 	if err != nil {
@@ -100,5 +100,5 @@ func (svc *basicAuthNService) ListAccount(ctx context.Context, aids []uint, qp *
 		return dto.ListAccountResponse{Err: err}
 	}
 
-	return dto.ListAccountResponse{Accounts: accnts, Err: err, PageInfo: pageInfo}
+	return dto.ListAccountResponse{Accounts: accnts, Err: err, Page: page}
 }
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/error"
@@ -26,6 +25,10 @@ func (m *authzMW) HandlePolicyUpdatedEvent(ctx context.Context, t, sub, rtype, r
 	return m.next.HandlePolicyUpdatedEvent(ctx, t, sub, rtype, rid, act)
 }
 
+func (m *authzMW) BackfillACL(ctx context.Context) (int, error) {
+	return m.next.BackfillACL(ctx)
+}
+
 func (m *authzMW) DeleteAccount(ctx context.Context, aid uint) (err error) {
 	claim := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "accounts", "delete", aid)
@@ -43,25 +46,19 @@ func (m *authzMW) CreateAccount(ctx context.Context, accnt dto.CreateAccountRequ
 	return m.next.CreateAccount(ctx, accnt)
 }
 
-func (m *authzMW) ListAccount(ctx context.Context, aids []uint, qp *dto.BasicQueryParam) dto.ListAccountResponse {
+func (m *authzMW) ListAccount(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListAccountResponse {
 	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	if !ok {
 		return dto.ListAccountResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
-	rids := m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "accounts", "*")
-	rids = append(rids, m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "accounts", "get")...)
-	if len(rids) <= 0 {
+	// the callers list for themselves only
+	if sub != fmt.Sprint(claim.AccntID) {
 		return dto.ListAccountResponse{Err: ce.ErrInsufficientPerm}
 	}
-	for _, rid := range rids {
-		if rid == "*" {
-			return m.next.ListAccount(ctx, []uint{}, qp)
-		}
-		id, err := strconv.Atoi(rid)
-		if err != nil {
-			continue
-		}
-		aids = append(aids, uint(id))
+	// who may get any account lists all of them, the others only the ones granted to them
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", sub, "accounts", "get", "*")
+	if m.pe.Enforce(ctx, reqPolicy, nil) {
+		return m.next.ListAccount(ctx, "", qp)
 	}
-	return m.next.ListAccount(ctx, aids, qp)
+	return m.next.ListAccount(ctx, sub, qp)
 }
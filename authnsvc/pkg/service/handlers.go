@@ -2,8 +2,46 @@ package service
 
 import (
 	"context"
+	"strconv"
+
+	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/model"
 )
 
 func (svc *basicAuthNService) HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error {
+	if err := svc.projectPolicy(ctx, method, sub, rtype, rid, act); err != nil {
+		return err
+	}
 	return svc.ps.UpdatePolicy(method, sub, rtype, rid, act)
 }
+
+// projectPolicy keeps the ACL projection in step with the policies on a single account.
+// The wildcard policies are left to the policy enforcer.
+func (svc *basicAuthNService) projectPolicy(ctx context.Context, method, sub, rtype, rid, act string) error {
+	aid, err := strconv.ParseUint(rid, 10, 0)
+	if rtype != "accounts" || err != nil {
+		return nil
+	}
+	e := model.ACLEntry{Subject: sub, ResourceType: rtype, ResourceID: uint(aid), Action: act}
+	if method == "delete" {
+		return svc.accntrepo.RevokeACL(ctx, e)
+	}
+	return svc.accntrepo.GrantACL(ctx, e)
+}
+
+// BackfillACL grants the policies which authzsvc holds on the resources of the service,
+// as the policy updates made before the projection was deployed are not in the streams
+// anymore. It is to be called before consuming the policy updates, so that the ones
+// made meanwhile apply on top of it. A policy already projected is granted again as a no-op.
+func (svc *basicAuthNService) BackfillACL(ctx context.Context) (int, error) {
+	policies, err := svc.ps.ListPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range policies {
+		err := svc.projectPolicy(ctx, "put", p.Sub(), p.ResourceType(), p.ResourceID(), p.Action())
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(policies), nil
+}
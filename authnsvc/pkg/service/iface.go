@@ -18,13 +18,17 @@ type Middleware func(IAuthNService) IAuthNService
 type IAuthNService interface {
 	// Handlers of the events
 	HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error
+	// BackfillACL projects the policies granted before the ACL projection was deployed,
+	// see README.md. It returns the number of policies it went through.
+	BackfillACL(ctx context.Context) (int, error)
 
 	// auth service methods
 	GenToken(ctx context.Context, accnt dto.LoginRequest) dto.LoginResponse
 
 	// account service methods
 	CreateAccount(ctx context.Context, accnt dto.CreateAccountRequest) dto.CreateAccountResponse
-	ListAccount(ctx context.Context, aids []uint, qp *dto.BasicQueryParam) dto.ListAccountResponse
+	// ListAccount lists the accounts granted to the subject, all of them if sub is empty
+	ListAccount(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListAccountResponse
 	DeleteAccount(ctx context.Context, aid uint) (err error)
 }
 
@@ -50,6 +54,13 @@ func WithRepo(r repo.UserRepository) SvcConf {
 	}
 }
 
+func WithAuthNRepo(r repo.AuthNRepository) SvcConf {
+	return func(svc *basicAuthNService) error {
+		svc.authnrepo = r
+		return nil
+	}
+}
+
 func WithNATSEncodedConn(nc *nats.EncodedConn) SvcConf {
 	return func(svc *basicAuthNService) error {
 		if nc == nil {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/endpoint"
+	"github.com/AyushSenapati/reactive-micro/authnsvc/pkg/lib/sorting"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
@@ -66,7 +67,15 @@ func makeListAccountHandler(m *mux.Router, endpoints endpoint.Endpoints, options
 			options...))
 }
 
+// accountSorts are the fields which the accounts can be sorted by
+var accountSorts = sorting.Fields{
+	"id":         {Column: "u.id"},
+	"name":       {Column: "u.name"},
+	"email":      {Column: "u.email"},
+	"created_at": {Column: "u.created_at"},
+	"updated_at": {Column: "u.updated_at"},
+}
+
 func decodeListResouceRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
-	qp := processBasicQP(r)
-	return qp, nil
+	return processBasicQP(r, accountSorts, "updated_at__desc")
 }
@@ -25,5 +25,6 @@ func NewHTTPHandler(endpoints endpoint.Endpoints, options map[string][]kithttp.S
 	// makeCreateRoleHandler(m, endpoints, options["CreateRole"])
 	// makeListRoleHandler(m, endpoints, options["ListRole"])
 	// makeDeleteRoleHandler(m, endpoints, options["DeleteRole"])
+	makeListEventsHandler(m, options["ListEvents"])
 	return m
 }
@@ -21,6 +21,19 @@ func getTargetSub(reqChan, svcName string) string {
 	return reqChan + "." + svcName
 }
 
+// ackInvalid validates the payload of the event and acks the message if it is invalid,
+// as an invalid payload would never be processed successfully, instead of letting it
+// be redelivered. It returns true if the message got acked.
+func ackInvalid(ctx context.Context, logger *cl.CustomLogger, m *nats.Msg, name svcevent.EventName, p interface{}) bool {
+	err := svcevent.ValidatePayload(name, p)
+	if err == nil {
+		return false
+	}
+	logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", name, err))
+	m.Ack()
+	return true
+}
+
 func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IAuthNService) *EventHandlerFuncs {
 	return &EventHandlerFuncs{
 		EventPolicyUpdatedHandler: makeEventPolicyUpdatedHandler(logger, svc),
@@ -67,6 +80,10 @@ func makeEventPolicyUpdatedHandler(logger *cl.CustomLogger, svc service.IAuthNSe
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventPolicyUpdated, p) {
+			return
+		}
+
 		err = svc.HandlePolicyUpdatedEvent(ctx, p.Method, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if (err == nil) || (err == pe.ErrUnsupportedRtype) || (err == pe.ErrSubNotCached) {
 			m.Ack() // if no error occurred processing event ack it
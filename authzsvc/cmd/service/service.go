@@ -30,7 +30,7 @@ var fs = flag.NewFlagSet("user", flag.ExitOnError)
 var httpAddr = fs.String("http-addr", ":8083", "HTTP listen address")
 
 // holds the name of all the endpoints that ther service supports
-var allMethods = []string{"UpsertPolicy", "ListPolicy", "RemovePolicy", "RemovePolicyBySub"}
+var allMethods = []string{"UpsertPolicy", "ListPolicy", "RemovePolicy", "RemovePolicyBySub", "ListEvents"}
 
 func Run() {
 	fs.Parse(os.Args[1:])
@@ -3,10 +3,15 @@ package dto
 type ListPolicyRequest struct {
 	Sub          string `json:"sub"`
 	ResourceType string `json:"resource_type"`
+	// Cursor and PageSize page the policies of all the subjects, when Sub is empty
+	Cursor   string `json:"cursor,omitempty"`
+	PageSize int    `json:"page_size,omitempty"`
 }
 
 type ListPolicyResponse struct {
 	Policies []string `json:"policies"`
+	// NextCursor gets the next page of the policies of all the subjects, if any
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type Policy struct {
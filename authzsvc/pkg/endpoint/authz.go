@@ -22,9 +22,11 @@ func MakeUpsertPolicyEndpoint(s service.IAuthzService) endpoint.Endpoint {
 
 func MakeListPolicyEndpoint(s service.IAuthzService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		reqObj := request.(dto.ListPolicyRequest)
-		response = s.ListPolicy(ctx, reqObj)
-		return
+		reqObj, ok := request.(dto.ListPolicyRequest)
+		if !ok {
+			return nil, svcevent.ErrInvalidPayload
+		}
+		return s.ListPolicy(ctx, reqObj)
 	}
 }
 
@@ -6,6 +6,7 @@ const EventRemovePolicy EventName = "EventRemovePolicy"
 func init() {
 	Registry.register(EventRemovePolicy, EventInfo{
 		ReqChan: "authzsvc.EventRemovePolicy",
+		Payload: EventRemovePolicyPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventRemovePolicyPayload)
 			return ok
@@ -0,0 +1,20 @@
+package event
+
+const EventRemoveResourcePolicies EventName = "EventRemoveResourcePolicies"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventRemoveResourcePolicies, EventInfo{
+		ReqChan: "authzsvc.EventRemoveResourcePolicies",
+		Payload: EventRemoveResourcePoliciesPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventRemoveResourcePoliciesPayload)
+			return ok
+		},
+	})
+}
+
+type EventRemoveResourcePoliciesPayload struct {
+	ResourceType string `json:"resource_type" validate:"required"`
+	ResourceID   string `json:"resource_id" validate:"required"`
+}
@@ -0,0 +1,133 @@
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValidationTag is the struct tag in which the payload fields declare their rules.
+// The elements of a list of structs are validated against the rules of their fields.
+// Rules are comma separated and can be any of:
+//
+//	required         field must not hold its zero value (i.e. nil uuid, empty string, 0)
+//	min=n / max=n    bounds of a number or the length of a string or a list
+//	oneof=a|b        field must be one of the given values
+//
+// ex: `validate:"required,oneof=payment_successful|payment_failed"`
+const ValidationTag = "validate"
+
+// ValidatePayload checks the payload of the event against the rules declared on its fields
+func ValidatePayload(name EventName, payload interface{}) error {
+	v := reflect.ValueOf(payload)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return &ErrInvalidField{Name: name, Rule: "required"}
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ErrInvalidPayload
+	}
+	return validateStruct(name, v, "")
+}
+
+// validateStruct validates the fields of v, prefix is the path of v in the payload
+func validateStruct(name EventName, v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+
+		// fields of embedded structs are promoted to the payload
+		if sf.Anonymous && sf.Tag.Get("json") == "" {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv = reflect.New(fv.Type().Elem())
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := validateStruct(name, fv, prefix); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		field := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if field == "" {
+			field = sf.Name
+		}
+		field = prefix + field
+
+		if rules := sf.Tag.Get(ValidationTag); rules != "" {
+			for _, rule := range strings.Split(rules, ",") {
+				if !checkRule(fv, rule) {
+					return &ErrInvalidField{Name: name, Field: field, Rule: rule, Value: fv.Interface()}
+				}
+			}
+		}
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct {
+			for j := 0; j < fv.Len(); j++ {
+				if err := validateStruct(name, fv.Index(j), fmt.Sprintf("%s[%d].", field, j)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkRule(v reflect.Value, rule string) bool {
+	kv := strings.SplitN(rule, "=", 2)
+	switch kv[0] {
+	case "required":
+		return !v.IsZero()
+	case "min", "max":
+		if len(kv) != 2 {
+			return false
+		}
+		bound, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return false
+		}
+		n, ok := numberOf(v)
+		if !ok {
+			return false
+		}
+		if kv[0] == "min" {
+			return n >= bound
+		}
+		return n <= bound
+	case "oneof":
+		if len(kv) != 2 {
+			return false
+		}
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Split(kv[1], "|") {
+			if s == allowed {
+				return true
+			}
+		}
+		return false
+	}
+	return false // unknown rules never pass, so that typos in the tags get noticed
+}
+
+// numberOf returns the value of numeric fields and the length of strings and lists
+func numberOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/imdario/mergo"
+)
+
+type inMemAuthzRepo struct {
+	mu       sync.RWMutex
+	policies map[string]*policyDoc
+}
+
+// NewInMemAuthzRepo returns an AuthzRepo which keeps all the policies in memory.
+// It is meant for tests and local setups where mongo is not available.
+func NewInMemAuthzRepo() AuthzRepo {
+	return &inMemAuthzRepo{policies: make(map[string]*policyDoc)}
+}
+
+func (b *inMemAuthzRepo) UpsertPolicy(ctx context.Context, sub, resourceType, resourceID, action string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	newPolicy := newPolicy(sub, resourceType, resourceID, action)
+
+	existingPolicy, found := b.policies[sub]
+	if !found {
+		b.policies[sub] = newPolicy
+		return nil
+	}
+
+	// remove duplicates
+	for removePolicy(existingPolicy, resourceType, resourceID, action) {
+	}
+
+	if err := mergo.MapWithOverwrite(newPolicy, existingPolicy, mergo.WithAppendSlice); err != nil {
+		return err
+	}
+	b.policies[sub] = newPolicy
+	return nil
+}
+
+func (b *inMemAuthzRepo) RemovePolicy(ctx context.Context, sub, resourceType, resourceID, action string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existingPolicy, found := b.policies[sub]
+	if !found {
+		return fmt.Errorf("inmem: policy for sub-%s not found", sub)
+	}
+
+	// it would remove duplications as well
+	for removePolicy(existingPolicy, resourceType, resourceID, action) {
+	}
+	return nil
+}
+
+func (b *inMemAuthzRepo) RemovePolicyBySub(ctx context.Context, sub string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, found := b.policies[sub]; !found {
+		return fmt.Errorf("inmem: policy for sub-%s not found", sub)
+	}
+	delete(b.policies, sub)
+	return nil
+}
+
+func (b *inMemAuthzRepo) ListPolicy(ctx context.Context, sub, resourceType string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if sub == "" {
+		policies := []string{}
+		for sub := range b.policies {
+			policies = append(policies, b.listPolicy(sub, resourceType)...)
+		}
+		return policies, nil
+	}
+	return b.listPolicy(sub, resourceType), nil
+}
+
+func (b *inMemAuthzRepo) ListPolicyPage(ctx context.Context, resourceType, after string, limit int) ([]string, string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// same as the mongo repo, only the subjects with policies on the resource type count
+	subs := []string{}
+	for sub, p := range b.policies {
+		if _, found := p.Policies[resourceType]; found && sub > after {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Strings(subs)
+	if len(subs) > limit {
+		subs = subs[:limit]
+	}
+
+	policies := []string{}
+	for _, sub := range subs {
+		policies = append(policies, b.listPolicy(sub, resourceType)...)
+	}
+	if len(subs) < limit {
+		return policies, "", nil
+	}
+	return policies, subs[len(subs)-1], nil
+}
+
+func (b *inMemAuthzRepo) listPolicy(sub, resourceType string) []string {
+	existingPolicy, found := b.policies[sub]
+	if !found {
+		return nil
+	}
+
+	// project only the requested resource type, same as the mongo repo does
+	a, found := existingPolicy.Policies[resourceType]
+	if !found {
+		return nil
+	}
+	projection := &policyDoc{Sub: sub, Policies: resourceTypes{resourceType: actions{}}}
+	for action, resourceIDs := range a {
+		projection.Policies[resourceType][action] = append([]string{}, resourceIDs...)
+	}
+	return buildPolicies(projection)
+}
@@ -13,7 +13,13 @@ import (
 
 type AuthzRepo interface {
 	UpsertPolicy(ctx context.Context, sub, resourceType, resourceID, action string) error
-	ListPolicy(ctx context.Context, sub, resourceType string) []string
+	// ListPolicy lists the policies of the subject on the resource type,
+	// of all the subjects if sub is empty
+	ListPolicy(ctx context.Context, sub, resourceType string) ([]string, error)
+	// ListPolicyPage lists the policies on the resource type of at most limit subjects,
+	// in the order of the subjects after the given one. It returns the last subject
+	// listed to get the next page from, empty when there are no more.
+	ListPolicyPage(ctx context.Context, resourceType, after string, limit int) ([]string, string, error)
 	RemovePolicy(ctx context.Context, sub, resourceType, resourceID, action string) error
 	RemovePolicyBySub(ctx context.Context, sub string) error
 }
@@ -154,26 +160,89 @@ func (b *basicAuthzRepo) RemovePolicyBySub(ctx context.Context, sub string) erro
 	return nil
 }
 
-func (b *basicAuthzRepo) ListPolicy(ctx context.Context, sub, resourceType string) (policies []string) {
+func (b *basicAuthzRepo) ListPolicy(ctx context.Context, sub, resourceType string) ([]string, error) {
 	policiesCollection := b.db.Collection("policies")
 
+	if sub == "" {
+		cursor, err := policiesCollection.Find(
+			ctx, bson.M{fmt.Sprintf("policies.%s", resourceType): bson.M{"$exists": true}},
+			&options.FindOptions{Projection: bson.M{"sub": 1, fmt.Sprintf("policies.%s", resourceType): 1}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := decodePolicyDocs(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		var policies []string
+		for _, doc := range docs {
+			policies = append(policies, buildPolicies(doc)...)
+		}
+		return policies, nil
+	}
+
 	doc := policiesCollection.FindOne(
 		ctx, bson.M{"sub": sub},
 		&options.FindOneOptions{Projection: bson.M{fmt.Sprintf("policies.%s", resourceType): 1}},
 	)
-
-	if doc.Err() != nil {
-		fmt.Println(doc.Err())
-		return
+	if err := doc.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
 	}
 
 	var existingPolicy *policyDoc
 	if err := doc.Decode(&existingPolicy); err != nil {
-		return
+		return nil, err
 	}
 
 	existingPolicy.Sub = sub
-	return buildPolicies(existingPolicy)
+	return buildPolicies(existingPolicy), nil
+}
+
+func (b *basicAuthzRepo) ListPolicyPage(ctx context.Context, resourceType, after string, limit int) ([]string, string, error) {
+	policiesCollection := b.db.Collection("policies")
+	field := fmt.Sprintf("policies.%s", resourceType)
+
+	cursor, err := policiesCollection.Find(
+		ctx, bson.M{"sub": bson.M{"$gt": after}, field: bson.M{"$exists": true}},
+		options.Find().
+			SetProjection(bson.M{"sub": 1, field: 1}).
+			SetSort(bson.M{"sub": 1}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	docs, err := decodePolicyDocs(ctx, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var policies []string
+	for _, doc := range docs {
+		policies = append(policies, buildPolicies(doc)...)
+	}
+	if len(docs) < limit {
+		return policies, "", nil
+	}
+	return policies, docs[len(docs)-1].Sub, nil
+}
+
+func decodePolicyDocs(ctx context.Context, cursor *mongo.Cursor) ([]*policyDoc, error) {
+	defer cursor.Close(ctx)
+
+	var docs []*policyDoc
+	for cursor.Next(ctx) {
+		var doc *policyDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, cursor.Err()
 }
 
 func buildPolicies(p *policyDoc) (policies []string) {
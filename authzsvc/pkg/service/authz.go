@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/AyushSenapati/reactive-micro/authzsvc/pkg/dto"
 	svcevent "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/event"
@@ -40,8 +41,20 @@ func (svc *basicAuthzService) UpsertPolicy(ctx context.Context, sub, resourceTyp
 	return err
 }
 
-func (svc *basicAuthzService) ListPolicy(ctx context.Context, reqObj dto.ListPolicyRequest) (resp dto.ListPolicyResponse) {
-	resp.Policies = svc.repo.ListPolicy(ctx, reqObj.Sub, reqObj.ResourceType)
+func (svc *basicAuthzService) ListPolicy(ctx context.Context, reqObj dto.ListPolicyRequest) (resp dto.ListPolicyResponse, err error) {
+	if reqObj.Sub != "" {
+		resp.Policies, err = svc.repo.ListPolicy(ctx, reqObj.Sub, reqObj.ResourceType)
+		svc.cl.LogIfError(ctx, err)
+		return
+	}
+
+	// the policies of all the subjects are listed a page of subjects at a time
+	pageSize := reqObj.PageSize
+	if pageSize <= 0 || pageSize > maxPolicyPageSize {
+		pageSize = defaultPolicyPageSize
+	}
+	resp.Policies, resp.NextCursor, err = svc.repo.ListPolicyPage(ctx, reqObj.ResourceType, reqObj.Cursor, pageSize)
+	svc.cl.LogIfError(ctx, err)
 	return
 }
 
@@ -79,3 +92,22 @@ func (svc *basicAuthzService) RemovePolicy(ctx context.Context, sub, resourceTyp
 func (svc *basicAuthzService) RemovePolicyBySub(ctx context.Context, sub string) error {
 	return svc.repo.RemovePolicyBySub(ctx, sub)
 }
+
+func (svc *basicAuthzService) RemovePolicyByResource(ctx context.Context, resourceType, resourceID string) error {
+	policies, err := svc.repo.ListPolicy(ctx, "", resourceType)
+	if err != nil {
+		return err
+	}
+
+	// every removal fires its policy updated event, a failed one is found again on redelivery
+	for _, p := range policies {
+		c := strings.Split(p, ":")
+		if len(c) != 4 || c[3] != resourceID {
+			continue
+		}
+		if err := svc.RemovePolicy(ctx, c[0], resourceType, resourceID, c[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -13,11 +13,20 @@ import (
 
 type IAuthzService interface {
 	UpsertPolicy(ctx context.Context, sub, resourceType, resourceID, action string) error
-	ListPolicy(ctx context.Context, reqObj dto.ListPolicyRequest) dto.ListPolicyResponse
+	// ListPolicy lists the policies of the subject on the resource type, or a page
+	// of the policies of all the subjects if the subject is empty
+	ListPolicy(ctx context.Context, reqObj dto.ListPolicyRequest) (dto.ListPolicyResponse, error)
 	RemovePolicy(ctx context.Context, sub, resourceType, resourceID, action string) error
 	RemovePolicyBySub(ctx context.Context, sub string) error
+	// RemovePolicyByResource removes the policies of all the subjects on the resource
+	RemovePolicyByResource(ctx context.Context, resourceType, resourceID string) error
 }
 
+const (
+	defaultPolicyPageSize = 100
+	maxPolicyPageSize     = 1000
+)
+
 type basicAuthzService struct {
 	cl   *cl.CustomLogger
 	repo repo.AuthzRepo
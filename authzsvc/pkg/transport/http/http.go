@@ -19,5 +19,7 @@ func NewHTTPHandler(endpoints endpoint.Endpoints, options map[string][]kithttp.S
 	makeRemovePolicyHandler(m, endpoints, options["RemovePolicy"])
 	makeRemovePolicyBySubHandler(m, endpoints, options["RemovePolicyBySub"])
 
+	makeListEventsHandler(m, options["ListEvents"])
+
 	return m
 }
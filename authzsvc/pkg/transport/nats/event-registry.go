@@ -16,17 +16,34 @@ type EventHandlerFuncs struct {
 	EventUpsertPolicyHandler   nats.Handler
 	EventRemovePolicyHandler   nats.Handler
 	EventAccountDeletedHandler nats.Handler
+
+	EventRemoveResourcePoliciesHandler nats.Handler
 }
 
 func getTargetSub(reqChan, svcName string) string {
 	return reqChan + "." + svcName
 }
 
+// ackInvalid validates the payload of the event and acks the message if it is invalid,
+// as an invalid payload would never be processed successfully, instead of letting it
+// be redelivered. It returns true if the message got acked.
+func ackInvalid(ctx context.Context, logger *cl.CustomLogger, m *nats.Msg, name svcevent.EventName, p interface{}) bool {
+	err := svcevent.ValidatePayload(name, p)
+	if err == nil {
+		return false
+	}
+	logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", name, err))
+	m.Ack()
+	return true
+}
+
 func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IAuthzService) *EventHandlerFuncs {
 	return &EventHandlerFuncs{
 		EventUpsertPolicyHandler:   makeEventUpsertPolicyHandler(logger, svc),
 		EventRemovePolicyHandler:   makeEventRemovePolicyHandler(logger, svc),
 		EventAccountDeletedHandler: makeEventAccountDeletedHandler(logger, svc),
+
+		EventRemoveResourcePoliciesHandler: makeEventRemoveResourcePoliciesHandler(logger, svc),
 	}
 }
 
@@ -68,6 +85,17 @@ func (ehf *EventHandlerFuncs) GetSubscription(nc *nats.EncodedConn) (subscriptio
 	}
 	subscriptions = append(subscriptions, s)
 
+	// subscribe to EventRemoveResourcePolicies
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventRemoveResourcePolicies)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventRemoveResourcePoliciesHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
 	return
 }
 
@@ -86,12 +114,16 @@ func makeEventUpsertPolicyHandler(logger *cl.CustomLogger, svc service.IAuthzSer
 			return
 		}
 
-		json.Unmarshal(encodedPayload, &p)
+		err = json.Unmarshal(encodedPayload, &p)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventUpsertPolicy] err: %v", err))
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventUpsertPolicy, p) {
+			return
+		}
+
 		err = svc.UpsertPolicy(ctx, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventUpsertPolicy] err: %v", err))
@@ -116,12 +148,16 @@ func makeEventRemovePolicyHandler(logger *cl.CustomLogger, svc service.IAuthzSer
 			return
 		}
 
-		json.Unmarshal(encodedPayload, &p)
+		err = json.Unmarshal(encodedPayload, &p)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventRemovePolicy] err: %v", err))
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventRemovePolicy, p) {
+			return
+		}
+
 		err = svc.RemovePolicy(ctx, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventRemovePolicy] err: %v", err))
@@ -146,12 +182,16 @@ func makeEventAccountDeletedHandler(logger *cl.CustomLogger, svc service.IAuthzS
 			return
 		}
 
-		json.Unmarshal(encodedPayload, &p)
+		err = json.Unmarshal(encodedPayload, &p)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventAccountDeleted] err: %v", err))
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventAccountDeleted, p) {
+			return
+		}
+
 		err = svc.RemovePolicyBySub(ctx, fmt.Sprint(p.AccntID))
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventAccountDeleted] err: %v", err))
@@ -160,3 +200,37 @@ func makeEventAccountDeletedHandler(logger *cl.CustomLogger, svc service.IAuthzS
 		m.Ack()
 	}
 }
+
+func makeEventRemoveResourcePoliciesHandler(logger *cl.CustomLogger, svc service.IAuthzService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventRemoveResourcePoliciesPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRemoveResourcePolicies] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRemoveResourcePolicies] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventRemoveResourcePolicies, p) {
+			return
+		}
+
+		err = svc.RemovePolicyByResource(ctx, p.ResourceType, p.ResourceID)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRemoveResourcePolicies] err: %v", err))
+			return
+		}
+		m.Ack()
+	}
+}
@@ -0,0 +1,169 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestListGrantedResources lists the resources granted to an account through
+// the ACL projection of the service, kept up to date from the policy events
+func TestListGrantedResources(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	otherID, otherToken := h.Signup("Other", "seller")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(otherID, "merchants", "post", "*")
+
+	m1 := h.createMerchant(sellerToken, "e2e-merchant-1")
+	m2 := h.createMerchant(sellerToken, "e2e-merchant-2")
+	other := h.createMerchant(otherToken, "e2e-other-merchant")
+
+	listMerchants := func(token, query string) ([]uuid.UUID, page) {
+		t.Helper()
+		var resp struct {
+			Merchants []struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"merchants"`
+			Page page `json:"page"`
+		}
+		code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/merchants?"+query, token, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list merchants: got status %d", code)
+		}
+		ids := []uuid.UUID{}
+		for _, m := range resp.Merchants {
+			ids = append(ids, m.ID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+		return ids, resp.Page
+	}
+	sorted := func(ids ...uuid.UUID) []uuid.UUID {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+		return ids
+	}
+
+	h.Eventually("merchants granted to the seller", func() bool {
+		ids, _ := listMerchants(sellerToken, "")
+		return reflect.DeepEqual(ids, sorted(m1, m2))
+	})
+	h.Eventually("merchants granted to the other seller", func() bool {
+		ids, _ := listMerchants(otherToken, "")
+		return reflect.DeepEqual(ids, []uuid.UUID{other})
+	})
+
+	// the granted merchants are paginated like any list
+	ids, p := listMerchants(sellerToken, "page_size=1&total=true")
+	if len(ids) != 1 || p.NextCursor == "" || p.TotalRecords == nil || *p.TotalRecords != 2 {
+		t.Errorf("first page of the granted merchants: want 1 of 2, got %v %+v", ids, p)
+	}
+
+	// revoking the policy drops the merchant from the list
+	policy := map[string]string{
+		"subject": fmt.Sprint(sellerID), "resource_type": "merchants", "resource_id": m2.String(), "action": "*",
+	}
+	if code := h.Do("DELETE", h.AuthzURL+"/v1/authzsvc/policies", "", policy, nil); code != http.StatusOK {
+		t.Fatalf("remove policy: got status %d", code)
+	}
+	h.Eventually("revoked merchant to be dropped", func() bool {
+		ids, _ := listMerchants(sellerToken, "")
+		return reflect.DeepEqual(ids, []uuid.UUID{m1})
+	})
+}
+
+// TestBackfillACL checks that the policies granted before the ACL projection was
+// deployed, whose events are not in the streams anymore, are projected on startup
+func TestBackfillACL(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	otherID, otherToken := h.Signup("Other", "seller")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(otherID, "merchants", "post", "*")
+	mid := h.createMerchant(otherToken, "e2e-other-merchant")
+
+	listMerchants := func() []uuid.UUID {
+		t.Helper()
+		var resp struct {
+			Merchants []struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"merchants"`
+		}
+		if code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/merchants", sellerToken, nil, &resp); code != http.StatusOK {
+			t.Fatalf("list merchants: got status %d", code)
+		}
+		ids := []uuid.UUID{}
+		for _, m := range resp.Merchants {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	// a policy granted with no event, as if its event expired from the stream
+	if err := h.AuthzRepo.UpsertPolicy(context.Background(), fmt.Sprint(sellerID), "merchants", mid.String(), "get"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := listMerchants(); len(ids) != 0 {
+		t.Fatalf("merchants granted before the backfill: want none, got %v", ids)
+	}
+
+	n, err := h.InventorySvc.BackfillACL(context.Background())
+	if err != nil || n == 0 {
+		t.Fatalf("backfill: got %d policies [%v]", n, err)
+	}
+	if ids := listMerchants(); !reflect.DeepEqual(ids, []uuid.UUID{mid}) {
+		t.Errorf("merchants granted after the backfill: want %v, got %v", mid, ids)
+	}
+
+	// backfilling again grants nothing new
+	if _, err := h.InventorySvc.BackfillACL(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ids := listMerchants(); len(ids) != 1 {
+		t.Errorf("merchants granted after backfilling twice: want %v, got %v", mid, ids)
+	}
+}
+
+// TestListPolicyPages checks that authzsvc lists the policies of all the subjects
+// on a resource type a page of subjects at a time
+func TestListPolicyPages(t *testing.T) {
+	h := NewHarness(t)
+
+	want := []string{}
+	for _, sub := range []string{"e2e-1", "e2e-2", "e2e-3"} {
+		if err := h.AuthzRepo.UpsertPolicy(context.Background(), sub, "e2e-things", "1", "get"); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, sub+":e2e-things:get:1")
+	}
+	// the subjects with no policy on the resource type fill no page
+	if err := h.AuthzRepo.UpsertPolicy(context.Background(), "e2e-0", "e2e-others", "1", "get"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, pages, cursor := []string{}, 0, ""
+	for {
+		var resp struct {
+			Policies   []string `json:"policies"`
+			NextCursor string   `json:"next_cursor"`
+		}
+		req := map[string]interface{}{"resource_type": "e2e-things", "page_size": 2, "cursor": cursor}
+		if code := h.Do("GET", h.AuthzURL+"/v1/authzsvc/policies", "", req, &resp); code != http.StatusOK {
+			t.Fatalf("list policies: got status %d", code)
+		}
+		got, pages = append(got, resp.Policies...), pages+1
+		if resp.NextCursor == "" || pages > 3 {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if sort.Strings(got); !reflect.DeepEqual(got, want) || pages != 2 {
+		t.Errorf("want %v in 2 pages, got %v in %d", want, got, pages)
+	}
+}
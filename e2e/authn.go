@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	kitep "github.com/go-kit/kit/endpoint"
+	"github.com/go-redis/redis/v8"
+	"github.com/patrickmn/go-cache"
+
+	authnconf "github.com/AyushSenapati/reactive-micro/authnsvc/conf"
+	authnep "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/endpoint"
+	authnpe "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/lib/policy-enforcer"
+	authnlog "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/logger"
+	authnrepo "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/repo"
+	authnsvc "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/service"
+	authnhttp "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/transport/http"
+	authnnats "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/transport/nats"
+)
+
+// bootAuthnSvc boots authnsvc on sqlite with miniredis as the token blacklist
+func (h *Harness) bootAuthnSvc() {
+	c := &authnconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-authn-svc"}
+	c.AuthzSvcUrl = h.AuthzURL + "/v1/authzsvc/policies"
+	c.Auth.SecretKey = secretKey
+	c.Auth.Issuer = "e2e"
+	c.Auth.AccessTokenTTL = 30 * time.Minute
+	c.Auth.RefreshTokenTTL = time.Hour
+	c.Auth.AccessKID = "id_at"
+	c.Auth.RefreshKID = "id_rt"
+	authnconf.C = c
+
+	logger := authnlog.NewLogger(c.Env)
+	logger.Configure(authnlog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.AuthnDB = h.newDB("authnsvc")
+	accntRepo := authnrepo.NewBasicUserRepo(h.AuthnDB)
+
+	// accounts can only be created with one of the existing roles
+	for _, role := range []string{"customer", "seller"} {
+		if _, err := accntRepo.CreateRole(context.Background(), role); err != nil {
+			h.t.Fatalf("authnsvc: error creating role-%s [%v]", role, err)
+		}
+	}
+
+	h.Redis = miniredis.NewMiniRedis()
+	if err := h.Redis.Start(); err != nil {
+		h.t.Fatalf("miniredis: %v", err)
+	}
+	h.t.Cleanup(h.Redis.Close)
+	authRepo := authnrepo.NewBasicAuthRepo(redis.NewClient(&redis.Options{Addr: h.Redis.Addr()}))
+
+	ps, err := authnpe.NewCachedPolicyStorageMW(
+		c.AuthzSvcUrl, []string{"accounts", "roles"}, cache.New(5*time.Minute, 10*time.Minute))
+	if err != nil {
+		h.t.Fatalf("authnsvc: error initialising policy storage [%v]", err)
+	}
+	pe, err := authnpe.NewPolicyEnforcer(ps)
+	if err != nil {
+		h.t.Fatalf("authnsvc: error initialising policy enforcer [%v]", err)
+	}
+
+	svc := authnsvc.New(
+		logger, []authnsvc.Middleware{authnsvc.NewAuthzMW(pe)},
+		authnsvc.WithRepo(accntRepo),
+		authnsvc.WithAuthNRepo(authRepo),
+		authnsvc.WithNATSEncodedConn(nc),
+		authnsvc.WithPolicyStorage(ps),
+	)
+	if svc == nil {
+		h.t.Fatal("authnsvc: error initialising service")
+	}
+
+	allMethods := []string{"Login", "CreateAccount", "ListAccount", "DeleteAccount", "ListEvents"}
+	securedMethods := []string{"ListAccount", "DeleteAccount"}
+	epMW := map[string][]kitep.Middleware{}
+	for _, method := range securedMethods {
+		epMW[method] = append(epMW[method], authnep.NewJWTTokenParsingMW(c.Auth.SecretKey))
+	}
+	eps := authnep.New(svc, epMW)
+
+	srv := httptest.NewServer(authnhttp.NewHTTPHandler(
+		eps, httpOptions(allMethods, securedMethods, authnhttp.ErrorEncoder)))
+	h.t.Cleanup(srv.Close)
+	h.AuthnURL = srv.URL
+
+	if _, err := svc.BackfillACL(context.Background()); err != nil {
+		h.t.Fatalf("authnsvc: error backfilling the acl projection [%v]", err)
+	}
+
+	eh := authnnats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("authnsvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+}
@@ -0,0 +1,47 @@
+package e2e
+
+import (
+	"net/http/httptest"
+
+	kitep "github.com/go-kit/kit/endpoint"
+
+	authzconf "github.com/AyushSenapati/reactive-micro/authzsvc/conf"
+	authzep "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/endpoint"
+	authzlog "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/logger"
+	authzrepo "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/repo"
+	authzsvc "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/service"
+	authzhttp "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/transport/http"
+	authznats "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/transport/nats"
+)
+
+// bootAuthzSvc boots authzsvc with an in-memory policy store in place of mongo
+func (h *Harness) bootAuthzSvc() {
+	c := &authzconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-authz-svc"}
+	authzconf.C = c
+
+	logger := authzlog.NewLogger(c.Env)
+	logger.Configure(authzlog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.AuthzRepo = authzrepo.NewInMemAuthzRepo()
+
+	svc := authzsvc.New(logger, authzsvc.WithRepo(h.AuthzRepo), authzsvc.WithNATSEncodedConn(nc))
+	if svc == nil {
+		h.t.Fatal("authzsvc: error initialising service")
+	}
+
+	eps := authzep.New(svc, map[string][]kitep.Middleware{})
+	allMethods := []string{"UpsertPolicy", "ListPolicy", "RemovePolicy", "RemovePolicyBySub", "ListEvents"}
+	srv := httptest.NewServer(authzhttp.NewHTTPHandler(
+		eps, httpOptions(allMethods, nil, authzhttp.ErrorEncoder)))
+	h.t.Cleanup(srv.Close)
+	h.AuthzURL = srv.URL
+
+	eh := authznats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("authzsvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+}
@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	paymodel "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
+)
+
+// TestCancelOrder checks that the customer can cancel an order and that the saga
+// waits for the reservation to be undone and the payment to be refunded before
+// marking the order canceled
+func TestCancelOrder(t *testing.T) {
+	h := NewHarness(t)
+
+	_, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	pid := h.createProduct(sellerToken, mid, "cheap-product", 10, 5.0)
+
+	t.Run("paid", func(t *testing.T) {
+		oid := h.createOrder(customerToken, pid, 1)
+		h.Eventually("order to be paid", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+		})
+		if balance := h.walletBalance(customerID); balance != 95.0 {
+			t.Fatalf("wallet balance: want 95.0, got %v", balance)
+		}
+		h.WaitForPolicy(customerID, "orders", "cancel", oid.String())
+		h.Eventually("order to be cancel requested", func() bool {
+			return h.cancelOrder(customerToken, oid) == http.StatusOK
+		})
+
+		// paymentsvc refunds the payable and the saga waits for it
+		h.Eventually("order to be canceled", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusCanceled
+		})
+		if balance := h.walletBalance(customerID); balance != 100.0 {
+			t.Errorf("wallet balance: want 100.0, got %v", balance)
+		}
+		var refunds []paymodel.Transaction
+		h.PaymentDB.Where("order_id = ? and is_credit = ?", oid, true).Find(&refunds)
+		if len(refunds) != 1 || refunds[0].RefundOf == nil || refunds[0].Amount != 5.0 {
+			t.Errorf("refund transactions: unexpected %+v", refunds)
+		}
+		_, saga := h.getSaga(customerToken, oid)
+		if last := saga.History[len(saga.History)-1]; last.To != string(ordermodel.SagaStateCanceled) {
+			t.Errorf("saga history: unexpected last transition %+v", last)
+		}
+
+		// the status history tells what moved the order along the way
+		code, history := h.getOrderHistory(customerToken, oid)
+		if code != http.StatusOK {
+			t.Fatalf("order history: want status 200, got %d", code)
+		}
+		want := []struct{ to, eventName string }{
+			{"pending", ""},
+			{"payment_pending", "EventProductReserved"},
+			{"paid", "EventPayment"},
+			{"cancel_requested", ""},
+			{"canceled", ""},
+		}
+		if len(history.History) != len(want) {
+			t.Fatalf("order history: unexpected %+v", history.History)
+		}
+		for i, w := range want {
+			c := history.History[i]
+			if c.To != w.to || (w.eventName != "" && (c.EventName != w.eventName || c.EventID == "")) {
+				t.Errorf("order history [%d]: want %+v, got %+v", i, w, c)
+			}
+		}
+		if c := history.History[3]; c.RequestID == "" || c.Reason != "canceled by the customer" || c.EventID != "" {
+			t.Errorf("order history of the cancel request: unexpected %+v", c)
+		}
+		if code, _ := h.getOrderHistory(sellerToken, oid); code != http.StatusForbidden {
+			t.Errorf("history of others order: want status 403, got %d", code)
+		}
+	})
+
+	t.Run("payment pending", func(t *testing.T) {
+		// paymentsvc never gets the payment command, so the order waits for the payment
+		if err := h.JS.DeleteConsumer("inventorysvc", "event-product-reserved-paymentsvc"); err != nil {
+			t.Fatal(err)
+		}
+		oid := h.createOrder(customerToken, pid, 2)
+		h.Eventually("order to wait for payment", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaymentPending
+		})
+		if qty := h.productQty(pid); qty != 7 {
+			t.Fatalf("product qty: want 7, got %d", qty)
+		}
+
+		if code := h.cancelOrder(sellerToken, oid); code != http.StatusForbidden {
+			t.Errorf("cancel others order: want status 403, got %d", code)
+		}
+		h.Eventually("order to be cancel requested", func() bool {
+			return h.cancelOrder(customerToken, oid) == http.StatusOK
+		})
+
+		h.Eventually("order to be canceled", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusCanceled
+		})
+		if _, saga := h.getSaga(customerToken, oid); saga.State != string(ordermodel.SagaStateCanceled) {
+			t.Errorf("saga state: want canceled, got %q", saga.State)
+		}
+		if h.isReserved(oid) || h.productQty(pid) != 9 {
+			t.Errorf("reservation not undone: product qty %d", h.productQty(pid))
+		}
+
+		// a canceled order can't be canceled again
+		if code := h.cancelOrder(customerToken, oid); code != http.StatusConflict {
+			t.Errorf("cancel canceled order: want status 409, got %d", code)
+		}
+	})
+}
+
+func (h *Harness) cancelOrder(token string, oid uuid.UUID) int {
+	h.t.Helper()
+	return h.Do("POST", h.OrderURL+"/v1/ordersvc/orders/"+oid.String()+"/cancel", token, nil, nil)
+}
+
+type orderHistoryResponse struct {
+	History []struct {
+		From      string `json:"from"`
+		To        string `json:"to"`
+		EventID   string `json:"event_id"`
+		EventName string `json:"event_name"`
+		RequestID string `json:"req_id"`
+		Reason    string `json:"reason"`
+	} `json:"history"`
+}
+
+func (h *Harness) getOrderHistory(token string, oid uuid.UUID) (int, orderHistoryResponse) {
+	h.t.Helper()
+	var resp orderHistoryResponse
+	code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders/"+oid.String()+"/history", token, nil, &resp)
+	return code, resp
+}
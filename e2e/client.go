@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Signup creates an account with the given role through authnsvc and
+// returns the account ID along with an access token for it
+func (h *Harness) Signup(name, role string) (uint, string) {
+	h.t.Helper()
+
+	email := fmt.Sprintf("%s@e2e.local", strings.ToLower(name))
+	password := "password"
+
+	var created struct {
+		UserID uint   `json:"user_id"`
+		Err    string `json:"error"`
+	}
+	code := h.Do("POST", h.AuthnURL+"/v1/authnsvc/accounts", "", map[string]string{
+		"name": name, "email": email, "password": password, "role": role,
+	}, &created)
+	if code != http.StatusOK || created.UserID == 0 {
+		h.t.Fatalf("signup %s: got status %d [%s]", email, code, created.Err)
+	}
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	code = h.Do("POST", h.AuthnURL+"/v1/authnsvc/login", "", map[string]string{
+		"email": email, "password": password,
+	}, &login)
+	if code != http.StatusOK || login.AccessToken == "" {
+		h.t.Fatalf("login %s: got status %d", email, code)
+	}
+
+	return created.UserID, login.AccessToken
+}
+
+// WaitForPolicy blocks until authzsvc has stored the given policy for the account
+func (h *Harness) WaitForPolicy(aid uint, rtype, act, rid string) {
+	h.t.Helper()
+
+	policy := fmt.Sprintf("%d:%s:%s:%s", aid, rtype, act, rid)
+	h.Eventually("policy "+policy, func() bool {
+		policies, err := h.AuthzRepo.ListPolicy(context.Background(), fmt.Sprint(aid), rtype)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		for _, p := range policies {
+			if p == policy {
+				return true
+			}
+		}
+		return false
+	})
+}
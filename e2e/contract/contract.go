@@ -0,0 +1,344 @@
+// Package contract implements consumer driven contract checks for the event payloads.
+//
+// Every service keeps its own copy of the payload structs, so a renamed JSON tag on
+// one side silently produces zero values on the other. Consumers record the fields
+// they read through the payload prototype registered in their event registry and
+// producers verify their golden sample payloads against all those contracts as well
+// as against the dtypes declared in events.json.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// supported dtypes of events.json
+const (
+	DTypeInt    = "int"
+	DTypeFloat  = "float"
+	DTypeString = "string"
+	DTypeUUID   = "uuid"
+	DTypeBool   = "bool"
+	// a list of objects, whose fields are declared in items
+	DTypeList = "list"
+)
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+type Field struct {
+	Name  string `json:"name"`
+	DType string `json:"dtype"`
+	// Validate holds the validation rules of the field. ex: required,min=1
+	Validate string `json:"validate"`
+	// Items holds the fields of the elements of a list
+	Items []Field `json:"items,omitempty"`
+}
+
+// Event is the service agnostic view of an event registry entry
+type Event struct {
+	ReqChan string
+	Payload interface{}
+}
+
+// Contract holds the fields a consumer reads from an event
+type Contract struct {
+	Consumer string
+	Event    string
+	Fields   []Field
+}
+
+// NewContract records the fields of the payload struct the consumer decodes the event into
+func NewContract(consumer, event string, payload interface{}) (Contract, error) {
+	fields, err := FieldsOf(payload)
+	if err != nil {
+		return Contract{}, fmt.Errorf("contract %s/%s: %v", consumer, event, err)
+	}
+	return Contract{Consumer: consumer, Event: event, Fields: fields}, nil
+}
+
+// FieldsOf derives the JSON fields and their dtypes from a payload struct.
+// Embedded structs are flattened the same way encoding/json does.
+func FieldsOf(payload interface{}) ([]Field, error) {
+	t := reflect.TypeOf(payload)
+	if t == nil {
+		return nil, fmt.Errorf("payload prototype is not set")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("payload must be a struct, got %s", t)
+	}
+	return fieldsOf(t)
+}
+
+func fieldsOf(t reflect.Type) (fields []Field, err error) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded, err := fieldsOf(ft)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := Field{Name: name, Validate: sf.Tag.Get("validate")}
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+			f.DType = DTypeList
+			if f.Items, err = fieldsOf(ft.Elem()); err != nil {
+				return nil, fmt.Errorf("field %s: %v", name, err)
+			}
+		} else if f.DType, err = dtypeOf(ft); err != nil {
+			return nil, fmt.Errorf("field %s: %v", name, err)
+		}
+		fields = append(fields, f)
+	}
+	return
+}
+
+func dtypeOf(t reflect.Type) (string, error) {
+	if t == uuidType {
+		return DTypeUUID, nil
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return DTypeInt, nil
+	case reflect.Float32, reflect.Float64:
+		return DTypeFloat, nil
+	case reflect.String:
+		return DTypeString, nil
+	case reflect.Bool:
+		return DTypeBool, nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// CatalogEvent is an entry of events.json
+type CatalogEvent struct {
+	Description string   `json:"description"`
+	Fields      []Field  `json:"fields"`
+	Producers   []string `json:"producers"`
+	Subscribers []string `json:"subscribers"`
+}
+
+// Catalog maps the event names of events.json (i.e. event-order-created) to their details
+type Catalog map[string]CatalogEvent
+
+func LoadCatalog(fname string) (Catalog, error) {
+	var c Catalog
+	if err := readJSONFile(fname, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CatalogName converts the registry name of an event to its events.json name
+// ex: EventOrderCreated -> event-order-created
+func CatalogName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Subscription tells which service consumes the events published to ReqChan
+type Subscription struct {
+	ReqChan string
+	Service string
+}
+
+// LoadSubscriptions derives the subscriptions from the JetStream consumer configs.
+// By convention the deliver subject of every consumer is {req_chan}.{consumer_svc}
+func LoadSubscriptions(consumersDir string) (subs []Subscription, err error) {
+	files, err := ioutil.ReadDir(consumersDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fo := range files {
+		if fo.IsDir() || !strings.HasSuffix(fo.Name(), ".json") {
+			continue
+		}
+		var cc struct {
+			DeliverSubject string `json:"deliver_subject"`
+		}
+		if err := readJSONFile(path.Join(consumersDir, fo.Name()), &cc); err != nil {
+			return nil, err
+		}
+		i := strings.LastIndex(cc.DeliverSubject, ".")
+		if i < 0 {
+			return nil, fmt.Errorf("%s: invalid deliver subject %q", fo.Name(), cc.DeliverSubject)
+		}
+		subs = append(subs, Subscription{ReqChan: cc.DeliverSubject[:i], Service: cc.DeliverSubject[i+1:]})
+	}
+	return
+}
+
+// VerifySample checks that the sample payload carries all the given fields with matching dtypes
+func VerifySample(sample []byte, fields []Field) (errs []error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(sample, &obj); err != nil {
+		return []error{fmt.Errorf("sample is not a JSON object [%v]", err)}
+	}
+
+	for _, f := range fields {
+		raw, found := obj[f.Name]
+		if !found {
+			errs = append(errs, fmt.Errorf("field %s is missing", f.Name))
+			continue
+		}
+		if f.DType == DTypeList {
+			errs = append(errs, verifyList(raw, f)...)
+			continue
+		}
+		if err := checkDType(raw, f.DType); err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %v", f.Name, err))
+		}
+	}
+	return
+}
+
+// verifyList checks every element of the list against the fields of its items
+func verifyList(raw json.RawMessage, f Field) (errs []error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return []error{fmt.Errorf("field %s: expected %s, got %s", f.Name, DTypeList, string(raw))}
+	}
+	for i, elem := range elems {
+		for _, err := range VerifySample(elem, f.Items) {
+			errs = append(errs, fmt.Errorf("field %s[%d]: %v", f.Name, i, err))
+		}
+	}
+	return
+}
+
+// VerifyProducerSample checks that the sample is exactly what the producer
+// payload struct encodes to, so the golden sample can't drift from the code
+func VerifyProducerSample(sample []byte, payload interface{}) []error {
+	fields, err := FieldsOf(payload)
+	if err != nil {
+		return []error{err}
+	}
+	errs := VerifySample(sample, fields)
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(sample, &obj); err != nil {
+		return errs
+	}
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Name] = true
+	}
+	var unknown []string
+	for k := range obj {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		errs = append(errs, fmt.Errorf("field %s is not written by the producer", k))
+	}
+	return errs
+}
+
+// VerifyRules checks that the validation rules of the fields match the ones declared in the catalog
+func VerifyRules(fields, catalogFields []Field) (errs []error) {
+	catalog := make(map[string]Field, len(catalogFields))
+	for _, f := range catalogFields {
+		catalog[f.Name] = f
+	}
+	for _, f := range fields {
+		want, found := catalog[f.Name]
+		if !found {
+			continue
+		}
+		if want.Validate != f.Validate {
+			errs = append(errs, fmt.Errorf("field %s: rules %q don't match events.json %q", f.Name, f.Validate, want.Validate))
+		}
+		for _, err := range VerifyRules(f.Items, want.Items) {
+			errs = append(errs, fmt.Errorf("field %s: %v", f.Name, err))
+		}
+	}
+	return
+}
+
+func checkDType(raw json.RawMessage, dtype string) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	switch dtype {
+	case DTypeInt:
+		if n, ok := v.(float64); ok && n == float64(int64(n)) {
+			return nil
+		}
+	case DTypeFloat:
+		if _, ok := v.(float64); ok {
+			return nil
+		}
+	case DTypeString:
+		if _, ok := v.(string); ok {
+			return nil
+		}
+	case DTypeUUID:
+		if s, ok := v.(string); ok {
+			if _, err := uuid.Parse(s); err == nil {
+				return nil
+			}
+		}
+	case DTypeBool:
+		if _, ok := v.(bool); ok {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown dtype %s", dtype)
+	}
+	return fmt.Errorf("expected %s, got %s", dtype, string(raw))
+}
+
+func readJSONFile(fname string, v interface{}) error {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s [%v]", fname, err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package contract
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+
+	authnevent "github.com/AyushSenapati/reactive-micro/authnsvc/pkg/event"
+	authzevent "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/event"
+	invevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	orderevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+	payevent "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/event"
+)
+
+const (
+	rootDir      = "../.."
+	catalogFile  = rootDir + "/events.json"
+	consumersDir = rootDir + "/nats-js-setup/consumer-configs"
+)
+
+// registries returns the event registry of every service keyed by the service name
+func registries() map[string]map[string]Event {
+	regs := map[string]map[string]Event{
+		"authnsvc": {}, "authzsvc": {}, "ordersvc": {}, "inventorysvc": {}, "paymentsvc": {},
+	}
+	for name, info := range authnevent.Registry.Registry {
+		regs["authnsvc"][string(name)] = Event{ReqChan: info.ReqChan, Payload: info.Payload}
+	}
+	for name, info := range authzevent.Registry.Registry {
+		regs["authzsvc"][string(name)] = Event{ReqChan: info.ReqChan, Payload: info.Payload}
+	}
+	for name, info := range orderevent.Registry.Registry {
+		regs["ordersvc"][string(name)] = Event{ReqChan: info.ReqChan, Payload: info.Payload}
+	}
+	for name, info := range invevent.Registry.Registry {
+		regs["inventorysvc"][string(name)] = Event{ReqChan: info.ReqChan, Payload: info.Payload}
+	}
+	for name, info := range payevent.Registry.Registry {
+		regs["paymentsvc"][string(name)] = Event{ReqChan: info.ReqChan, Payload: info.Payload}
+	}
+	return regs
+}
+
+func findByReqChan(reg map[string]Event, reqChan string) (string, Event, bool) {
+	for name, e := range reg {
+		if e.ReqChan == reqChan {
+			return name, e, true
+		}
+	}
+	return "", Event{}, false
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// consumerContracts records the contract of every consumer wired in the JetStream configs
+func consumerContracts(t *testing.T, catalog Catalog, regs map[string]map[string]Event) map[string][]Contract {
+	subs, err := LoadSubscriptions(consumersDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contracts := map[string][]Contract{}
+	for _, sub := range subs {
+		// the event store persists the envelopes without reading the payloads
+		if sub.Service == "eventstoresvc" {
+			continue
+		}
+		reg, found := regs[sub.Service]
+		if !found {
+			t.Errorf("unknown consumer service %s of %s", sub.Service, sub.ReqChan)
+			continue
+		}
+		name, e, found := findByReqChan(reg, sub.ReqChan)
+		if !found {
+			t.Errorf("%s consumes %s but has not registered it", sub.Service, sub.ReqChan)
+			continue
+		}
+		if ce, found := catalog[CatalogName(name)]; !found {
+			t.Errorf("%s is missing in events.json", CatalogName(name))
+		} else if !contains(ce.Subscribers, sub.Service) {
+			t.Errorf("%s is not listed as subscriber of %s in events.json", sub.Service, CatalogName(name))
+		}
+
+		c, err := NewContract(sub.Service, name, e.Payload)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, err := range VerifyRules(c.Fields, catalog[CatalogName(name)].Fields) {
+			t.Errorf("consumer %s of %s: %v", sub.Service, name, err)
+		}
+		contracts[name] = append(contracts[name], c)
+	}
+	return contracts
+}
+
+func TestProducerSamples(t *testing.T) {
+	catalog, err := LoadCatalog(catalogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regs := registries()
+	contracts := consumerContracts(t, catalog, regs)
+
+	eventNames := map[string]string{} // events.json name -> registry name
+	for _, reg := range regs {
+		for name := range reg {
+			eventNames[CatalogName(name)] = name
+		}
+	}
+	var catalogNames []string
+	for cname := range catalog {
+		catalogNames = append(catalogNames, cname)
+	}
+	sort.Strings(catalogNames)
+
+	for _, cname := range catalogNames {
+		ce := catalog[cname]
+		name := eventNames[cname]
+		for _, producer := range ce.Producers {
+			t.Run(fmt.Sprintf("%s/%s", producer, cname), func(t *testing.T) {
+				e, found := regs[producer][name]
+				if !found {
+					t.Fatalf("%s is listed as producer but has not registered the event", producer)
+				}
+
+				fname := path.Join(rootDir, producer, "pkg/event/testdata", name+".json")
+				sample, err := ioutil.ReadFile(fname)
+				if err != nil {
+					t.Fatalf("golden sample payload is missing [%v]", err)
+				}
+
+				for _, err := range VerifyProducerSample(sample, e.Payload) {
+					t.Errorf("producer struct: %v", err)
+				}
+				for _, err := range VerifySample(sample, ce.Fields) {
+					t.Errorf("events.json: %v", err)
+				}
+				fields, err := FieldsOf(e.Payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, err := range VerifyRules(fields, ce.Fields) {
+					t.Errorf("producer struct: %v", err)
+				}
+				for _, c := range contracts[name] {
+					for _, err := range VerifySample(sample, c.Fields) {
+						t.Errorf("consumer %s: %v", c.Consumer, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFieldsOf(t *testing.T) {
+	type embedded struct {
+		Sub string `json:"subject"`
+	}
+	type payload struct {
+		*embedded
+		Ignored string  `json:"-"`
+		Qty     int     `json:"quantity" validate:"min=1"`
+		Payble  float32 `json:"payble"`
+		private string
+	}
+	fields, err := FieldsOf(payload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{{"subject", DTypeString, "", nil}, {"quantity", DTypeInt, "min=1", nil}, {"payble", DTypeFloat, "", nil}}
+	if fmt.Sprint(fields) != fmt.Sprint(want) {
+		t.Errorf("want %v, got %v", want, fields)
+	}
+}
+
+func TestVerifySample(t *testing.T) {
+	fields := []Field{{Name: "order_id", DType: DTypeUUID}, {Name: "quantity", DType: DTypeInt}}
+
+	ok := []byte(`{"order_id": "0f5e6f4e-36a4-4bd4-a8f5-0c1b6e5e3a51", "quantity": 2}`)
+	if errs := VerifySample(ok, fields); len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	// a renamed tag on the producer side
+	renamed := []byte(`{"order_id": "0f5e6f4e-36a4-4bd4-a8f5-0c1b6e5e3a51", "qty": 2.5}`)
+	if errs := VerifySample(renamed, fields); len(errs) != 1 {
+		t.Errorf("want 1 error, got %v", errs)
+	}
+
+	wrongType := []byte(`{"order_id": "not-a-uuid", "quantity": 2.5}`)
+	if errs := VerifySample(wrongType, fields); len(errs) != 2 {
+		t.Errorf("want 2 errors, got %v", errs)
+	}
+}
+
+func TestVerifyListSample(t *testing.T) {
+	type line struct {
+		PID uuid.UUID `json:"product_id" validate:"required"`
+		Qty int       `json:"quantity" validate:"min=1"`
+	}
+	type payload struct {
+		Lines []line `json:"lines" validate:"min=1"`
+	}
+	fields, err := FieldsOf(payload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].DType != DTypeList || len(fields[0].Items) != 2 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	ok := []byte(`{"lines": [{"product_id": "0f5e6f4e-36a4-4bd4-a8f5-0c1b6e5e3a51", "quantity": 2}]}`)
+	if errs := VerifySample(ok, fields); len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	renamed := []byte(`{"lines": [{"product_id": "0f5e6f4e-36a4-4bd4-a8f5-0c1b6e5e3a51", "qty": 2}]}`)
+	if errs := VerifySample(renamed, fields); len(errs) != 1 {
+		t.Errorf("want 1 error, got %v", errs)
+	}
+
+	notAList := []byte(`{"lines": {"quantity": 2}}`)
+	if errs := VerifySample(notAList, fields); len(errs) != 1 {
+		t.Errorf("want 1 error, got %v", errs)
+	}
+}
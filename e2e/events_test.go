@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AyushSenapati/reactive-micro/e2e/contract"
+)
+
+// TestEventsIntrospection checks the deployment described by the _events endpoints of the services against events.json
+func TestEventsIntrospection(t *testing.T) {
+	h := NewHarness(t)
+
+	catalog, err := contract.LoadCatalog("../events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svcURLs := map[string]string{
+		"authnsvc":     h.AuthnURL,
+		"authzsvc":     h.AuthzURL,
+		"ordersvc":     h.OrderURL,
+		"inventorysvc": h.InventoryURL,
+		"paymentsvc":   h.PaymentURL,
+	}
+	subscriptions := 0
+	for svc, url := range svcURLs {
+		var events []struct {
+			Name   string `json:"name"`
+			Schema struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schema"`
+			Subscriptions []struct {
+				Subject  string `json:"subject"`
+				Consumer string `json:"consumer"`
+			} `json:"subscriptions"`
+		}
+		if code := h.Do("GET", url+"/v1/"+svc+"/_events", "", nil, &events); code != http.StatusOK {
+			t.Fatalf("%s: list events: got status %d", svc, code)
+		}
+
+		for _, e := range events {
+			ce, found := catalog[contract.CatalogName(e.Name)]
+			if !found {
+				t.Errorf("%s: %s is missing in events.json", svc, e.Name)
+				continue
+			}
+			for _, f := range ce.Fields {
+				if _, found := e.Schema.Properties[f.Name]; !found {
+					t.Errorf("%s: %s: field %s is missing in the payload schema", svc, e.Name, f.Name)
+				}
+			}
+			for _, s := range e.Subscriptions {
+				subscriptions++
+				if s.Consumer == "" {
+					t.Errorf("%s: no JetStream consumer delivers to %s", svc, s.Subject)
+				}
+				if !contains(ce.Subscribers, svc) {
+					t.Errorf("%s is not listed as subscriber of %s in events.json", svc, e.Name)
+				}
+			}
+		}
+	}
+
+	// eventstoresvc consumes all the streams but registers no events
+	want := 0
+	for _, c := range h.consumers {
+		if !strings.HasSuffix(c.Config.DeliverSubject, ".eventstoresvc") {
+			want++
+		}
+	}
+	if subscriptions != want {
+		t.Errorf("want %d subscriptions, got %d", want, subscriptions)
+	}
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
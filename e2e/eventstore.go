@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	kitep "github.com/go-kit/kit/endpoint"
+	"github.com/patrickmn/go-cache"
+
+	esconf "github.com/AyushSenapati/reactive-micro/eventstoresvc/conf"
+	esep "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/endpoint"
+	espe "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/policy-enforcer"
+	eslog "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/logger"
+	esrepo "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/repo"
+	essvc "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/service"
+	eshttp "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/transport/http"
+	esnats "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/transport/nats"
+)
+
+// bootEventStoreSvc boots eventstoresvc on sqlite
+func (h *Harness) bootEventStoreSvc() {
+	c := &esconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-eventstore-svc", ReplayLimit: 100}
+	c.AuthzSvcUrl = h.AuthzURL + "/v1/authzsvc/policies"
+	c.Auth.SecretKey = secretKey
+	// the tests listen on the deliver subjects of a consumer of their own
+	c.ReplayConsumers = []string{"e2e"}
+	esconf.C = c
+
+	logger := eslog.NewLogger(c.Env)
+	logger.Configure(eslog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.EventStoreDB = h.newDB("eventstoresvc")
+	repoObj := esrepo.NewBasicEventRepo(h.EventStoreDB)
+
+	ps, err := espe.NewCachedPolicyStorageMW(
+		c.AuthzSvcUrl, []string{"events"}, cache.New(5*time.Minute, 10*time.Minute))
+	if err != nil {
+		h.t.Fatalf("eventstoresvc: error initialising policy storage [%v]", err)
+	}
+	pe, err := espe.NewPolicyEnforcer(ps)
+	if err != nil {
+		h.t.Fatalf("eventstoresvc: error initialising policy enforcer [%v]", err)
+	}
+
+	svc := essvc.New(
+		logger, []essvc.Middleware{essvc.NewAuthzMW(pe)},
+		essvc.WithRepo(repoObj),
+		essvc.WithNATSEncodedConn(nc),
+		essvc.WithPolicyStorage(ps),
+		essvc.WithReplayLimit(c.ReplayLimit),
+		essvc.WithReplayConsumers(c.ReplayConsumers),
+	)
+	if svc == nil {
+		h.t.Fatal("eventstoresvc: error initialising service")
+	}
+
+	allMethods := []string{"ListEvent", "ReplayEvent"}
+	securedMethods := []string{"ListEvent", "ReplayEvent"}
+	epMW := map[string][]kitep.Middleware{}
+	for _, method := range securedMethods {
+		epMW[method] = append(epMW[method], esep.NewJWTTokenParsingMW(c.Auth.SecretKey))
+	}
+	eps := esep.New(svc, epMW)
+
+	srv := httptest.NewServer(eshttp.NewHTTPHandler(
+		eps, httpOptions(allMethods, securedMethods, eshttp.ErrorEncoder)))
+	h.t.Cleanup(srv.Close)
+	h.EventStoreURL = srv.URL
+
+	eh := esnats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("eventstoresvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+}
+
+// grantAdmin grants the account the events of all the services through the API of authzsvc,
+// the way an operator does
+func (h *Harness) grantAdmin(aid uint) {
+	h.t.Helper()
+	for _, act := range []string{"get", "replay"} {
+		code := h.Do("POST", h.AuthzURL+"/v1/authzsvc/policies", "", map[string]string{
+			"subject": fmt.Sprint(aid), "resource_type": "events", "resource_id": "*", "action": act,
+		}, nil)
+		if code != http.StatusOK {
+			h.t.Fatalf("grant admin: got status %d", code)
+		}
+	}
+}
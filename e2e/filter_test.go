@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+// TestListFilters lists the resources of the services through the filter grammar and orderby
+func TestListFilters(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "merchants", "*", mid.String())
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+
+	cheap := h.createProduct(sellerToken, mid, "Cheap Shoe", 10, 5.0)
+	costly := h.createProduct(sellerToken, mid, "Costly Shoe", 10, 50.0)
+	h.createProduct(sellerToken, mid, "Cheap Hat", 10, 8.0)
+
+	listProducts := func(t *testing.T, query url.Values) []uuid.UUID {
+		t.Helper()
+		var resp struct {
+			Products []struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"products"`
+		}
+		code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/products?"+query.Encode(), customerToken, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list products: got status %d", code)
+		}
+		ids := []uuid.UUID{}
+		for _, p := range resp.Products {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("products", func(t *testing.T) {
+		if ids := listProducts(t, url.Values{"price": {"lt:20"}}); len(ids) != 2 {
+			t.Errorf("products under 20: want 2, got %d", len(ids))
+		}
+		ids := listProducts(t, url.Values{
+			"price": {"lt:20"}, "name": {"contains:shoe"}, "merchant_id": {mid.String()}})
+		if len(ids) != 1 || ids[0] != cheap {
+			t.Errorf("cheap shoes: want [%v], got %v", cheap, ids)
+		}
+		// the wildcards are matched literally
+		for _, name := range []string{"contains:%", "contains:sh_e", `contains:\`} {
+			if ids := listProducts(t, url.Values{"name": {name}}); len(ids) != 0 {
+				t.Errorf("name %s: want 0, got %d", name, len(ids))
+			}
+		}
+		if ids := listProducts(t, url.Values{"price": {"gte:10"}, "qty": {"in:1,2,3"}}); len(ids) != 0 {
+			t.Errorf("products short of stock: want 0, got %d", len(ids))
+		}
+	})
+
+	t.Run("orderby", func(t *testing.T) {
+		ids := listProducts(t, url.Values{"orderby": {"price__desc"}})
+		if len(ids) != 3 || ids[0] != costly || ids[2] != cheap {
+			t.Errorf("products by price: want %v first and %v last, got %v", costly, cheap, ids)
+		}
+
+		var resp struct {
+			Error string `json:"error"`
+		}
+		for _, orderby := range []string{"password", "price__sideways", "price desc; drop table products"} {
+			code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/products?"+url.Values{"orderby": {orderby}}.Encode(),
+				customerToken, nil, &resp)
+			if code != http.StatusBadRequest {
+				t.Errorf("orderby %q: want status 400, got %d", orderby, code)
+			}
+			if !strings.Contains(resp.Error, "allowed are created_at, created_at__desc, name") {
+				t.Errorf("orderby %q: want the allowed fields listed, got %q", orderby, resp.Error)
+			}
+		}
+	})
+
+	t.Run("orders", func(t *testing.T) {
+		paid := h.createOrder(customerToken, cheap, 1)
+		h.Eventually("order to be paid", func() bool {
+			return h.orderStatus(paid) == ordermodel.OrderStatusPaid
+		})
+		// the order exceeding the wallet balance fails
+		failed := h.createOrder(customerToken, costly, 3)
+		h.Eventually("order to fail", func() bool {
+			return h.orderStatus(failed) == ordermodel.OrderStatusFailed
+		})
+		h.WaitForPolicy(customerID, "orders", "get", failed.String())
+
+		var resp struct {
+			Orders []struct {
+				OID uuid.UUID `json:"order_id"`
+			} `json:"orders"`
+		}
+		code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders?status=in:paid,payment_pending&created_at=gte:2020-01-01",
+			customerToken, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list orders: got status %d", code)
+		}
+		if len(resp.Orders) != 1 || resp.Orders[0].OID != paid {
+			t.Errorf("paid orders: want [%v], got %v", paid, resp.Orders)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, u := range []string{
+			h.InventoryURL + "/v1/inventorysvc/products?password=secret",    // not a filterable field
+			h.InventoryURL + "/v1/inventorysvc/products?price=contains:1",   // not an operator of numbers
+			h.InventoryURL + "/v1/inventorysvc/products?price=lt:cheap",     // not a number
+			h.OrderURL + "/v1/ordersvc/orders?status=in:",                   // no value
+			h.PaymentURL + "/v1/paymentsvc/transactions?is_credit=gt:false", // not an operator of bools
+		} {
+			if code := h.Do("GET", u, customerToken, nil, nil); code != http.StatusBadRequest {
+				t.Errorf("GET %s: want status 400, got %d", u, code)
+			}
+		}
+	})
+}
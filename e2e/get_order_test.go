@@ -0,0 +1,86 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type getOrderResponse struct {
+	OID    string `json:"order_id"`
+	Status string `json:"status"`
+	Lines  []struct {
+		PID        uuid.UUID `json:"product_id"`
+		Qty        int       `json:"qty"`
+		ProdName   string    `json:"product_name"`
+		UnitPrice  float32   `json:"unit_price"`
+		MerchantID uuid.UUID `json:"merchant_id"`
+	} `json:"lines"`
+	Total float32 `json:"total"`
+}
+
+// TestGetOrder checks that an order is returned with the details of its products,
+// which ordersvc learns from EventProductCreated instead of asking inventorysvc
+func TestGetOrder(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	_, otherToken := h.Signup("Other", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pen := h.createProduct(sellerToken, mid, "pen", 10, 5.0)
+	book := h.createProduct(sellerToken, mid, "book", 5, 10.0)
+	oid := h.createOrderLines(customerToken, map[uuid.UUID]int{pen: 2, book: 1})
+	h.WaitForPolicy(customerID, "orders", "get", oid.String())
+
+	var resp getOrderResponse
+	h.Eventually("order with product details", func() bool {
+		code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders/"+oid.String(), customerToken, nil, &resp)
+		if code != http.StatusOK || len(resp.Lines) != 2 {
+			return false
+		}
+		for _, l := range resp.Lines {
+			if l.ProdName == "" {
+				return false
+			}
+		}
+		return true
+	})
+	for _, l := range resp.Lines {
+		want := map[uuid.UUID]struct {
+			name  string
+			price float32
+			qty   int
+		}{pen: {"pen", 5.0, 2}, book: {"book", 10.0, 1}}[l.PID]
+		if l.ProdName != want.name || l.UnitPrice != want.price || l.Qty != want.qty || l.MerchantID != mid {
+			t.Errorf("line of %v: unexpected %+v", l.PID, l)
+		}
+	}
+
+	if resp.Total != 20.0 {
+		t.Errorf("want a quoted total of 20, got %v", resp.Total)
+	}
+
+	// the reservation reaches the projection through EventProductUpdated
+	h.Eventually("projection to see the reservation", func() bool {
+		return h.projectedQty(pen) == 8 && h.projectedQty(book) == 4
+	})
+
+	// products unknown to ordersvc are refused up front
+	code := h.Do("POST", h.OrderURL+"/v1/ordersvc/orders", customerToken,
+		map[string]interface{}{"product_id": uuid.New(), "qty": 1}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("order of unknown product: want status 400, got %d", code)
+	}
+
+	if code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders/"+oid.String(), otherToken, nil, nil); code != http.StatusForbidden {
+		t.Errorf("order of others: want status 403, got %d", code)
+	}
+	if code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders/not-an-id", customerToken, nil, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid order id: want status 400, got %d", code)
+	}
+}
@@ -0,0 +1,30 @@
+module github.com/AyushSenapati/reactive-micro/e2e
+
+go 1.16
+
+require (
+	github.com/AyushSenapati/reactive-micro/authnsvc v0.0.0
+	github.com/AyushSenapati/reactive-micro/authzsvc v0.0.0
+	github.com/AyushSenapati/reactive-micro/eventstoresvc v0.0.0
+	github.com/AyushSenapati/reactive-micro/inventorysvc v0.0.0
+	github.com/AyushSenapati/reactive-micro/ordersvc v0.0.0
+	github.com/AyushSenapati/reactive-micro/paymentsvc v0.0.0
+	github.com/alicebob/miniredis/v2 v2.14.5
+	github.com/go-kit/kit v0.10.0
+	github.com/go-redis/redis/v8 v8.9.0
+	github.com/google/uuid v1.2.0
+	github.com/nats-io/nats-server/v2 v2.2.6
+	github.com/nats-io/nats.go v1.11.0
+	github.com/patrickmn/go-cache v2.1.0+incompatible
+	gorm.io/driver/sqlite v1.1.4
+	gorm.io/gorm v1.21.10
+)
+
+replace (
+	github.com/AyushSenapati/reactive-micro/authnsvc => ../authnsvc
+	github.com/AyushSenapati/reactive-micro/authzsvc => ../authzsvc
+	github.com/AyushSenapati/reactive-micro/eventstoresvc => ../eventstoresvc
+	github.com/AyushSenapati/reactive-micro/inventorysvc => ../inventorysvc
+	github.com/AyushSenapati/reactive-micro/ordersvc => ../ordersvc
+	github.com/AyushSenapati/reactive-micro/paymentsvc => ../paymentsvc
+)
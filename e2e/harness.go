@@ -0,0 +1,260 @@
+// Package e2e boots all the reactive-micro services in-process against an
+// embedded NATS JetStream server and local stand-ins of their storages, so
+// that the whole event driven saga can be exercised from a single go test.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	authzrepo "github.com/AyushSenapati/reactive-micro/authzsvc/pkg/repo"
+	inventoryevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	inventorysvc "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/service"
+	orderevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+)
+
+const (
+	reqIDKey  = "X-Request-ID"
+	secretKey = "e2e-secret-key"
+
+	// the schedulers poll often to keep the tests of the delayed events fast
+	schedulerPollInterval = 50 * time.Millisecond
+	// long enough for the saga replies, short enough to test the timeouts
+	sagaTimeout = 2 * time.Second
+	// the sweeper leaves the orders of the tests alone, the stale orders are backdated
+	sweepInterval  = 100 * time.Millisecond
+	sweepThreshold = time.Hour
+	// inventorysvc accepts the prices within 10% of the quoted ones
+	priceTolerance = 0.1
+	// the releaser runs often, the tests which expire a reservation shorten its TTL
+	// down to the reserve and payment timeouts of the saga
+	releaseInterval   = 100 * time.Millisecond
+	minReservationTTL = 2 * sagaTimeout
+
+	// paths of the NATS JetStream configurations relative to this package
+	streamsDir   = "../nats-js-setup/stream-configs"
+	consumersDir = "../nats-js-setup/consumer-configs"
+)
+
+// Harness holds everything that was booted for a test. The DB handles and
+// repos are exposed so that tests can assert on the final state of the saga.
+type Harness struct {
+	t *testing.T
+
+	NATS *server.Server
+	JS   nats.JetStreamContext
+
+	AuthnURL     string
+	AuthzURL     string
+	OrderURL     string
+	InventoryURL string
+	// the internal listeners of the metrics
+	OrderMetricsURL     string
+	InventoryMetricsURL string
+	PaymentURL          string
+	EventStoreURL       string
+
+	AuthnDB      *gorm.DB
+	OrderDB      *gorm.DB
+	InventoryDB  *gorm.DB
+	PaymentDB    *gorm.DB
+	EventStoreDB *gorm.DB
+	AuthzRepo    authzrepo.AuthzRepo
+	Redis        *miniredis.Miniredis
+
+	InventorySvc inventorysvc.IInventoryService
+
+	OrderScheduler     *orderevent.Scheduler
+	InventoryScheduler *inventoryevent.Scheduler
+
+	consumers []jsConsumer
+}
+
+// NewHarness starts an embedded NATS server with JetStream, applies the
+// nats-js-setup configs and boots all the services. Everything is torn
+// down when the test completes.
+func NewHarness(t *testing.T) *Harness {
+	t.Helper()
+	h := &Harness{t: t}
+
+	h.startNATS()
+	h.bootAuthzSvc()
+	h.bootAuthnSvc()
+	h.bootOrderSvc()
+	h.bootInventorySvc()
+	h.bootPaymentSvc()
+	h.bootEventStoreSvc()
+	h.waitForSubscriptions()
+
+	return h
+}
+
+func (h *Harness) startNATS() {
+	opts := &server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1, // random port
+		JetStream: true,
+		StoreDir:  h.t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		h.t.Fatalf("nats: error creating server [%v]", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		h.t.Fatal("nats: server not ready for connections")
+	}
+	h.t.Cleanup(srv.Shutdown)
+	h.NATS = srv
+
+	conn, err := nats.Connect(srv.ClientURL(), nats.Name("e2e-harness"))
+	if err != nil {
+		h.t.Fatalf("nats: error connecting [%v]", err)
+	}
+	h.t.Cleanup(conn.Close)
+
+	h.JS, err = conn.JetStream()
+	if err != nil {
+		h.t.Fatalf("nats: error getting jetstream context [%v]", err)
+	}
+
+	h.consumers, err = applyJSConfigs(h.JS, streamsDir, consumersDir)
+	if err != nil {
+		h.t.Fatalf("nats: %v", err)
+	}
+}
+
+// waitForSubscriptions blocks until every configured consumer has a
+// subscriber on its deliver subject. Without this JetStream would push the
+// first events to nobody and redeliver them only after the ack wait.
+func (h *Harness) waitForSubscriptions() {
+	acc := h.NATS.GlobalAccount()
+	for _, c := range h.consumers {
+		subject := c.Config.DeliverSubject
+		h.Eventually(fmt.Sprintf("subscription on %s", subject), func() bool {
+			return acc.SubscriptionInterest(subject)
+		})
+	}
+}
+
+func (h *Harness) newEncodedConn(name string) *nats.EncodedConn {
+	conn, err := nats.Connect(h.NATS.ClientURL(), nats.Name(name))
+	if err != nil {
+		h.t.Fatalf("nats: error connecting %s [%v]", name, err)
+	}
+	nc, err := nats.NewEncodedConn(conn, "json")
+	if err != nil {
+		h.t.Fatalf("nats: error creating encoded conn for %s [%v]", name, err)
+	}
+	return nc
+}
+
+// newDB opens a file backed sqlite DB which stands in for postgres.
+// sqlite allows a single writer, so the pool is limited to one connection
+// to serialise the concurrent event handlers instead of failing with SQLITE_BUSY.
+func (h *Harness) newDB(name string) *gorm.DB {
+	dsn := filepath.Join(h.t.TempDir(), name+".db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		h.t.Fatalf("sqlite: error opening %s [%v]", dsn, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		h.t.Fatalf("sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	h.t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// httpOptions mirrors what cmd/service of every service sets on its HTTP server
+func httpOptions(
+	allMethods, securedMethods []string, errEncoder kithttp.ErrorEncoder) map[string][]kithttp.ServerOption {
+
+	options := map[string][]kithttp.ServerOption{}
+	for _, method := range allMethods {
+		options[method] = append(options[method],
+			kithttp.ServerErrorEncoder(errEncoder),
+			kithttp.ServerBefore(func(c context.Context, r *http.Request) context.Context {
+				return context.WithValue(c, reqIDKey, r.Header.Get(reqIDKey))
+			}),
+		)
+	}
+	for _, method := range securedMethods {
+		options[method] = append(options[method], kithttp.ServerBefore(kitjwt.HTTPToContext()))
+	}
+	return options
+}
+
+// Eventually polls cond until it returns true or fails the test after a timeout.
+// Every hop of the saga is asynchronous, so all the assertions on it use this.
+func (h *Harness) Eventually(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	h.t.Fatalf("timed out waiting for %s", what)
+}
+
+// Do sends a JSON request to one of the services and decodes the JSON response into out
+func (h *Harness) Do(method, url, token string, body, out interface{}) int {
+	h.t.Helper()
+	return h.DoWithHeader(method, url, token, nil, body, out)
+}
+
+// DoWithHeader is Do with extra request headers
+func (h *Harness) DoWithHeader(method, url, token string, header http.Header, body, out interface{}) int {
+	h.t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			h.t.Fatalf("error encoding request body [%v]", err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &reqBody)
+	if err != nil {
+		h.t.Fatalf("error creating request [%v]", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(reqIDKey, uuid.New().String())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s failed [%v]", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,176 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	orderidem "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/idempotency"
+	orderlog "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	paymodel "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
+)
+
+// TestIdempotencyKey retries the mutating requests with the same Idempotency-Key
+// and expects them to take effect only once
+func TestIdempotencyKey(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.WaitForPolicy(customerID, "transactions", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "merchants", "*", mid.String())
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+
+	t.Run("create order", func(t *testing.T) {
+		pid := h.createProduct(sellerToken, mid, "idempotent-product", 10, 1.0)
+		// wait for the product projection of ordersvc before the keyed requests
+		oid := h.createOrder(customerToken, pid, 1)
+
+		header := http.Header{"Idempotency-Key": []string{uuid.NewString()}}
+		body := map[string]interface{}{"product_id": pid, "qty": 1}
+		var first, retry struct {
+			OID uuid.UUID `json:"order_id"`
+		}
+		if code := h.DoWithHeader("POST", h.OrderURL+"/v1/ordersvc/orders",
+			customerToken, header, body, &first); code != http.StatusOK {
+			t.Fatalf("create order: got status %d", code)
+		}
+		if code := h.DoWithHeader("POST", h.OrderURL+"/v1/ordersvc/orders",
+			customerToken, header, body, &retry); code != http.StatusOK {
+			t.Fatalf("retry create order: got status %d", code)
+		}
+		if retry.OID != first.OID {
+			t.Errorf("retried order id: want %v, got %v", first.OID, retry.OID)
+		}
+		var orders int64
+		h.OrderDB.Model(&ordermodel.Order{}).Where("accnt_id = ?", customerID).Count(&orders)
+		if orders != 2 {
+			t.Errorf("orders: want 2, got %d", orders)
+		}
+
+		body["qty"] = 2
+		if code := h.DoWithHeader("POST", h.OrderURL+"/v1/ordersvc/orders",
+			customerToken, header, body, nil); code != http.StatusUnprocessableEntity {
+			t.Errorf("reused key with another body: want status 422, got %d", code)
+		}
+
+		// let the payments settle before the wallet is recharged
+		h.Eventually("orders to be paid", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaid &&
+				h.orderStatus(first.OID) == ordermodel.OrderStatusPaid
+		})
+	})
+
+	t.Run("recharge wallet", func(t *testing.T) {
+		before := h.walletBalance(customerID)
+		header := http.Header{"Idempotency-Key": []string{uuid.NewString()}}
+		body := map[string]interface{}{"amount": 25.0}
+		var first, retry struct {
+			TXID uuid.UUID `json:"transaction_id"`
+		}
+		if code := h.DoWithHeader("POST", h.PaymentURL+"/v1/paymentsvc/recharge-wallet",
+			customerToken, header, body, &first); code != http.StatusOK {
+			t.Fatalf("recharge wallet: got status %d", code)
+		}
+		if code := h.DoWithHeader("POST", h.PaymentURL+"/v1/paymentsvc/recharge-wallet",
+			customerToken, header, body, &retry); code != http.StatusOK {
+			t.Fatalf("retry recharge wallet: got status %d", code)
+		}
+		if retry.TXID != first.TXID {
+			t.Errorf("retried transaction id: want %v, got %v", first.TXID, retry.TXID)
+		}
+		if balance := h.walletBalance(customerID); balance != before+25.0 {
+			t.Errorf("wallet balance: want %v, got %v", before+25.0, balance)
+		}
+		var credits int64
+		h.PaymentDB.Model(&paymodel.Transaction{}).Where("id = ?", first.TXID).Count(&credits)
+		if credits != 1 {
+			t.Errorf("credit transactions: want 1, got %d", credits)
+		}
+
+		body["amount"] = 30.0
+		if code := h.DoWithHeader("POST", h.PaymentURL+"/v1/paymentsvc/recharge-wallet",
+			customerToken, header, body, nil); code != http.StatusUnprocessableEntity {
+			t.Errorf("reused key with another body: want status 422, got %d", code)
+		}
+	})
+}
+
+// TestIdempotencyKeyPurge checks that the expired keys are purged
+// while the ones which are still valid are kept
+func TestIdempotencyKeyPurge(t *testing.T) {
+	h := &Harness{t: t}
+	h.OrderDB = h.newDB("ordersvc")
+	ctx := context.Background()
+
+	expired := orderidem.NewGormStore(h.OrderDB, -time.Minute, time.Minute)
+	if _, _, err := expired.Begin(ctx, "1", "expired", ""); err != nil {
+		t.Fatal(err)
+	}
+	store := orderidem.NewGormStore(h.OrderDB, time.Hour, time.Minute)
+	if _, _, err := store.Begin(ctx, "1", "valid", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	purger, err := orderidem.NewPurger(orderlog.NewLogger("test"), store, schedulerPollInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go purger.Execute()
+	defer purger.Interrupt(nil)
+
+	h.Eventually("expired key to be purged", func() bool {
+		var keys []orderidem.Record
+		h.OrderDB.Find(&keys)
+		return len(keys) == 1 && keys[0].Key == "valid"
+	})
+}
+
+// TestIdempotencyStaleClaim checks that a key whose request never completed nor
+// released it, as its process died, is taken over by a retry once its lease is over
+func TestIdempotencyStaleClaim(t *testing.T) {
+	h := &Harness{t: t}
+	h.OrderDB = h.newDB("ordersvc")
+	ctx := context.Background()
+	store := orderidem.NewGormStore(h.OrderDB, time.Hour, time.Minute)
+
+	for _, key := range []string{"running", "completed"} {
+		if _, claimed, err := store.Begin(ctx, "1", key, "first"); err != nil || !claimed {
+			t.Fatalf("claim %s: got %v [%v]", key, claimed, err)
+		}
+	}
+	if err := store.Complete(ctx, "1", "completed", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, claimed, err := store.Begin(ctx, "1", "running", "retry"); err != nil || claimed {
+		t.Fatalf("retry within the lease: want it in progress, got claimed %v [%v]", claimed, err)
+	}
+
+	// the process which claimed the keys died past the lease
+	err := h.OrderDB.Model(&orderidem.Record{}).Where("1 = 1").
+		Update("claimed_at", time.Now().Add(-2*time.Minute)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, claimed, err := store.Begin(ctx, "1", "running", "retry")
+	if err != nil || !claimed || rec.Fingerprint != "retry" {
+		t.Errorf("retry past the lease: want it claimed, got claimed %v, %+v [%v]", claimed, rec, err)
+	}
+	rec, claimed, err = store.Begin(ctx, "1", "completed", "retry")
+	if err != nil || claimed || !rec.Completed || rec.Fingerprint != "first" {
+		t.Errorf("completed key: want its response kept, got claimed %v, %+v [%v]", claimed, rec, err)
+	}
+}
@@ -0,0 +1,106 @@
+package e2e
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	kitep "github.com/go-kit/kit/endpoint"
+	"github.com/patrickmn/go-cache"
+
+	inventoryconf "github.com/AyushSenapati/reactive-micro/inventorysvc/conf"
+	inventoryep "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/endpoint"
+	inventoryevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	inventorype "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/policy-enforcer"
+	inventorylog "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
+	inventoryrepo "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/repo"
+	inventorysvc "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/service"
+	inventoryhttp "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/transport/http"
+	inventorynats "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/transport/nats"
+)
+
+// bootInventorySvc boots inventorysvc on sqlite
+func (h *Harness) bootInventorySvc() {
+	c := &inventoryconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-inventory-svc"}
+	c.AuthzSvcUrl = h.AuthzURL + "/v1/authzsvc/policies"
+	c.Auth.SecretKey = secretKey
+	inventoryconf.C = c
+
+	logger := inventorylog.NewLogger(c.Env)
+	logger.Configure(inventorylog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.InventoryDB = h.newDB("inventorysvc")
+	repoObj, err := inventoryrepo.NewBasicOrderRepo(h.InventoryDB)
+	if err != nil {
+		h.t.Fatalf("inventorysvc: error initialising repo [%v]", err)
+	}
+
+	scheduler, err := inventoryevent.NewScheduler(logger, h.InventoryDB, nc, inventoryevent.WithPollInterval(schedulerPollInterval))
+	if err != nil {
+		h.t.Fatalf("inventorysvc: error initialising event scheduler [%v]", err)
+	}
+	h.InventoryScheduler = scheduler
+
+	ps, err := inventorype.NewCachedPolicyStorageMW(
+		c.AuthzSvcUrl, []string{"merchants", "products", "reserved_products"}, cache.New(5*time.Minute, 10*time.Minute))
+	if err != nil {
+		h.t.Fatalf("inventorysvc: error initialising policy storage [%v]", err)
+	}
+	pe, err := inventorype.NewPolicyEnforcer(ps)
+	if err != nil {
+		h.t.Fatalf("inventorysvc: error initialising policy enforcer [%v]", err)
+	}
+
+	svc := inventorysvc.New(
+		logger, []inventorysvc.Middleware{inventorysvc.NewAuthzMW(pe)},
+		inventorysvc.WithRepo(repoObj),
+		inventorysvc.WithNATSEncodedConn(nc),
+		inventorysvc.WithPolicyStorage(ps),
+		inventorysvc.WithScheduler(scheduler),
+		inventorysvc.WithPriceTolerance(priceTolerance),
+		inventorysvc.WithMinReservationTTL(minReservationTTL),
+	)
+	if svc == nil {
+		h.t.Fatal("inventorysvc: error initialising service")
+	}
+
+	allMethods := []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct", "UpdateProduct", "DeleteProduct", "RestockProduct", "ListStockMovement", "ListEvents"}
+	securedMethods := []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct", "UpdateProduct", "DeleteProduct", "RestockProduct", "ListStockMovement"}
+	epMW := map[string][]kitep.Middleware{}
+	for _, method := range securedMethods {
+		epMW[method] = append(epMW[method], inventoryep.NewJWTTokenParsingMW(c.Auth.SecretKey))
+	}
+	eps := inventoryep.New(svc, epMW)
+
+	srv := httptest.NewServer(inventoryhttp.NewHTTPHandler(
+		eps, httpOptions(allMethods, securedMethods, inventoryhttp.ErrorEncoder)))
+	h.t.Cleanup(srv.Close)
+	h.InventoryURL = srv.URL
+	metricsSrv := httptest.NewServer(inventoryhttp.NewMetricsHandler())
+	h.t.Cleanup(metricsSrv.Close)
+	h.InventoryMetricsURL = metricsSrv.URL
+	h.InventorySvc = svc
+
+	if _, err := svc.BackfillACL(context.Background()); err != nil {
+		h.t.Fatalf("inventorysvc: error backfilling the acl projection [%v]", err)
+	}
+
+	eh := inventorynats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("inventorysvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+
+	go scheduler.Execute()
+	h.t.Cleanup(func() { scheduler.Interrupt(nil) })
+
+	releaser, err := inventorysvc.NewReleaser(logger, svc, releaseInterval)
+	if err != nil {
+		h.t.Fatalf("inventorysvc: error initialising reservation releaser [%v]", err)
+	}
+	go releaser.Execute()
+	h.t.Cleanup(func() { releaser.Interrupt(nil) })
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// jsConsumer pairs a consumer config with the stream it belongs to
+type jsConsumer struct {
+	Stream string
+	Config nats.ConsumerConfig
+}
+
+// loadJSConsumers reads all the consumer configs from the given dir. Like setup-nats-js
+// the stream name is taken from the file name i.e. {stream}.{consumer_durable_name}.json
+func loadJSConsumers(consumersDir string) (consumers []jsConsumer, err error) {
+	files, err := ioutil.ReadDir(consumersDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fo := range files {
+		if fo.IsDir() || !strings.HasSuffix(fo.Name(), ".json") {
+			continue
+		}
+		c := jsConsumer{Stream: strings.Split(fo.Name(), ".")[0]}
+		if err := readJSONFile(path.Join(consumersDir, fo.Name()), &c.Config); err != nil {
+			return nil, err
+		}
+		consumers = append(consumers, c)
+	}
+	return
+}
+
+// applyJSConfigs creates all the streams and consumers present in the
+// nats-js-setup config dirs using the JetStream API instead of the nats cli
+func applyJSConfigs(js nats.JetStreamContext, streamsDir, consumersDir string) ([]jsConsumer, error) {
+	files, err := ioutil.ReadDir(streamsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fo := range files {
+		if fo.IsDir() || !strings.HasSuffix(fo.Name(), ".json") {
+			continue
+		}
+		var sc nats.StreamConfig
+		if err := readJSONFile(path.Join(streamsDir, fo.Name()), &sc); err != nil {
+			return nil, err
+		}
+		if _, err := js.AddStream(&sc); err != nil {
+			return nil, fmt.Errorf("error adding stream-%s [%v]", sc.Name, err)
+		}
+	}
+
+	consumers, err := loadJSConsumers(consumersDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range consumers {
+		cc := c.Config
+		if _, err := js.AddConsumer(c.Stream, &cc); err != nil {
+			return nil, fmt.Errorf("error adding consumer-%s [%v]", cc.Durable, err)
+		}
+	}
+
+	return consumers, nil
+}
+
+func readJSONFile(fname string, v interface{}) error {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s [%v]", fname, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	kitep "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/patrickmn/go-cache"
+
+	orderconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
+	orderdto "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	orderep "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/endpoint"
+	orderevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+	orderidem "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/idempotency"
+	orderpe "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/policy-enforcer"
+	orderlog "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+	orderrepo "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/repo"
+	ordersvc "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/service"
+	orderhttp "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/transport/http"
+	ordernats "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/transport/nats"
+)
+
+// bootOrderSvc boots ordersvc on sqlite
+func (h *Harness) bootOrderSvc() {
+	c := &orderconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-order-svc"}
+	c.AuthzSvcUrl = h.AuthzURL + "/v1/authzsvc/policies"
+	c.Auth.SecretKey = secretKey
+	orderconf.C = c
+
+	logger := orderlog.NewLogger(c.Env)
+	logger.Configure(orderlog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.OrderDB = h.newDB("ordersvc")
+	repoObj, err := orderrepo.NewBasicOrderRepo(h.OrderDB)
+	if err != nil {
+		h.t.Fatalf("ordersvc: error initialising repo [%v]", err)
+	}
+	sagaRepo := orderrepo.NewBasicSagaRepo(h.OrderDB)
+	productRepo := orderrepo.NewBasicProductRepo(h.OrderDB)
+
+	scheduler, err := orderevent.NewScheduler(logger, h.OrderDB, nc, orderevent.WithPollInterval(schedulerPollInterval))
+	if err != nil {
+		h.t.Fatalf("ordersvc: error initialising event scheduler [%v]", err)
+	}
+	h.OrderScheduler = scheduler
+
+	ps, err := orderpe.NewCachedPolicyStorageMW(
+		c.AuthzSvcUrl, []string{"orders"}, cache.New(5*time.Minute, 10*time.Minute))
+	if err != nil {
+		h.t.Fatalf("ordersvc: error initialising policy storage [%v]", err)
+	}
+	pe, err := orderpe.NewPolicyEnforcer(ps)
+	if err != nil {
+		h.t.Fatalf("ordersvc: error initialising policy enforcer [%v]", err)
+	}
+
+	svc := ordersvc.New(
+		logger, []ordersvc.Middleware{ordersvc.NewAuthzMW(pe)},
+		ordersvc.WithRepo(repoObj),
+		ordersvc.WithNATSEncodedConn(nc),
+		ordersvc.WithPolicyStorage(ps),
+		ordersvc.WithScheduler(scheduler),
+		ordersvc.WithSagaRepo(sagaRepo),
+		ordersvc.WithProductRepo(productRepo),
+		ordersvc.WithSagaTimeouts(sagaTimeout, sagaTimeout),
+		ordersvc.WithCancelableStatuses([]string{"pending", "payment_pending", "paid"}),
+		ordersvc.WithSweepThresholds(map[string]time.Duration{
+			"pending":         sweepThreshold,
+			"payment_pending": sweepThreshold,
+		}),
+		ordersvc.WithStreamPollInterval(schedulerPollInterval),
+	)
+	if svc == nil {
+		h.t.Fatal("ordersvc: error initialising service")
+	}
+
+	allMethods := []string{"CreateOrder", "ListOrder", "GetOrder", "GetOrderHistory", "CancelOrder", "GetSaga", "StreamOrderStatus", "ListEvents"}
+	securedMethods := []string{"CreateOrder", "ListOrder", "GetOrder", "GetOrderHistory", "CancelOrder", "GetSaga", "StreamOrderStatus"}
+	epMW := map[string][]kitep.Middleware{}
+	epMW["CreateOrder"] = append(epMW["CreateOrder"], orderep.NewIdempotencyMW(
+		orderidem.NewGormStore(h.OrderDB, 24*time.Hour, time.Minute), "CreateOrder", orderdto.CreateOrderResponse{}))
+	for _, method := range securedMethods {
+		epMW[method] = append(epMW[method], orderep.NewJWTTokenParsingMW(c.Auth.SecretKey))
+	}
+	eps := orderep.New(svc, epMW)
+
+	options := httpOptions(allMethods, securedMethods, orderhttp.ErrorEncoder)
+	options["CreateOrder"] = append(options["CreateOrder"], kithttp.ServerBefore(orderidem.HTTPToContext()))
+	srv := httptest.NewServer(orderhttp.NewHTTPHandler(eps, options))
+	h.t.Cleanup(srv.Close)
+	h.OrderURL = srv.URL
+	metricsSrv := httptest.NewServer(orderhttp.NewMetricsHandler())
+	h.t.Cleanup(metricsSrv.Close)
+	h.OrderMetricsURL = metricsSrv.URL
+
+	if _, err := svc.BackfillACL(context.Background()); err != nil {
+		h.t.Fatalf("ordersvc: error backfilling the acl projection [%v]", err)
+	}
+
+	eh := ordernats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("ordersvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+
+	go scheduler.Execute()
+	h.t.Cleanup(func() { scheduler.Interrupt(nil) })
+
+	sweeper, err := ordersvc.NewSweeper(logger, svc, sweepInterval)
+	if err != nil {
+		h.t.Fatalf("ordersvc: error initialising order sweeper [%v]", err)
+	}
+	go sweeper.Execute()
+	h.t.Cleanup(func() { sweeper.Interrupt(nil) })
+}
@@ -0,0 +1,45 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	orderrepo "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/repo"
+)
+
+// TestOrderStatusTransitions checks that the order repo refuses the illegal status changes
+func TestOrderStatusTransitions(t *testing.T) {
+	h := &Harness{t: t}
+	h.OrderDB = h.newDB("ordersvc")
+	repo, err := orderrepo.NewBasicOrderRepo(h.OrderDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	oid, err := repo.CreateOrder(ctx, 1, []ordermodel.OrderLine{{ProductID: uuid.New(), Qty: 1}}, ordermodel.OrderStatusPending, ordermodel.StatusChangeCause{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateOrderStatus(ctx, oid, ordermodel.OrderStatusPaid, ordermodel.StatusChangeCause{}); err != nil {
+		t.Fatalf("pending -> paid: %v", err)
+	}
+	// a redelivered event sets the same status again
+	if err := repo.UpdateOrderStatus(ctx, oid, ordermodel.OrderStatusPaid, ordermodel.StatusChangeCause{}); err != nil {
+		t.Fatalf("paid -> paid: %v", err)
+	}
+
+	// a late EventErrReservingProduct
+	err = repo.UpdateOrderStatus(ctx, oid, ordermodel.OrderStatusProductOutOfStock, ordermodel.StatusChangeCause{})
+	var illegalErr *ordermodel.ErrIllegalStatusTransition
+	if !errors.As(err, &illegalErr) || illegalErr.From != ordermodel.OrderStatusPaid {
+		t.Fatalf("paid -> product_out_of_stock: want ErrIllegalStatusTransition, got %v", err)
+	}
+	if status := h.orderStatus(oid); status != ordermodel.OrderStatusPaid {
+		t.Errorf("order status: want paid, got %s", status)
+	}
+}
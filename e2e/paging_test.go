@@ -0,0 +1,162 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+type page struct {
+	NextCursor   string `json:"next_cursor"`
+	PrevCursor   string `json:"prev_cursor"`
+	PageSize     int    `json:"page_size"`
+	TotalRecords *int64 `json:"total_records"`
+}
+
+// TestCursorPagination walks the pages of the list endpoints back and forth through their cursors
+func TestCursorPagination(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "merchants", "*", mid.String())
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+
+	// the products in the order of their price
+	pids := []uuid.UUID{}
+	for i := 1; i <= 5; i++ {
+		pids = append(pids, h.createProduct(sellerToken, mid, fmt.Sprintf("Shoe %d", i), 10, float32(i)))
+	}
+
+	listProducts := func(t *testing.T, query url.Values) ([]uuid.UUID, page) {
+		t.Helper()
+		var resp struct {
+			Products []struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"products"`
+			Page page `json:"page"`
+		}
+		code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/products?"+query.Encode(), customerToken, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list products: got status %d", code)
+		}
+		ids := []uuid.UUID{}
+		for _, p := range resp.Products {
+			ids = append(ids, p.ID)
+		}
+		return ids, resp.Page
+	}
+	query := func(cursor string) url.Values {
+		q := url.Values{"merchant_id": {mid.String()}, "orderby": {"price"}, "page_size": {"2"}}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		return q
+	}
+
+	t.Run("products", func(t *testing.T) {
+		q := query("")
+		q.Set("total", "true")
+		first, p1 := listProducts(t, q)
+		if !reflect.DeepEqual(first, pids[:2]) || p1.PrevCursor != "" || p1.NextCursor == "" {
+			t.Fatalf("first page: want %v with only a next cursor, got %v %+v", pids[:2], first, p1)
+		}
+		if p1.PageSize != 2 || p1.TotalRecords == nil || *p1.TotalRecords != 5 {
+			t.Errorf("first page: want 2 of 5 records, got %+v", p1)
+		}
+
+		second, p2 := listProducts(t, query(p1.NextCursor))
+		if !reflect.DeepEqual(second, pids[2:4]) || p2.PrevCursor == "" || p2.NextCursor == "" {
+			t.Fatalf("second page: want %v with both cursors, got %v %+v", pids[2:4], second, p2)
+		}
+		if p2.TotalRecords != nil {
+			t.Errorf("second page: want no total unless asked for, got %d", *p2.TotalRecords)
+		}
+
+		last, p3 := listProducts(t, query(p2.NextCursor))
+		if !reflect.DeepEqual(last, pids[4:]) || p3.NextCursor != "" {
+			t.Fatalf("last page: want %v without a next cursor, got %v %+v", pids[4:], last, p3)
+		}
+
+		back, p := listProducts(t, query(p3.PrevCursor))
+		if !reflect.DeepEqual(back, second) {
+			t.Errorf("back to the second page: want %v, got %v", second, back)
+		}
+		back, p = listProducts(t, query(p.PrevCursor))
+		if !reflect.DeepEqual(back, first) || p.PrevCursor != "" {
+			t.Errorf("back to the first page: want %v without a prev cursor, got %v %+v", first, back, p)
+		}
+	})
+
+	t.Run("orders", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			oid := h.createOrder(customerToken, pids[0], 1)
+			h.Eventually("order to be paid", func() bool {
+				return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+			})
+		}
+
+		type listOrderResponse struct {
+			Orders []struct {
+				OID   uuid.UUID `json:"order_id"`
+				Lines []struct {
+					ProductID uuid.UUID `json:"product_id"`
+				} `json:"lines"`
+			} `json:"orders"`
+			Page page `json:"page"`
+		}
+		var resp listOrderResponse
+		code := h.Do("GET", h.OrderURL+"/v1/ordersvc/orders?page_size=1&total=true", customerToken, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list orders: got status %d", code)
+		}
+		if len(resp.Orders) != 1 || len(resp.Orders[0].Lines) != 1 || resp.Page.NextCursor == "" {
+			t.Fatalf("first page of orders: want an order with its line and a next cursor, got %+v", resp)
+		}
+		if resp.Page.TotalRecords == nil || *resp.Page.TotalRecords != 2 {
+			t.Errorf("first page of orders: want 2 records, got %+v", resp.Page)
+		}
+		first, next := resp.Orders[0].OID, resp.Page.NextCursor
+
+		resp = listOrderResponse{}
+		code = h.Do("GET", h.OrderURL+"/v1/ordersvc/orders?page_size=1&cursor="+next,
+			customerToken, nil, &resp)
+		if code != http.StatusOK {
+			t.Fatalf("list orders: got status %d", code)
+		}
+		if len(resp.Orders) != 1 || resp.Orders[0].OID == first || resp.Page.NextCursor != "" {
+			t.Errorf("last page of orders: want the other order without a next cursor, got %+v", resp)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, p := listProducts(t, query(""))
+
+		// a cursor is valid only for the orderby it was issued for
+		q := query(p.NextCursor)
+		q.Set("orderby", "name")
+		for _, u := range []string{
+			h.InventoryURL + "/v1/inventorysvc/products?" + q.Encode(),
+			h.InventoryURL + "/v1/inventorysvc/products?cursor=not-a-cursor",
+			h.OrderURL + "/v1/ordersvc/orders?cursor=" + p.NextCursor,
+		} {
+			if code := h.Do("GET", u, customerToken, nil, nil); code != http.StatusBadRequest {
+				t.Errorf("GET %s: want status 400, got %d", u, code)
+			}
+		}
+	})
+}
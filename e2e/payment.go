@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	kitep "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/patrickmn/go-cache"
+
+	paymentconf "github.com/AyushSenapati/reactive-micro/paymentsvc/conf"
+	paymentdto "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
+	paymentep "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/endpoint"
+	paymentidem "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/idempotency"
+	paymentpe "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/policy-enforcer"
+	paymentlog "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/logger"
+	paymentrepo "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/repo"
+	paymentsvc "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/service"
+	paymenthttp "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/transport/http"
+	paymentnats "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/transport/nats"
+)
+
+// bootPaymentSvc boots paymentsvc on sqlite
+func (h *Harness) bootPaymentSvc() {
+	c := &paymentconf.Config{ReqIDKey: reqIDKey, SVCName: "e2e-payment-svc"}
+	c.AuthzSvcUrl = h.AuthzURL + "/v1/authzsvc/policies"
+	c.Auth.SecretKey = secretKey
+	paymentconf.C = c
+
+	logger := paymentlog.NewLogger(c.Env)
+	logger.Configure(paymentlog.WithSvcName(c.SVCName))
+
+	nc := h.newEncodedConn(c.SVCName)
+	h.PaymentDB = h.newDB("paymentsvc")
+	repoObj := paymentrepo.NewBasicOrderRepo(h.PaymentDB)
+
+	ps, err := paymentpe.NewCachedPolicyStorageMW(
+		c.AuthzSvcUrl, []string{"wallets", "transactions"}, cache.New(5*time.Minute, 10*time.Minute))
+	if err != nil {
+		h.t.Fatalf("paymentsvc: error initialising policy storage [%v]", err)
+	}
+	pe, err := paymentpe.NewPolicyEnforcer(ps)
+	if err != nil {
+		h.t.Fatalf("paymentsvc: error initialising policy enforcer [%v]", err)
+	}
+
+	svc := paymentsvc.New(
+		logger, []paymentsvc.Middleware{paymentsvc.NewAuthzMW(pe)},
+		paymentsvc.WithRepo(repoObj),
+		paymentsvc.WithNATSEncodedConn(nc),
+		paymentsvc.WithPolicyStorage(ps),
+	)
+	if svc == nil {
+		h.t.Fatal("paymentsvc: error initialising service")
+	}
+
+	allMethods := []string{"RechargeWallet", "ListTransactions", "ListEvents"}
+	securedMethods := []string{"RechargeWallet", "ListTransactions"}
+	epMW := map[string][]kitep.Middleware{}
+	epMW["RechargeWallet"] = append(epMW["RechargeWallet"], paymentep.NewIdempotencyMW(
+		paymentidem.NewGormStore(h.PaymentDB, 24*time.Hour, time.Minute), "RechargeWallet", paymentdto.RechargeWalletResponse{}))
+	for _, method := range securedMethods {
+		epMW[method] = append(epMW[method], paymentep.NewJWTTokenParsingMW(c.Auth.SecretKey))
+	}
+	eps := paymentep.New(svc, epMW)
+
+	options := httpOptions(allMethods, securedMethods, paymenthttp.ErrorEncoder)
+	options["RechargeWallet"] = append(options["RechargeWallet"], kithttp.ServerBefore(paymentidem.HTTPToContext()))
+	srv := httptest.NewServer(paymenthttp.NewHTTPHandler(eps, options))
+	h.t.Cleanup(srv.Close)
+	h.PaymentURL = srv.URL
+
+	if _, err := svc.BackfillACL(context.Background()); err != nil {
+		h.t.Fatalf("paymentsvc: error backfilling the acl projection [%v]", err)
+	}
+
+	eh := paymentnats.NewEventHandler(logger, nc, svc)
+	go func() {
+		if err := eh.Execute(); err != nil {
+			h.t.Errorf("paymentsvc: event handler [%v]", err)
+		}
+	}()
+	h.t.Cleanup(func() { eh.Interrupt(nil) })
+}
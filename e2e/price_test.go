@@ -0,0 +1,74 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	invmodel "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+// TestQuotedPrice checks that an order is charged the price quoted when it was created
+// and rejected with EventPriceMismatch if the price moved beyond the tolerance meanwhile
+func TestQuotedPrice(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, mid, "Shoe", 10, 10.0)
+	h.Eventually("product to be projected", func() bool {
+		return h.projectedQty(pid) == 10
+	})
+
+	// the price is changed behind the back of ordersvc, which keeps quoting the old one
+	setPrice := func(price float32) {
+		t.Helper()
+		err := h.InventoryDB.Model(&invmodel.Product{}).Where("id = ?", pid).Update("price", price).Error
+		if err != nil {
+			t.Fatalf("set price: %v", err)
+		}
+	}
+
+	t.Run("within tolerance", func(t *testing.T) {
+		setPrice(10.5)
+		oid := h.createOrder(customerToken, pid, 2)
+		h.Eventually("order to be paid", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+		})
+		if b := h.walletBalance(customerID); b != 80.0 {
+			t.Errorf("want the quoted 20 to be charged leaving 80, got a balance of %v", b)
+		}
+		if total := h.orderTotal(oid); total != 20.0 {
+			t.Errorf("want a quoted total of 20, got %v", total)
+		}
+	})
+
+	t.Run("beyond tolerance", func(t *testing.T) {
+		setPrice(12)
+		qty := h.productQty(pid)
+		oid := h.createOrder(customerToken, pid, 1)
+		h.Eventually("order to be rejected", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPriceChanged
+		})
+		if h.isReserved(oid) || h.productQty(pid) != qty {
+			t.Errorf("want nothing reserved for the rejected order")
+		}
+		if b := h.walletBalance(customerID); b != 80.0 {
+			t.Errorf("want nothing charged for the rejected order, got a balance of %v", b)
+		}
+	})
+}
+
+func (h *Harness) orderTotal(oid uuid.UUID) float32 {
+	var o ordermodel.Order
+	h.OrderDB.Where("id = ?", oid).Limit(1).Find(&o)
+	return o.Total
+}
@@ -0,0 +1,126 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	invmodel "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+// TestUpdateDeleteProduct checks that a seller can change and delete the products
+// granted to them, which reaches the projection of ordersvc, and that the policies
+// granted on a deleted product are removed
+func TestUpdateDeleteProduct(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	otherID, otherToken := h.Signup("Other", "seller")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(otherID, "merchants", "post", "*")
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, mid, "Shoe", 10, 5.0)
+	h.WaitForPolicy(sellerID, "products", "*", pid.String())
+	url := h.InventoryURL + "/v1/inventorysvc/products/" + pid.String()
+
+	projected := func() ordermodel.Product {
+		var p ordermodel.Product
+		h.OrderDB.Where("id = ?", pid).Limit(1).Find(&p)
+		return p
+	}
+	stored := func() (invmodel.Product, bool) {
+		var p invmodel.Product
+		n := h.InventoryDB.Where("id = ?", pid).Limit(1).Find(&p).RowsAffected
+		return p, n == 1
+	}
+
+	t.Run("update", func(t *testing.T) {
+		if code := h.Do("PATCH", url, otherToken, map[string]interface{}{"price": 1}, nil); code != http.StatusForbidden {
+			t.Errorf("patch the product of others: want status 403, got %d", code)
+		}
+
+		var resp struct {
+			Version int `json:"version"`
+		}
+		code := h.Do("PATCH", url, sellerToken, map[string]interface{}{"price": 7.5}, &resp)
+		if code != http.StatusOK || resp.Version != 2 {
+			t.Fatalf("patch price: got status %d, version %d", code, resp.Version)
+		}
+		h.Eventually("projection to get the new price", func() bool {
+			return projected().Price == 7.5
+		})
+		if p, _ := stored(); p.Name != "Shoe" || p.Qty != 10 {
+			t.Errorf("patch price: want the other fields kept, got %+v", p)
+		}
+
+		// PUT replaces the whole product, so its name, qty and price are required
+		if code := h.Do("PUT", url, sellerToken, map[string]interface{}{"name": "Boot"}, nil); code != http.StatusBadRequest {
+			t.Errorf("put a partial product: want status 400, got %d", code)
+		}
+		for _, body := range []map[string]interface{}{{}, {"qty": -1}, {"name": ""}, {"colour": "red"}} {
+			if code := h.Do("PATCH", url, sellerToken, body, nil); code != http.StatusBadRequest {
+				t.Errorf("patch %v: want status 400, got %d", body, code)
+			}
+		}
+
+		code = h.Do("PUT", url, sellerToken, map[string]interface{}{"name": "Boot", "qty": 3, "price": 8}, nil)
+		if code != http.StatusOK {
+			t.Fatalf("put product: got status %d", code)
+		}
+		h.Eventually("projection to get the new product", func() bool {
+			p := projected()
+			return p.Name == "Boot" && p.Qty == 3 && p.Price == 8
+		})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// an order in progress holds some of the stock
+		reserved := invmodel.ReservedProduct{OID: uuid.New(), PID: pid, Qty: 1, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := h.InventoryDB.Create(&reserved).Error; err != nil {
+			t.Fatal(err)
+		}
+		if code := h.Do("DELETE", url, sellerToken, nil, nil); code != http.StatusConflict {
+			t.Errorf("delete a reserved product: want status 409, got %d", code)
+		}
+		h.InventoryDB.Where("o_id = ?", reserved.OID).Delete(&invmodel.ReservedProduct{})
+
+		if code := h.Do("DELETE", url, otherToken, nil, nil); code != http.StatusForbidden {
+			t.Errorf("delete the product of others: want status 403, got %d", code)
+		}
+		// a policy which never reached the ACL projection of inventorysvc
+		if err := h.AuthzRepo.UpsertPolicy(context.Background(), fmt.Sprint(otherID), "products", pid.String(), "get"); err != nil {
+			t.Fatal(err)
+		}
+		if code := h.Do("DELETE", url, sellerToken, nil, nil); code != http.StatusOK {
+			t.Fatalf("delete product: got status %d", code)
+		}
+		if _, ok := stored(); ok {
+			t.Errorf("delete product: want it gone from inventorysvc")
+		}
+		h.Eventually("projection to drop the product", func() bool {
+			return projected().Deleted
+		})
+
+		// authzsvc removes the policies granted on the product
+		h.Eventually("policies on the product to be removed", func() bool {
+			policies, err := h.AuthzRepo.ListPolicy(context.Background(), "", "products")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range policies {
+				if strings.HasSuffix(p, ":"+pid.String()) {
+					return false
+				}
+			}
+			return true
+		})
+	})
+}
@@ -0,0 +1,184 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	invmodel "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	paymodel "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
+)
+
+// TestReservationExpiry checks that the stock reserved for an order which is neither
+// approved nor canceled within the reservation TTL of its merchant is given back,
+// and that ordersvc records EventReservationExpired on the failed order
+func TestReservationExpiry(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+
+	// a TTL which would expire the reservation before the saga times out is refused
+	createMerchant := func(name string, ttl int, out interface{}) int {
+		return h.Do("POST", h.InventoryURL+"/v1/inventorysvc/merchants", sellerToken,
+			map[string]interface{}{"name": name, "reservation_ttl": ttl}, out)
+	}
+	for _, ttl := range []int{-1, 1} {
+		if code := createMerchant("e2e-other-merchant", ttl, nil); code != http.StatusBadRequest {
+			t.Errorf("reservation ttl %d: want status 400, got %d", ttl, code)
+		}
+	}
+
+	// the products of the merchant stay reserved for as short as allowed
+	var merchant struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if code := createMerchant("e2e-merchant", int(minReservationTTL.Seconds()), &merchant); code != http.StatusOK {
+		t.Fatalf("create merchant: got status %d", code)
+	}
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, merchant.ID, "cheap-product", 10, 5.0)
+
+	// paymentsvc never gets the pay command, so the saga times out and fails the order,
+	// but inventorysvc never gets the cancellation and holds on to the stock till it expires
+	for stream, durable := range map[string]string{
+		"inventorysvc": "event-product-reserved-paymentsvc",
+		"ordersvc":     "event-order-canceled-inventorysvc",
+	} {
+		if err := h.JS.DeleteConsumer(stream, durable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oid := h.createOrder(customerToken, pid, 2)
+
+	h.Eventually("saga to time out", func() bool {
+		return h.orderStatus(oid) == ordermodel.OrderStatusFailed
+	})
+	h.Eventually("reservation to expire", func() bool {
+		return !h.isReserved(oid)
+	})
+	if qty := h.productQty(pid); qty != 10 {
+		t.Errorf("want the reserved stock back, got a qty of %d", qty)
+	}
+
+	h.WaitForPolicy(customerID, "orders", "get", oid.String())
+	h.Eventually("saga to record the expiry", func() bool {
+		_, saga := h.getSaga(customerToken, oid)
+		for _, tr := range saga.History {
+			if tr.Trigger == string(ordermodel.SagaTriggerReservationExpired) && tr.To == string(ordermodel.SagaStateCompensated) {
+				return true
+			}
+		}
+		return false
+	})
+
+	var metrics struct {
+		Released map[string]int `json:"inventorysvc_released_reservations"`
+	}
+	code := h.Do("GET", h.InventoryMetricsURL+"/v1/inventorysvc/_metrics", "", nil, &metrics)
+	if code != http.StatusOK || metrics.Released["orders"] < 1 {
+		t.Errorf("metrics: got status %d, released reservations %v", code, metrics.Released)
+	}
+	// the metrics are served on the internal listener only
+	if code := h.Do("GET", h.InventoryURL+"/v1/inventorysvc/_metrics", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("public metrics: want status 404, got %d", code)
+	}
+}
+
+// TestReservationExpiryAfterPayment checks that a reservation which expires after the
+// order got paid, as inventorysvc never saw the approval, fails the order and refunds it
+func TestReservationExpiryAfterPayment(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	var merchant struct {
+		ID uuid.UUID `json:"id"`
+	}
+	code := h.Do("POST", h.InventoryURL+"/v1/inventorysvc/merchants", sellerToken,
+		map[string]interface{}{"name": "e2e-merchant", "reservation_ttl": int(minReservationTTL.Seconds())}, &merchant)
+	if code != http.StatusOK {
+		t.Fatalf("create merchant: got status %d", code)
+	}
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, merchant.ID, "cheap-product", 10, 5.0)
+
+	if err := h.JS.DeleteConsumer("ordersvc", "event-order-approved-inventorysvc"); err != nil {
+		t.Fatal(err)
+	}
+	oid := h.createOrder(customerToken, pid, 2)
+
+	h.Eventually("order to be paid", func() bool {
+		return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+	})
+	if balance := h.walletBalance(customerID); balance != 90.0 {
+		t.Errorf("want a balance of 90 after the payment, got %v", balance)
+	}
+
+	h.Eventually("order to fail on the expiry", func() bool {
+		return h.orderStatus(oid) == ordermodel.OrderStatusFailed
+	})
+	h.WaitForPolicy(customerID, "orders", "get", oid.String())
+	if _, saga := h.getSaga(customerToken, oid); saga.State != string(ordermodel.SagaStateCompensated) {
+		t.Errorf("want a compensated saga, got %s", saga.State)
+	}
+	h.Eventually("payment to be refunded", func() bool {
+		var refunds int64
+		h.PaymentDB.Model(&paymodel.Transaction{}).
+			Where("order_id = ? AND refund_of IS NOT NULL", oid).Count(&refunds)
+		return refunds == 1 && h.walletBalance(customerID) == 100.0
+	})
+	if qty := h.productQty(pid); qty != 10 {
+		t.Errorf("want the reserved stock back, got a qty of %d", qty)
+	}
+}
+
+// TestLegacyOrderCreated checks that an EventOrderCreated fired before the orders had lines,
+// with a single product_id and quantity, still reserves the product as a single line
+func TestLegacyOrderCreated(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, mid, "e2e-product", 10, 2.5)
+
+	conn := h.newEncodedConn("e2e-legacy")
+	defer conn.Close()
+	oid := uuid.New()
+	err := conn.Publish("ordersvc.EventOrderCreated", map[string]interface{}{
+		"meta": map[string]interface{}{
+			"version": "v1", "source": "ordersvc", "time": time.Now(),
+			"name": "EventOrderCreated", "id": uuid.New().String(),
+		},
+		"payload": map[string]interface{}{
+			"order_id": oid, "order_status": "pending", "account_id": 7,
+			"product_id": pid, "quantity": 3,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Eventually("legacy order reserved", func() bool { return h.isReserved(oid) })
+	var reserved []invmodel.ReservedProduct
+	h.InventoryDB.Where("o_id = ?", oid).Find(&reserved)
+	if len(reserved) != 1 || reserved[0].PID != pid || reserved[0].Qty != 3 {
+		t.Errorf("want a single line of 3 x %s reserved, got %+v", pid, reserved)
+	}
+	if qty := h.productQty(pid); qty != 7 {
+		t.Errorf("want qty 7 left, got %d", qty)
+	}
+}
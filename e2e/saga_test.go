@@ -0,0 +1,386 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	invmodel "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	paymodel "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
+)
+
+// TestOrderSaga drives the whole choreography across all the services:
+// signup -> policy grants -> create product -> create order -> reserve -> pay -> approve/cancel
+func TestOrderSaga(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+
+	// policies granted by the services on EventAccountCreated
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.WaitForPolicy(customerID, "transactions", "post", "*")
+
+	// every customer wallet is enabled with 100.0 on signup
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "merchants", "*", mid.String())
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+
+	t.Run("approve", func(t *testing.T) {
+		pid := h.createProduct(sellerToken, mid, "cheap-product", 10, 5.0)
+		oid := h.createOrder(customerToken, pid, 2)
+
+		h.Eventually("order to be paid", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+		})
+		// on approval inventorysvc drops the reservation but keeps the stock deducted
+		h.Eventually("reservation to be removed", func() bool {
+			return !h.isReserved(oid)
+		})
+		if qty := h.productQty(pid); qty != 8 {
+			t.Errorf("product qty: want 8, got %d", qty)
+		}
+		if balance := h.walletBalance(customerID); balance != 90.0 {
+			t.Errorf("wallet balance: want 90.0, got %v", balance)
+		}
+		var debits int64
+		h.PaymentDB.Model(&paymodel.Transaction{}).Where(
+			"made_by = ? and is_credit = ?", customerID, false).Count(&debits)
+		if debits != 1 {
+			t.Errorf("debit transactions: want 1, got %d", debits)
+		}
+
+		// the saga of the order ends up completed and records how it got there
+		code, saga := h.getSaga(customerToken, oid)
+		if code != http.StatusOK || saga.State != string(ordermodel.SagaStateCompleted) {
+			t.Fatalf("saga: got status %d, state %q", code, saga.State)
+		}
+		first, last := saga.History[0], saga.History[len(saga.History)-1]
+		if first.Trigger != string(ordermodel.SagaTriggerStarted) || last.Trigger != string(ordermodel.SagaTriggerPaymentSuccessful) {
+			t.Errorf("saga history: unexpected %+v", saga.History)
+		}
+		if code, _ := h.getSaga(sellerToken, oid); code != http.StatusForbidden {
+			t.Errorf("saga of others order: want status 403, got %d", code)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		// payable exceeds the wallet balance so the payment fails
+		pid := h.createProduct(sellerToken, mid, "costly-product", 3, 500.0)
+		oid := h.createOrder(customerToken, pid, 1)
+
+		h.Eventually("order to fail", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusFailed
+		})
+		if _, saga := h.getSaga(customerToken, oid); saga.State != string(ordermodel.SagaStateCompensated) {
+			t.Errorf("saga state: want compensated, got %q", saga.State)
+		}
+		// EventOrderCanceled must undo the reservation and restore the stock
+		h.Eventually("reservation to be undone", func() bool {
+			return !h.isReserved(oid) && h.productQty(pid) == 3
+		})
+		if balance := h.walletBalance(customerID); balance != 90.0 {
+			t.Errorf("wallet balance: want 90.0, got %v", balance)
+		}
+	})
+
+	t.Run("multi-line", func(t *testing.T) {
+		pen := h.createProduct(sellerToken, mid, "pen", 10, 5.0)
+		book := h.createProduct(sellerToken, mid, "book", 5, 10.0)
+		oid := h.createOrderLines(customerToken, map[uuid.UUID]int{pen: 2, book: 3})
+
+		h.Eventually("order to be paid", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+		})
+		if qty := h.productQty(pen); qty != 8 {
+			t.Errorf("pen qty: want 8, got %d", qty)
+		}
+		if qty := h.productQty(book); qty != 2 {
+			t.Errorf("book qty: want 2, got %d", qty)
+		}
+		// the payable is the total of all the lines: 2*5 + 3*10
+		if balance := h.walletBalance(customerID); balance != 50.0 {
+			t.Errorf("wallet balance: want 50.0, got %v", balance)
+		}
+
+		// ordersvc refuses a line short of stock as per its product projection
+		code := h.Do("POST", h.OrderURL+"/v1/ordersvc/orders", customerToken, map[string]interface{}{
+			"lines": []map[string]interface{}{{"product_id": pen, "qty": 1}, {"product_id": book, "qty": 3}},
+		}, nil)
+		if code != http.StatusConflict {
+			t.Errorf("order short of stock: want status 409, got %d", code)
+		}
+
+		// inventorysvc still fails the whole order for a single line short of stock,
+		// when the projection of ordersvc is stale
+		h.Eventually("projection to see the stock", func() bool {
+			return h.projectedQty(book) == 2
+		})
+		h.OrderDB.Model(&ordermodel.Product{}).Where("id = ?", book).Update("qty", 5)
+		oid = h.createOrderLines(customerToken, map[uuid.UUID]int{pen: 1, book: 3})
+		h.Eventually("order to be out of stock", func() bool {
+			return h.orderStatus(oid) == ordermodel.OrderStatusProductOutOfStock
+		})
+		if h.isReserved(oid) || h.productQty(pen) != 8 || h.productQty(book) != 2 {
+			t.Errorf("stock must be untouched: pen %d, book %d", h.productQty(pen), h.productQty(book))
+		}
+	})
+}
+
+// TestSagaTimeout checks that the saga compensates an order whose step never gets a reply
+func TestSagaTimeout(t *testing.T) {
+	h := NewHarness(t)
+
+	_, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	pid := h.createProduct(sellerToken, mid, "cheap-product", 10, 5.0)
+
+	// inventorysvc never gets the reserve command
+	if err := h.JS.DeleteConsumer("ordersvc", "event-order-created-inventorysvc"); err != nil {
+		t.Fatal(err)
+	}
+	oid := h.createOrder(customerToken, pid, 1)
+
+	h.Eventually("saga to time out", func() bool {
+		_, saga := h.getSaga(customerToken, oid)
+		return saga.State == string(ordermodel.SagaStateCompensated)
+	})
+	_, saga := h.getSaga(customerToken, oid)
+	// the release of the reservation might be recorded after the timeout
+	timedOut := saga.History[1]
+	if timedOut.From != string(ordermodel.SagaStateReserving) || timedOut.Trigger != string(ordermodel.SagaTriggerTimedOut) || timedOut.Reason == "" {
+		t.Errorf("saga history: unexpected transition %+v", timedOut)
+	}
+	if status := h.orderStatus(oid); status != ordermodel.OrderStatusFailed {
+		t.Errorf("order status: want failed, got %s", status)
+	}
+}
+
+// TestEventStore checks that the whole saga of an order is recorded and can be replayed
+func TestEventStore(t *testing.T) {
+	h := NewHarness(t)
+
+	_, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	adminID, adminToken := h.Signup("Admin", "customer")
+	h.grantAdmin(adminID)
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	// the events are of the admins only
+	if code := h.Do("GET", h.EventStoreURL+"/v1/eventstoresvc/events", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("list events anonymously: want status 401, got %d", code)
+	}
+	if code := h.Do("GET", h.EventStoreURL+"/v1/eventstoresvc/events", customerToken, nil, nil); code != http.StatusForbidden {
+		t.Errorf("list events as a customer: want status 403, got %d", code)
+	}
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	pid := h.createProduct(sellerToken, mid, "cheap-product", 10, 5.0)
+	oid := h.createOrder(customerToken, pid, 1)
+	h.Eventually("order to be paid", func() bool {
+		return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+	})
+
+	saga := []string{"EventOrderCreated", "EventProductReserved", "EventPayment", "EventOrderApproved"}
+	var names []string
+	h.Eventually("saga to be stored", func() bool {
+		var resp struct {
+			Events []struct {
+				Name string `json:"name"`
+			} `json:"events"`
+		}
+		h.Do("GET", h.EventStoreURL+"/v1/eventstoresvc/events?aggregate_id="+oid.String(), adminToken, nil, &resp)
+		names = names[:0]
+		for _, e := range resp.Events {
+			// the policy events carry the order id as resource_id, skip them
+			if e.Name != "EventUpsertPolicy" && e.Name != "EventPolicyUpdated" {
+				names = append(names, e.Name)
+			}
+		}
+		return len(names) == len(saga)
+	})
+	for i := range saga {
+		if names[i] != saga[i] {
+			t.Fatalf("want events %v in order, got %v", saga, names)
+		}
+	}
+
+	// replay the order created event to the deliver subject of a consumer of our own
+	conn := h.newEncodedConn("e2e-replay")
+	defer conn.Close()
+	sub, err := conn.Conn.SubscribeSync("ordersvc.EventOrderCreated.e2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := func(token, subject string) int {
+		return h.Do("POST", h.EventStoreURL+"/v1/eventstoresvc/events/replay", token, map[string]interface{}{
+			"filter":  map[string]string{"aggregate_id": oid.String(), "name": "EventOrderCreated"},
+			"subject": subject,
+		}, nil)
+	}
+	if code := replay(customerToken, "ordersvc.EventOrderCreated.e2e"); code != http.StatusForbidden {
+		t.Errorf("replay as a customer: want status 403, got %d", code)
+	}
+	// an event can't be passed off as another one
+	for _, subject := range []string{"e2e.replay", "paymentsvc.EventPayment", "ordersvc.EventOrderCreated.other"} {
+		if code := replay(adminToken, subject); code != http.StatusBadRequest {
+			t.Errorf("replay on %s: want status 400, got %d", subject, code)
+		}
+	}
+
+	var resp struct {
+		Replayed int `json:"replayed"`
+	}
+	code := h.Do("POST", h.EventStoreURL+"/v1/eventstoresvc/events/replay", adminToken, map[string]interface{}{
+		"filter":  map[string]string{"aggregate_id": oid.String(), "name": "EventOrderCreated"},
+		"subject": "ordersvc.EventOrderCreated.e2e",
+	}, &resp)
+	if code != http.StatusOK || resp.Replayed != 1 {
+		t.Fatalf("replay: got status %d, replayed %d", code, resp.Replayed)
+	}
+	msg, err := sub.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("replayed event not received [%v]", err)
+	}
+	var replayed struct {
+		Meta struct {
+			Name string `json:"name"`
+		} `json:"meta"`
+		Payload struct {
+			OrderID uuid.UUID `json:"order_id"`
+		} `json:"payload"`
+	}
+	json.Unmarshal(msg.Data, &replayed)
+	if replayed.Meta.Name != "EventOrderCreated" || replayed.Payload.OrderID != oid {
+		t.Errorf("unexpected replayed event %s", string(msg.Data))
+	}
+
+	// replaying without a filter is refused
+	code = h.Do("POST", h.EventStoreURL+"/v1/eventstoresvc/events/replay", adminToken,
+		map[string]interface{}{"subject": "ordersvc.EventOrderCreated.e2e"}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("replay without filter: want status 400, got %d", code)
+	}
+}
+
+type sagaResponse struct {
+	State   string `json:"state"`
+	History []struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		Trigger string `json:"trigger"`
+		Reason  string `json:"reason"`
+	} `json:"history"`
+}
+
+func (h *Harness) getSaga(token string, oid uuid.UUID) (int, sagaResponse) {
+	h.t.Helper()
+	var resp sagaResponse
+	code := h.Do("GET", h.OrderURL+"/v1/ordersvc/sagas/"+oid.String(), token, nil, &resp)
+	return code, resp
+}
+
+func (h *Harness) createMerchant(token, name string) uuid.UUID {
+	h.t.Helper()
+	var resp struct {
+		ID uuid.UUID `json:"id"`
+	}
+	code := h.Do("POST", h.InventoryURL+"/v1/inventorysvc/merchants", token,
+		map[string]string{"name": name}, &resp)
+	if code != http.StatusOK {
+		h.t.Fatalf("create merchant: got status %d", code)
+	}
+	return resp.ID
+}
+
+func (h *Harness) createProduct(token string, mid uuid.UUID, name string, qty int, price float32) uuid.UUID {
+	h.t.Helper()
+	var resp struct {
+		ID uuid.UUID `json:"id"`
+	}
+	// the local policy cache of inventorysvc is refreshed asynchronously
+	// from EventPolicyUpdated, so retry till the grant reaches there
+	h.Eventually("product to be created", func() bool {
+		code := h.Do("POST", h.InventoryURL+"/v1/inventorysvc/merchants/"+mid.String()+"/products", token,
+			map[string]interface{}{"name": name, "description": name, "qty": qty, "price": price}, &resp)
+		return code == http.StatusOK
+	})
+	return resp.ID
+}
+
+func (h *Harness) createOrder(token string, pid uuid.UUID, qty int) uuid.UUID {
+	h.t.Helper()
+	var resp struct {
+		OID uuid.UUID `json:"order_id"`
+	}
+	h.Eventually("order to be created", func() bool {
+		code := h.Do("POST", h.OrderURL+"/v1/ordersvc/orders", token,
+			map[string]interface{}{"product_id": pid, "qty": qty}, &resp)
+		return code == http.StatusOK
+	})
+	return resp.OID
+}
+
+// createOrderLines places an order with a line per product of the given quantities
+func (h *Harness) createOrderLines(token string, lines map[uuid.UUID]int) uuid.UUID {
+	h.t.Helper()
+	var resp struct {
+		OID uuid.UUID `json:"order_id"`
+	}
+	body := []map[string]interface{}{}
+	for pid, qty := range lines {
+		body = append(body, map[string]interface{}{"product_id": pid, "qty": qty})
+	}
+	h.Eventually("order to be created", func() bool {
+		code := h.Do("POST", h.OrderURL+"/v1/ordersvc/orders", token,
+			map[string]interface{}{"lines": body}, &resp)
+		return code == http.StatusOK
+	})
+	return resp.OID
+}
+
+func (h *Harness) orderStatus(oid uuid.UUID) ordermodel.OrderStatus {
+	var o ordermodel.Order
+	h.OrderDB.Where("id = ?", oid).Limit(1).Find(&o)
+	return ordermodel.OrderStatus(o.Status)
+}
+
+func (h *Harness) productQty(pid uuid.UUID) int {
+	var p invmodel.Product
+	h.InventoryDB.Where("id = ?", pid).Limit(1).Find(&p)
+	return p.Qty
+}
+
+// projectedQty returns the stock of the product as known to ordersvc
+func (h *Harness) projectedQty(pid uuid.UUID) int {
+	var p ordermodel.Product
+	h.OrderDB.Where("id = ?", pid).Limit(1).Find(&p)
+	return p.Qty
+}
+
+func (h *Harness) isReserved(oid uuid.UUID) bool {
+	var count int64
+	h.InventoryDB.Model(&invmodel.ReservedProduct{}).Where("o_id = ?", oid).Count(&count)
+	return count > 0
+}
+
+func (h *Harness) walletBalance(aid uint) float32 {
+	var w paymodel.Wallet
+	h.PaymentDB.Where("accnt_id = ?", aid).Limit(1).Find(&w)
+	return w.Balance
+}
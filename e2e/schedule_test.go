@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	orderevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+	orderlog "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+)
+
+// TestScheduledEvents checks that a scheduled event is published once when due
+// even with several replicas polling the schedule table, and that a canceled one is not
+func TestScheduledEvents(t *testing.T) {
+	h := NewHarness(t)
+	ctx := context.Background()
+
+	// a second replica of ordersvc polling the same schedule table
+	replica, err := orderevent.NewScheduler(orderlog.NewLogger(""), h.OrderDB,
+		h.newEncodedConn("e2e-order-svc-replica"), orderevent.WithPollInterval(schedulerPollInterval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go replica.Execute()
+	defer replica.Interrupt(nil)
+
+	// core NATS subscribers see every publish, including the ones JetStream would dedupe
+	conn := h.newEncodedConn("e2e-schedule")
+	defer conn.Close()
+	sub, err := conn.Conn.SubscribeSync("ordersvc.EventOrderCanceled")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule := func(oid uuid.UUID, at time.Time) {
+		e, err := orderevent.NewEvent(ctx, orderevent.EventOrderCanceled,
+			orderevent.EventOrderCanceledPayload{OID: oid, AccntID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OrderScheduler.Schedule(ctx, e, oid.String(), at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	due, canceled := uuid.New(), uuid.New()
+	at := time.Now().Add(500 * time.Millisecond)
+	schedule(due, at)
+	schedule(canceled, at)
+
+	n, err := h.OrderScheduler.Cancel(ctx, canceled.String(), orderevent.EventOrderCanceled)
+	if err != nil || n != 1 {
+		t.Fatalf("cancel: want 1 canceled event, got %d [%v]", n, err)
+	}
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("scheduled event not published [%v]", err)
+	}
+	if time.Now().Before(at) {
+		t.Errorf("scheduled event published before it was due")
+	}
+	var e struct {
+		Payload orderevent.EventOrderCanceledPayload `json:"payload"`
+	}
+	json.Unmarshal(msg.Data, &e)
+	if e.Payload.OID != due {
+		t.Errorf("published event of order %s, want %s", e.Payload.OID, due)
+	}
+
+	// neither a duplicate nor the canceled event must follow
+	if msg, err := sub.NextMsg(time.Second); err == nil {
+		t.Errorf("unexpected event published %s", string(msg.Data))
+	}
+
+	var pending int64
+	h.OrderDB.Model(&orderevent.ScheduledEvent{}).Count(&pending)
+	if pending != 0 {
+		t.Errorf("schedule table: want no pending events, got %d", pending)
+	}
+}
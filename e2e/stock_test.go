@@ -0,0 +1,170 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	invmodel "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	inventoryrepo "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/repo"
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+type stockMovement struct {
+	Kind    string     `json:"kind"`
+	Qty     int        `json:"qty"`
+	Delta   int        `json:"delta"`
+	Balance int        `json:"balance"`
+	OrderID *uuid.UUID `json:"order_id"`
+	Actor   string     `json:"actor"`
+	Reason  string     `json:"reason"`
+}
+
+// TestStockMovements checks that every change of the stock of a product is recorded in
+// its ledger, so that the movements explain the qty it is left with
+func TestStockMovements(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	otherID, otherToken := h.Signup("Other", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	h.WaitForPolicy(sellerID, "merchants", "post", "*")
+	h.WaitForPolicy(otherID, "merchants", "post", "*")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, mid, "Shoe", 10, 5.0)
+	h.WaitForPolicy(sellerID, "products", "*", pid.String())
+	url := h.InventoryURL + "/v1/inventorysvc/products/" + pid.String()
+
+	movements := func(query string) []stockMovement {
+		t.Helper()
+		var resp struct {
+			Movements []stockMovement `json:"movements"`
+		}
+		if code := h.Do("GET", url+"/movements"+query, sellerToken, nil, &resp); code != http.StatusOK {
+			t.Fatalf("list movements: got status %d", code)
+		}
+		return resp.Movements
+	}
+
+	// a sold order moves the stock twice: reserved and then sold
+	oid := h.createOrder(customerToken, pid, 2)
+	h.Eventually("order to be paid", func() bool {
+		return h.orderStatus(oid) == ordermodel.OrderStatusPaid
+	})
+	h.Eventually("order to be sold", func() bool {
+		return len(movements("?kind=sale")) == 1
+	})
+
+	if code := h.Do("POST", url+"/restock", otherToken, map[string]interface{}{"qty": 5}, nil); code != http.StatusForbidden {
+		t.Errorf("restock the product of others: want status 403, got %d", code)
+	}
+	for _, body := range []map[string]interface{}{{}, {"qty": 0}, {"qty": -1}} {
+		if code := h.Do("POST", url+"/restock", sellerToken, body, nil); code != http.StatusBadRequest {
+			t.Errorf("restock %v: want status 400, got %d", body, code)
+		}
+	}
+	var restocked struct {
+		Qty int `json:"qty"`
+	}
+	code := h.Do("POST", url+"/restock", sellerToken, map[string]interface{}{"qty": 5, "reason": "delivery"}, &restocked)
+	if code != http.StatusOK || restocked.Qty != 13 {
+		t.Fatalf("restock: got status %d, qty %d", code, restocked.Qty)
+	}
+	h.Eventually("projection to get the restocked qty", func() bool {
+		return h.projectedQty(pid) == 13
+	})
+
+	// a stock count found a unit missing
+	if code := h.Do("PATCH", url, sellerToken, map[string]interface{}{"qty": 12}, nil); code != http.StatusOK {
+		t.Fatalf("patch qty: got status %d", code)
+	}
+
+	seller := fmt.Sprint(sellerID)
+	want := []stockMovement{
+		{Kind: "adjustment", Qty: 1, Delta: -1, Balance: 12, Actor: seller},
+		{Kind: "restock", Qty: 5, Delta: 5, Balance: 13, Actor: seller, Reason: "delivery"},
+		{Kind: "sale", Qty: 2, Delta: 0, Balance: 8, OrderID: &oid, Actor: invmodel.SystemActor, Reason: "order approved"},
+		{Kind: "reserve", Qty: 2, Delta: -2, Balance: 8, OrderID: &oid, Actor: fmt.Sprint(customerID)},
+		{Kind: "initial", Qty: 10, Delta: 10, Balance: 10, Actor: seller},
+	}
+	got := movements("")
+	if len(got) != len(want) {
+		t.Fatalf("movements: want %d of them, got %+v", len(want), got)
+	}
+	sum := 0
+	for i, m := range got {
+		w := want[i]
+		if m.Kind != w.Kind || m.Qty != w.Qty || m.Delta != w.Delta || m.Balance != w.Balance ||
+			m.Actor != w.Actor || m.Reason != w.Reason || (m.OrderID == nil) != (w.OrderID == nil) ||
+			(m.OrderID != nil && *m.OrderID != *w.OrderID) {
+			t.Errorf("movement %d: want %+v, got %+v", i, w, m)
+		}
+		sum += m.Delta
+	}
+	if qty := h.productQty(pid); sum != qty {
+		t.Errorf("want the deltas to sum up to the qty %d, got %d", qty, sum)
+	}
+
+	if got := movements("?orderby=created_at__asc&page_size=1"); len(got) != 1 || got[0].Kind != "initial" {
+		t.Errorf("movements in the order of creation: want the initial one first, got %+v", got)
+	}
+	if got := movements("?order_id=" + oid.String()); len(got) != 2 {
+		t.Errorf("movements of the order: want the reserve and the sale, got %+v", got)
+	}
+	if code := h.Do("GET", url+"/movements?colour=red", sellerToken, nil, nil); code != http.StatusBadRequest {
+		t.Errorf("filter by an unknown field: want status 400, got %d", code)
+	}
+	if code := h.Do("GET", url+"/movements", otherToken, nil, nil); code != http.StatusForbidden {
+		t.Errorf("list the movements of the product of others: want status 403, got %d", code)
+	}
+
+	// deleting the product closes its ledger
+	if code := h.Do("DELETE", url, sellerToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete product: got status %d", code)
+	}
+	var closing invmodel.StockMovement
+	h.InventoryDB.Where("p_id = ?", pid).Order("id desc").Limit(1).Find(&closing)
+	if closing.Kind != invmodel.StockMovementAdjustment || closing.Qty != 12 || closing.Delta != -12 ||
+		closing.Balance != 0 || closing.Actor != seller || closing.Reason != "product deleted" {
+		t.Errorf("closing movement: got %+v", closing)
+	}
+}
+
+// TestStockLedgerOpening checks that the products created before the ledger was
+// introduced get their qty recorded as their initial movement on migration
+func TestStockLedgerOpening(t *testing.T) {
+	h := &Harness{t: t}
+	h.InventoryDB = h.newDB("inventorysvc")
+	if _, err := inventoryrepo.NewBasicOrderRepo(h.InventoryDB); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := invmodel.Product{ID: uuid.New(), Name: "legacy", MerchantID: uuid.New(), Qty: 7, Price: 1}
+	if err := h.InventoryDB.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	// migrating twice opens the ledger once
+	for i := 0; i < 2; i++ {
+		if _, err := inventoryrepo.NewBasicOrderRepo(h.InventoryDB); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var movements []invmodel.StockMovement
+	h.InventoryDB.Where("p_id = ?", legacy.ID).Find(&movements)
+	if len(movements) != 1 {
+		t.Fatalf("movements: want the opening one, got %+v", movements)
+	}
+	m := movements[0]
+	if m.Kind != invmodel.StockMovementInitial || m.Qty != 7 || m.Delta != 7 || m.Balance != 7 || m.Actor != invmodel.SystemActor {
+		t.Errorf("opening movement: got %+v", m)
+	}
+}
@@ -0,0 +1,167 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+type statusEvent struct {
+	ID     string
+	OID    uuid.UUID `json:"order_id"`
+	From   string    `json:"from"`
+	Status string    `json:"status"`
+}
+
+// streamOrderStatus connects to the order status stream and sends the events it
+// reads to the returned channel, which is closed once the stream ends
+func (h *Harness) streamOrderStatus(ctx context.Context, token, lastEventID string) (int, <-chan statusEvent) {
+	h.t.Helper()
+	req, err := http.NewRequestWithContext(ctx, "GET", h.OrderURL+"/v1/ordersvc/orders/stream", nil)
+	if err != nil {
+		h.t.Fatalf("error creating request [%v]", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("error connecting to the stream [%v]", err)
+	}
+
+	events := make(chan statusEvent, 10)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		var e statusEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				e.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+			case line == "" && e.ID != "":
+				events <- e
+				e = statusEvent{}
+			}
+		}
+	}()
+	return resp.StatusCode, events
+}
+
+// nextStatusEvent waits for the next event of the stream
+func (h *Harness) nextStatusEvent(events <-chan statusEvent) statusEvent {
+	h.t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			h.t.Fatal("status stream ended")
+		}
+		return e
+	case <-time.After(10 * time.Second):
+		h.t.Fatal("timed out waiting for a status event")
+	}
+	return statusEvent{}
+}
+
+// TestOrderStatusStream checks that the status changes of the orders of the
+// caller are pushed to its stream and replayed to a resuming client
+func TestOrderStatusStream(t *testing.T) {
+	h := NewHarness(t)
+
+	sellerID, sellerToken := h.Signup("Seller", "seller")
+	customerID, customerToken := h.Signup("Customer", "customer")
+	otherID, otherToken := h.Signup("Other", "customer")
+	h.WaitForPolicy(customerID, "orders", "post", "*")
+	h.WaitForPolicy(otherID, "orders", "post", "*")
+	h.Eventually("customer wallet", func() bool {
+		return h.walletBalance(customerID) == 100.0
+	})
+
+	mid := h.createMerchant(sellerToken, "e2e-merchant")
+	h.WaitForPolicy(sellerID, "products", "post", "*")
+	pid := h.createProduct(sellerToken, mid, "pen", 10, 5.0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	code, events := h.streamOrderStatus(ctx, customerToken, "")
+	if code != http.StatusOK {
+		t.Fatalf("stream: want status 200, got %d", code)
+	}
+	ctxOther, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	_, otherEvents := h.streamOrderStatus(ctxOther, otherToken, "")
+
+	oid := h.createOrder(customerToken, pid, 1)
+	// the payment may be recorded before the reservation, skipping payment_pending
+	var seen []statusEvent
+	for len(seen) == 0 || seen[len(seen)-1].Status != "paid" {
+		e := h.nextStatusEvent(events)
+		want := []string{"payment_pending", "paid"}
+		if len(seen) == 0 {
+			want = []string{"pending"}
+		}
+		if e.OID != oid || !contains(want, e.Status) {
+			t.Fatalf("status event: want %v of %v, got %+v", want, oid, e)
+		}
+		seen = append(seen, e)
+	}
+
+	// a change committed after a later one was streamed is streamed too
+	last, err := strconv.ParseUint(seen[len(seen)-1].ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint{uint(last) + 100, uint(last) + 50} {
+		change := ordermodel.OrderStatusChange{ID: id, OrderID: oid, AccntID: customerID, From: "paid", To: fmt.Sprint(id)}
+		if err := h.OrderDB.Create(&change).Error; err != nil {
+			t.Fatal(err)
+		}
+		if e := h.nextStatusEvent(events); e.ID != fmt.Sprint(id) {
+			t.Fatalf("status event committed late: want %d, got %+v", id, e)
+		}
+	}
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Errorf("status event streamed twice: %+v", e)
+		}
+	case <-time.After(200 * time.Millisecond):
+	}
+	cancel()
+
+	select {
+	case e, ok := <-otherEvents:
+		if ok {
+			t.Errorf("status event of others order: %+v", e)
+		}
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// a client reconnecting with the ID of the first event misses nothing after it
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, events = h.streamOrderStatus(ctx, customerToken, seen[0].ID)
+	for _, want := range seen[1:] {
+		if e := h.nextStatusEvent(events); e.ID != want.ID || e.Status != want.Status {
+			t.Errorf("resumed status event: want %+v, got %+v", want, e)
+		}
+	}
+
+	if code, _ := h.streamOrderStatus(context.Background(), "", ""); code != http.StatusUnauthorized {
+		t.Errorf("stream without token: want status 401, got %d", code)
+	}
+}
@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	ordermodel "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	orderrepo "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/repo"
+)
+
+// TestStaleOrderSweeper checks that the orders stuck in a status past its
+// threshold are failed and their reservations are asked to be undone
+func TestStaleOrderSweeper(t *testing.T) {
+	h := NewHarness(t)
+	ctx := context.Background()
+	repo, err := orderrepo.NewBasicOrderRepo(h.OrderDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sagaRepo := orderrepo.NewBasicSagaRepo(h.OrderDB)
+
+	conn := h.newEncodedConn("e2e-sweeper")
+	defer conn.Close()
+	sub, err := conn.Conn.SubscribeSync("ordersvc.EventOrderCanceled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make sure the server knows of the subscription before the sweeper publishes
+	if err := conn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	createOrder := func(status ordermodel.OrderStatus, age time.Duration, withSaga bool) uuid.UUID {
+		oid, err := repo.CreateOrder(ctx, 1, []ordermodel.OrderLine{{ProductID: uuid.New(), Qty: 1}}, status, ordermodel.StatusChangeCause{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withSaga {
+			if err := sagaRepo.StartSaga(ctx, oid, 1, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		h.OrderDB.Model(&ordermodel.Order{}).Where("id = ?", oid).
+			UpdateColumn("updated_at", time.Now().Add(-age))
+		return oid
+	}
+
+	stale := createOrder(ordermodel.OrderStatusPending, 2*sweepThreshold, true)
+	// orders created before the sagas were introduced have none
+	staleWithoutSaga := createOrder(ordermodel.OrderStatusPaymentPending, 2*sweepThreshold, false)
+	fresh := createOrder(ordermodel.OrderStatusPending, 0, true)
+
+	h.Eventually("stale orders to fail", func() bool {
+		return h.orderStatus(stale) == ordermodel.OrderStatusFailed &&
+			h.orderStatus(staleWithoutSaga) == ordermodel.OrderStatusFailed
+	})
+	if status := h.orderStatus(fresh); status != ordermodel.OrderStatusPending {
+		t.Errorf("fresh order: want pending, got %s", status)
+	}
+
+	sagaObj, err := sagaRepo.GetSaga(ctx, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the release of the reservation might be recorded after the timeout
+	timedOut := sagaObj.History[1]
+	if sagaObj.State != string(ordermodel.SagaStateCompensated) || !strings.Contains(timedOut.Reason, "stale in pending") {
+		t.Errorf("saga: unexpected state %s, transition %+v", sagaObj.State, timedOut)
+	}
+
+	// both stale orders are asked to be undone
+	canceled := map[uuid.UUID]bool{}
+	for i := 0; i < 2; i++ {
+		msg, err := sub.NextMsg(5 * time.Second)
+		if err != nil {
+			t.Fatalf("EventOrderCanceled not published [%v]", err)
+		}
+		var e struct {
+			Payload struct {
+				OID uuid.UUID `json:"order_id"`
+			} `json:"payload"`
+		}
+		json.Unmarshal(msg.Data, &e)
+		canceled[e.Payload.OID] = true
+	}
+	if !canceled[stale] || !canceled[staleWithoutSaga] {
+		t.Errorf("EventOrderCanceled: unexpected orders %v", canceled)
+	}
+
+	var metrics struct {
+		SweptOrders map[string]int `json:"ordersvc_swept_orders"`
+	}
+	code := h.Do("GET", h.OrderMetricsURL+"/v1/ordersvc/_metrics", "", nil, &metrics)
+	if code != http.StatusOK || metrics.SweptOrders["pending"] < 1 || metrics.SweptOrders["payment_pending"] < 1 {
+		t.Errorf("metrics: got status %d, swept orders %v", code, metrics.SweptOrders)
+	}
+	// the metrics are served on the internal listener only
+	if code := h.Do("GET", h.OrderURL+"/v1/ordersvc/_metrics", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("public metrics: want status 404, got %d", code)
+	}
+}
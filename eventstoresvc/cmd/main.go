@@ -0,0 +1,7 @@
+package main
+
+import "github.com/AyushSenapati/reactive-micro/eventstoresvc/cmd/service"
+
+func main() {
+	service.Run()
+}
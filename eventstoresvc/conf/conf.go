@@ -0,0 +1,119 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrLoadConf          = errors.New("conf: error loading configuration")
+	ErrReloadConf        = errors.New("conf: error reloading configuration")
+	ErrAlreadyLoaded     = errors.New("conf: configuration already exist")
+	ErrNoConfigFileFound = errors.New("conf: configuration file not provided/found")
+
+	// C is the global configuration obj
+	C = &Config{}
+
+	defaults = map[string]interface{}{
+		"replay_limit":     1000,
+		"replay_consumers": []string{"authnsvc", "authzsvc", "ordersvc", "inventorysvc", "paymentsvc"},
+		"auth": map[string]interface{}{
+			"secret_key": "topsecret",
+		},
+	}
+)
+
+// Config hold the event store service configuration
+type Config struct {
+	Env string `mapstructure:"env"`
+
+	ReqIDKey    string `mapstructure:"req_id_key"`
+	SVCName     string `mapstructure:"svc_name"`
+	NATSUrl     string `mapstructure:"nats_url"`
+	AuthzSvcUrl string `mapstructure:"authzsvc_url"`
+
+	// ReplayLimit is the max number of events that can be replayed in one request
+	ReplayLimit int `mapstructure:"replay_limit"`
+	// ReplayConsumers are the services on whose deliver subject ({req_chan}.{svc})
+	// the events can be replayed besides their own request channel
+	ReplayConsumers []string `mapstructure:"replay_consumers"`
+
+	Postgres struct {
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		User     string `mapstructure:"user"`
+		Password string `mapstructure:"password"`
+		DB       string `mapstructure:"db"`
+		SSLMode  string `mapstructure:"sslmode"`
+	} `mapstructure:"postgres"`
+
+	Auth struct {
+		SecretKey string `mapstructure:"secret_key"`
+	} `mapstructure:"auth"`
+}
+
+func (c *Config) Load(confFname string) error {
+	v := viper.New()
+
+	if !reflect.DeepEqual(*c, Config{}) {
+		return ErrAlreadyLoaded
+	}
+
+	if confFname != "" {
+		v.SetConfigFile(confFname)
+	} else {
+		dir, _ := os.Getwd()
+		dir = path.Join(dir, "conf")
+		v.SetConfigName("conf")
+		v.SetConfigType("json")
+		v.AddConfigPath(dir)
+	}
+
+	setDefaults(v)
+
+	if err := v.ReadInConfig(); err != nil {
+		return ErrNoConfigFileFound
+	}
+
+	// overwrite the configurations with the provided env variables
+	v.SetEnvPrefix("eventstoresvc")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if err := v.Unmarshal(&c); err != nil {
+		return ErrLoadConf
+	}
+
+	// set the global config obj
+	C = c
+
+	return nil
+}
+
+// Load reads the config file and returns read configs
+func Load(confFname string) (*Config, error) {
+	var conf Config
+	err := conf.Load(confFname)
+	return &conf, err
+}
+
+func (c *Config) GetDSN() (dsn string) {
+	dsn = fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		c.Postgres.Host, c.Postgres.User, c.Postgres.Password,
+		c.Postgres.DB, c.Postgres.Port, c.Postgres.SSLMode,
+	)
+	return
+}
+
+func setDefaults(v *viper.Viper) {
+	for key, val := range defaults {
+		v.SetDefault(key, val)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/AyushSenapati/reactive-micro/eventstoresvc
+
+go 1.16
+
+require (
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/go-kit/kit v0.10.0
+	github.com/gorilla/mux v1.8.0
+	github.com/imdario/mergo v0.3.12
+	github.com/nats-io/nats.go v1.11.0
+	github.com/oklog/run v1.1.0
+	github.com/patrickmn/go-cache v2.1.0+incompatible
+	github.com/spf13/viper v1.7.1
+	gorm.io/driver/postgres v1.1.0
+	gorm.io/gorm v1.21.10
+)
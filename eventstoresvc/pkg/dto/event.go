@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventFilter narrows down the stored events. Zero valued fields are ignored.
+type EventFilter struct {
+	Name        string    `json:"name,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	RequestID   string    `json:"req_id,omitempty"`
+	AggregateID string    `json:"aggregate_id,omitempty"`
+	From        time.Time `json:"from,omitempty"`
+	To          time.Time `json:"to,omitempty"`
+}
+
+func (f EventFilter) IsEmpty() bool {
+	return f == (EventFilter{})
+}
+
+type ListEventRequest struct {
+	Filter EventFilter
+	QP     *BasicQueryParam
+}
+
+type GetEventResponse struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Source     string            `json:"source"`
+	RequestID  string            `json:"req_id"`
+	Subject    string            `json:"subject"`
+	Time       time.Time         `json:"time"`
+	Aggregates map[string]string `json:"aggregates,omitempty"`
+	Event      json.RawMessage   `json:"event"`
+}
+
+type ListEventResponse struct {
+	Events []GetEventResponse `json:"events"`
+	Err    error              `json:"error,omitempty"`
+}
+
+func (resp ListEventResponse) Failed() error {
+	return resp.Err
+}
+
+type ReplayEventRequest struct {
+	Filter  EventFilter `json:"filter"`
+	Subject string      `json:"subject"`
+}
+
+type ReplayEventResponse struct {
+	Replayed int   `json:"replayed"`
+	Err      error `json:"error,omitempty"`
+}
+
+func (resp ReplayEventResponse) Failed() error {
+	return resp.Err
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	stdjwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/sorting"
+)
+
+// CustomClaim defines the claim to be used in JWT
+type CustomClaim struct {
+	*stdjwt.StandardClaims
+	AccntID uint   `json:"accnt_id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+}
+
+// BasicQueryParam should be used to param basic pagination and orderby queryparams
+type BasicQueryParam struct {
+	Paginator struct {
+		Page     int
+		PageSize int
+	}
+	Filter struct {
+		OrderBy []sorting.Key
+	}
+}
+
+func NewBasicQueryParam() *BasicQueryParam {
+	return &BasicQueryParam{}
+}
@@ -0,0 +1,33 @@
+package endpoint
+
+import (
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/service"
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints collects all of the endpoints that compose a event store service. It's
+// meant to be used as a helper struct, to collect all of the endpoints into a
+// single parameter.
+type Endpoints struct {
+	ListEventEndpoint   endpoint.Endpoint
+	ReplayEventEndpoint endpoint.Endpoint
+}
+
+// New returns a Endpoints struct that wraps the provided service, and wires in all of the
+// expected endpoint middlewares
+func New(s service.IEventStoreService, mdw map[string][]endpoint.Middleware) Endpoints {
+	eps := Endpoints{
+		ListEventEndpoint:   MakeListEventEndpoint(s),
+		ReplayEventEndpoint: MakeReplayEventEndpoint(s),
+	}
+
+	// apply transport middlewares
+	for _, m := range mdw["ListEvent"] {
+		eps.ListEventEndpoint = m(eps.ListEventEndpoint)
+	}
+	for _, m := range mdw["ReplayEvent"] {
+		eps.ReplayEventEndpoint = m(eps.ReplayEventEndpoint)
+	}
+
+	return eps
+}
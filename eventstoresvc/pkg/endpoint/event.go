@@ -0,0 +1,32 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/service"
+	"github.com/go-kit/kit/endpoint"
+)
+
+func MakeListEventEndpoint(s service.IEventStoreService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqObj, ok := request.(dto.ListEventRequest)
+		if !ok {
+			return dto.ListEventResponse{Err: ce.ErrInvalidQueryParam}, nil
+		}
+		return s.ListEvent(ctx, reqObj.Filter, reqObj.QP), nil
+	}
+}
+
+func MakeReplayEventEndpoint(s service.IEventStoreService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqObj, ok := request.(dto.ReplayEventRequest)
+		if !ok {
+			return dto.ReplayEventResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+
+		n, err := s.ReplayEvent(ctx, reqObj.Filter, reqObj.Subject)
+		return dto.ReplayEventResponse{Replayed: n, Err: err}, nil
+	}
+}
@@ -0,0 +1,31 @@
+package error
+
+import "errors"
+
+var (
+	// ErrApplication should be used in case server failed to process any request and
+	// sensitive err info are intended to be kept hidden from the client.
+	ErrApplication = errors.New("server encountered some issue, please contact the Admin")
+
+	// ErrInvalidReqBody should be used when request body
+	// does not match expected fields
+	ErrInvalidReqBody = errors.New("invalid request body")
+
+	// ErrInvalidQueryParam should be used when the query params can't be parsed
+	ErrInvalidQueryParam = errors.New("invalid query param")
+
+	// ErrEmptyFilter is returned when a replay is requested without narrowing down the events
+	ErrEmptyFilter = errors.New("at least one filter is required")
+
+	// ErrSubjectRequired is returned when the subject to replay the events on is not provided
+	ErrSubjectRequired = errors.New("subject is required")
+
+	ErrInsufficientPerm = errors.New("insufficient permission")
+
+	// ErrSubjectNotAllowed is returned when the events are to be replayed on a subject other than
+	// their own request channel or the deliver subject of one of its consumers
+	ErrSubjectNotAllowed = errors.New("events can't be replayed on the subject")
+
+	// ErrReplayLimitExceeded is returned when more events match the replay filter than allowed at once
+	ErrReplayLimitExceeded = errors.New("too many events to replay, narrow down the filter")
+)
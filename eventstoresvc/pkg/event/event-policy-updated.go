@@ -0,0 +1,13 @@
+package event
+
+// EventPolicyUpdated is fired by authzsvc whenever a policy is granted or removed.
+// The event store keeps its policy cache up to date from it.
+const EventPolicyUpdated = "EventPolicyUpdated"
+
+type EventPolicyUpdatedPayload struct {
+	Method       string `json:"method"` // could be put/delete
+	Sub          string `json:"subject"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	Action       string `json:"action"`
+}
@@ -0,0 +1,53 @@
+package event
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is the envelope every service publishes its events in.
+// The payload is kept raw as the store is agnostic of the event types.
+type Event struct {
+	Meta    EventMeta       `json:"meta"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type EventMeta struct {
+	Version   string    `json:"version"`
+	Source    string    `json:"source"`
+	Time      time.Time `json:"time"`
+	Name      string    `json:"name"`
+	ID        string    `json:"id"`
+	RequestID string    `json:"req_id"`
+}
+
+// AggregateIDs returns the IDs of the aggregates (orders, products, accounts etc.)
+// the event is about. By convention those are the top level payload fields
+// having the suffix _id. Wildcard IDs are skipped.
+func (e *Event) AggregateIDs() map[string]string {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return nil
+	}
+
+	ids := map[string]string{}
+	for k, v := range payload {
+		if !strings.HasSuffix(k, "_id") {
+			continue
+		}
+		var id string
+		switch val := v.(type) {
+		case string:
+			id = val
+		case float64:
+			id = strconv.FormatFloat(val, 'f', -1, 64)
+		}
+		if id == "" || id == "*" {
+			continue
+		}
+		ids[k] = id
+	}
+	return ids
+}
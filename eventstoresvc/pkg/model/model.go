@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// Event is the persisted envelope of an event
+type Event struct {
+	ID        string    `gorm:"primaryKey"`
+	Name      string    `gorm:"index"`
+	Source    string    `gorm:"index"`
+	Version   string
+	RequestID string    `gorm:"index"`
+	Subject   string    // subject on which the event was published
+	Time      time.Time `gorm:"index"`
+	Data      []byte    // the event envelope as received
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	Aggregates []EventAggregate `gorm:"foreignKey:EventID"`
+}
+
+// EventAggregate indexes the IDs of the aggregates found in the payload of an event
+// ex: order_id, product_id
+type EventAggregate struct {
+	EventID string `gorm:"primaryKey"`
+	Key     string `gorm:"primaryKey"`
+	Value   string `gorm:"index"`
+}
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/sorting"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/model"
+)
+
+// EventRepository defines all the DB operations that the service supports
+type EventRepository interface {
+	// StoreEvent persists the event along with its aggregates.
+	// Storing an already stored event is a no-op, so redeliveries are safe.
+	StoreEvent(ctx context.Context, e *model.Event) error
+	ListEvent(ctx context.Context, f dto.EventFilter, qp *dto.BasicQueryParam) ([]model.Event, error)
+	// ListEventInOrder returns at most limit events in the order they were fired
+	ListEventInOrder(ctx context.Context, f dto.EventFilter, limit int) ([]model.Event, error)
+}
+
+type basicEventRepo struct {
+	db *gorm.DB
+}
+
+func NewBasicEventRepo(db *gorm.DB) EventRepository {
+	if db == nil {
+		return nil
+	}
+
+	// auto-migrate tables
+	db.AutoMigrate(&model.Event{}, &model.EventAggregate{})
+
+	return &basicEventRepo{
+		db: db,
+	}
+}
+
+func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if page == 0 {
+			page = 1
+		}
+
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize)
+	}
+}
+
+// filter applies the non zero fields of the event filter
+func filter(f dto.EventFilter) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if f.Name != "" {
+			tx = tx.Where("name = ?", f.Name)
+		}
+		if f.Source != "" {
+			tx = tx.Where("source = ?", f.Source)
+		}
+		if f.RequestID != "" {
+			tx = tx.Where("request_id = ?", f.RequestID)
+		}
+		if f.AggregateID != "" {
+			tx = tx.Where("id IN (?)", tx.Session(&gorm.Session{NewDB: true}).
+				Model(&model.EventAggregate{}).Select("event_id").Where("value = ?", f.AggregateID))
+		}
+		if !f.From.IsZero() {
+			tx = tx.Where("time >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			tx = tx.Where("time <= ?", f.To)
+		}
+		return tx
+	}
+}
+
+func (b *basicEventRepo) StoreEvent(ctx context.Context, e *model.Event) error {
+	return b.db.Clauses(clause.OnConflict{DoNothing: true}).Create(e).Error
+}
+
+func (b *basicEventRepo) ListEvent(ctx context.Context, f dto.EventFilter, qp *dto.BasicQueryParam) (events []model.Event, err error) {
+	tx := b.db.Scopes(filter(f)).Preload("Aggregates")
+	if qp != nil {
+		tx = tx.Scopes(
+			sorting.Scope(qp.Filter.OrderBy),
+			Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
+		)
+	}
+	err = tx.Find(&events).Error
+	return
+}
+
+func (b *basicEventRepo) ListEventInOrder(ctx context.Context, f dto.EventFilter, limit int) (events []model.Event, err error) {
+	err = b.db.Scopes(filter(f)).Order("time").Limit(limit).Find(&events).Error
+	return
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/error"
+	svcpe "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/policy-enforcer"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+)
+
+type authzMW struct {
+	pe   svcpe.PolicyEnforcer
+	next IEventStoreService
+}
+
+// NewAuthzMW lets only the admins, who are granted the events of all the services,
+// query and replay them
+func NewAuthzMW(pe svcpe.PolicyEnforcer) Middleware {
+	return func(s IEventStoreService) IEventStoreService {
+		return &authzMW{pe: pe, next: s}
+	}
+}
+
+func (m *authzMW) HandleEvent(ctx context.Context, subject string, data []byte) error {
+	return m.next.HandleEvent(ctx, subject, data)
+}
+
+func (m *authzMW) ListEvent(ctx context.Context, f dto.EventFilter, qp *dto.BasicQueryParam) dto.ListEventResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.ListEventResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "events", "get", "*")
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.ListEventResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.ListEvent(ctx, f, qp)
+}
+
+func (m *authzMW) ReplayEvent(ctx context.Context, f dto.EventFilter, subject string) (int, error) {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return 0, kitjwt.ErrTokenContextMissing
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "events", "replay", "*")
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return 0, ce.ErrInsufficientPerm
+	}
+	return m.next.ReplayEvent(ctx, f, subject)
+}
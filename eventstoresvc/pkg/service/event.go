@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/error"
+	svcevent "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/event"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/model"
+)
+
+func (svc *basicEventStoreService) HandleEvent(ctx context.Context, subject string, data []byte) error {
+	var e svcevent.Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if e.Meta.ID == "" {
+		return fmt.Errorf("event on %s has no ID", subject)
+	}
+
+	eventObj := &model.Event{
+		ID:        e.Meta.ID,
+		Name:      e.Meta.Name,
+		Source:    e.Meta.Source,
+		Version:   e.Meta.Version,
+		RequestID: e.Meta.RequestID,
+		Subject:   subject,
+		Time:      e.Meta.Time,
+		Data:      data,
+	}
+	for k, v := range e.AggregateIDs() {
+		eventObj.Aggregates = append(eventObj.Aggregates, model.EventAggregate{EventID: e.Meta.ID, Key: k, Value: v})
+	}
+
+	if err := svc.repo.StoreEvent(ctx, eventObj); err != nil {
+		return err
+	}
+
+	// the store gets the events of authzsvc as well, so it keeps its policy cache in step
+	// with them instead of subscribing to them separately
+	if e.Meta.Name == svcevent.EventPolicyUpdated && svc.ps != nil {
+		var p svcevent.EventPolicyUpdatedPayload
+		if err := json.Unmarshal(e.Payload, &p); err == nil {
+			svc.ps.UpdatePolicy(p.Method, p.Sub, p.ResourceType, p.ResourceID, p.Action)
+		}
+	}
+	return nil
+}
+
+func (svc *basicEventStoreService) ListEvent(ctx context.Context, f dto.EventFilter, qp *dto.BasicQueryParam) dto.ListEventResponse {
+	eventObjs, err := svc.repo.ListEvent(ctx, f, qp)
+	if err != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("err getting events [%v]", err))
+		return dto.ListEventResponse{Err: err}
+	}
+
+	events := []dto.GetEventResponse{}
+	for _, e := range eventObjs {
+		resp := dto.GetEventResponse{
+			ID:        e.ID,
+			Name:      e.Name,
+			Source:    e.Source,
+			RequestID: e.RequestID,
+			Subject:   e.Subject,
+			Time:      e.Time,
+			Event:     e.Data,
+		}
+		if len(e.Aggregates) > 0 {
+			resp.Aggregates = map[string]string{}
+			for _, a := range e.Aggregates {
+				resp.Aggregates[a.Key] = a.Value
+			}
+		}
+		events = append(events, resp)
+	}
+
+	return dto.ListEventResponse{Events: events}
+}
+
+func (svc *basicEventStoreService) ReplayEvent(ctx context.Context, f dto.EventFilter, subject string) (int, error) {
+	if subject == "" {
+		return 0, ce.ErrSubjectRequired
+	}
+	// replaying the whole store is most likely a mistake
+	if f.IsEmpty() {
+		return 0, ce.ErrEmptyFilter
+	}
+
+	// fetch one more than the limit to find out if the limit is exceeded
+	eventObjs, err := svc.repo.ListEventInOrder(ctx, f, svc.replayLimit+1)
+	if err != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("err getting events [%v]", err))
+		return 0, err
+	}
+	if len(eventObjs) > svc.replayLimit {
+		return 0, ce.ErrReplayLimitExceeded
+	}
+	for _, e := range eventObjs {
+		if !svc.canReplayOn(e, subject) {
+			return 0, fmt.Errorf("%w: event-%s is of %s", ce.ErrSubjectNotAllowed, e.ID, reqChanOf(e))
+		}
+	}
+
+	// the envelopes are republished as received, so the consumers handle them like the original delivery
+	for i, e := range eventObjs {
+		if err := svc.nc.Conn.Publish(subject, e.Data); err != nil {
+			svc.cl.Error(ctx, fmt.Sprintf("err replaying event-%s [%v]", e.ID, err))
+			return i, err
+		}
+	}
+	if err := svc.nc.Conn.Flush(); err != nil {
+		return len(eventObjs), err
+	}
+
+	svc.cl.Info(ctx, fmt.Sprintf("replayed %d events on %s", len(eventObjs), subject))
+	return len(eventObjs), nil
+}
+
+// reqChanOf returns the request channel the event was fired on, {stream}.{event name}.
+// The event store gets the events of a stream on {stream}.all.eventstoresvc.
+func reqChanOf(e model.Event) string {
+	stream := strings.SplitN(e.Subject, ".", 2)[0]
+	return stream + "." + e.Name
+}
+
+// canReplayOn tells whether the event can be replayed on the subject, which is either its
+// request channel, so that all its consumers get it again, or the deliver subject of one
+// of its consumers, {req_chan}.{svc}. The other subjects would let a replay pass an event
+// off as another one.
+func (svc *basicEventStoreService) canReplayOn(e model.Event, subject string) bool {
+	reqChan := reqChanOf(e)
+	if subject == reqChan {
+		return true
+	}
+	for _, consumer := range svc.replayConsumers {
+		if subject == reqChan+"."+consumer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	svcpe "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/policy-enforcer"
+	cl "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/repo"
+	"github.com/nats-io/nats.go"
+)
+
+// Middleware represents service middleware type
+type Middleware func(IEventStoreService) IEventStoreService
+
+type IEventStoreService interface {
+	// HandleEvent stores the event received on the given subject
+	HandleEvent(ctx context.Context, subject string, data []byte) error
+
+	ListEvent(ctx context.Context, f dto.EventFilter, qp *dto.BasicQueryParam) dto.ListEventResponse
+	// ReplayEvent republishes the filtered events in the order they were fired on the given subject,
+	// which must be the request channel of every one of them or the deliver subject of one of its consumers
+	ReplayEvent(ctx context.Context, f dto.EventFilter, subject string) (int, error)
+}
+
+type basicEventStoreService struct {
+	cl          *cl.CustomLogger
+	repo        repo.EventRepository
+	nc          *nats.EncodedConn
+	ps          svcpe.PolicyStorage
+	replayLimit int
+	// the services whose deliver subjects the events can be replayed on
+	replayConsumers []string
+}
+
+const defaultReplayLimit = 1000
+
+// NewBasicEventStoreService returns a naive, stateless implementation of EventStoreService
+func NewBasicEventStoreService() *basicEventStoreService {
+	return &basicEventStoreService{replayLimit: defaultReplayLimit}
+}
+
+type SvcConf func(*basicEventStoreService) error
+
+func WithRepo(r repo.EventRepository) SvcConf {
+	return func(svc *basicEventStoreService) error {
+		svc.repo = r
+		return nil
+	}
+}
+
+func WithNATSEncodedConn(nc *nats.EncodedConn) SvcConf {
+	return func(svc *basicEventStoreService) error {
+		if nc == nil {
+			return errors.New("nats encoded client not provided")
+		}
+		svc.nc = nc
+		return nil
+	}
+}
+
+func WithPolicyStorage(ps svcpe.PolicyStorage) SvcConf {
+	return func(svc *basicEventStoreService) error {
+		if ps == nil {
+			return errors.New("policy storage obj can't be empty")
+		}
+		svc.ps = ps
+		return nil
+	}
+}
+
+// WithReplayConsumers sets the services on whose deliver subject, {req_chan}.{svc},
+// the events can be replayed. By default they can be replayed on their request channel only.
+func WithReplayConsumers(svcs []string) SvcConf {
+	return func(svc *basicEventStoreService) error {
+		svc.replayConsumers = svcs
+		return nil
+	}
+}
+
+func WithReplayLimit(limit int) SvcConf {
+	return func(svc *basicEventStoreService) error {
+		if limit <= 0 {
+			return errors.New("replay limit must be positive")
+		}
+		svc.replayLimit = limit
+		return nil
+	}
+}
+
+// New returns a EventStoreService implementation with
+// all of the expected config/middleware wired in.
+func New(logger *cl.CustomLogger, mws []Middleware, svcconfs ...SvcConf) IEventStoreService {
+	svc := NewBasicEventStoreService()
+	svc.cl = logger
+	for _, configure := range svcconfs {
+		if configure != nil {
+			if err := configure(svc); err != nil {
+				logger.Error(context.TODO(), fmt.Sprintf("svc err: %v", err))
+				return nil
+			}
+		}
+	}
+
+	var s IEventStoreService
+	s = svc
+	for _, m := range mws {
+		s = m(s)
+	}
+
+	return s
+}
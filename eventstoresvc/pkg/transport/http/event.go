@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	stdhttp "net/http"
+	"time"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/endpoint"
+	ce "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/sorting"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// makeListEventHandler creates the handler logic
+func makeListEventHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/events").Handler(
+		kithttp.NewServer(
+			endpoints.ListEventEndpoint,
+			decodeListEventRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// eventSorts are the fields which the events can be sorted by,
+// they are listed in the order they were fired by default
+var eventSorts = sorting.Fields{
+	"time":   {Column: "time"},
+	"name":   {Column: "name"},
+	"source": {Column: "source"},
+}
+
+// decodeListEventRequest is a transport/http.DecodeRequestFunc that decodes the
+// event filter and pagination details from the query params.
+// ex: /events?aggregate_id={order_id} or /events?source=paymentsvc&from={RFC3339}&to={RFC3339}
+func decodeListEventRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	f, err := processEventFilterQP(r)
+	if err != nil {
+		return nil, err
+	}
+	qp, err := processBasicQP(r, eventSorts, "time")
+	if err != nil {
+		return nil, err
+	}
+	return dto.ListEventRequest{Filter: f, QP: qp}, nil
+}
+
+func processEventFilterQP(r *stdhttp.Request) (f dto.EventFilter, err error) {
+	f.Name = r.FormValue("name")
+	f.Source = r.FormValue("source")
+	f.RequestID = r.FormValue("req_id")
+	f.AggregateID = r.FormValue("aggregate_id")
+	if from := r.FormValue("from"); from != "" {
+		if f.From, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return f, ce.ErrInvalidQueryParam
+		}
+	}
+	if to := r.FormValue("to"); to != "" {
+		if f.To, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return f, ce.ErrInvalidQueryParam
+		}
+	}
+	return f, nil
+}
+
+// makeReplayEventHandler creates the handler logic
+func makeReplayEventHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("POST").Path("/events/replay").Handler(
+		kithttp.NewServer(
+			endpoints.ReplayEventEndpoint,
+			decodeReplayEventRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// decodeReplayEventRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeReplayEventRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	req := dto.ReplayEventRequest{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+	if err != nil {
+		err = ce.ErrInvalidReqBody
+	}
+	return req, err
+}
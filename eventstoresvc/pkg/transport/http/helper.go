@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	stdhttp "net/http"
+	"strconv"
+	"strings"
+
+	stdjwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	kitep "github.com/go-kit/kit/endpoint"
+
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/lib/sorting"
+)
+
+func ErrorEncoder(_ context.Context, err error, w stdhttp.ResponseWriter) {
+	w.WriteHeader(err2code(err))
+	json.NewEncoder(w).Encode(hideSensitiveContent(errorWrapper{Error: err.Error()}))
+}
+
+func ErrorDecoder(r *stdhttp.Response) error {
+	var w errorWrapper
+	if err := json.NewDecoder(r.Body).Decode(&w); err != nil {
+		return err
+	}
+	return errors.New(w.Error)
+}
+
+type errorWrapper struct {
+	Error string `json:"error"`
+}
+
+func hideSensitiveContent(e errorWrapper) errorWrapper {
+	if strings.Contains(e.Error, "failed to connect") {
+		e.Error = "server encountered some issue, please contact the Admin"
+	}
+	return e
+}
+
+// This is used to set the http status, see an example here :
+// https://github.com/go-kit/kit/blob/master/examples/addsvc/pkg/addtransport/http.go#L133
+func err2code(err error) int {
+	// these are wrapped along with the orderby key or the event they are about
+	if errors.Is(err, sorting.ErrInvalidSort) || errors.Is(err, ce.ErrSubjectNotAllowed) {
+		return stdhttp.StatusBadRequest
+	}
+
+	// check error type here
+	if _, ok := err.(*stdjwt.ValidationError); ok {
+		return stdhttp.StatusBadRequest
+	}
+
+	switch err {
+	case io.ErrUnexpectedEOF, io.EOF, ce.ErrInvalidReqBody, ce.ErrInvalidQueryParam,
+		ce.ErrEmptyFilter, ce.ErrSubjectRequired, ce.ErrReplayLimitExceeded:
+		return stdhttp.StatusBadRequest
+	case kitjwt.ErrTokenContextMissing, kitjwt.ErrTokenExpired:
+		return stdhttp.StatusUnauthorized
+	case ce.ErrInsufficientPerm:
+		return stdhttp.StatusForbidden
+	}
+	return stdhttp.StatusInternalServerError
+}
+
+// encodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer. Primarily useful in a server.
+func encodeHTTPGenericResponse(ctx context.Context, w stdhttp.ResponseWriter, response interface{}) (err error) {
+	if f, ok := response.(kitep.Failer); ok && f.Failed() != nil {
+		ErrorEncoder(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func processBasicQP(r *stdhttp.Request, sortable sorting.Fields, defaultOrderBy string) (*dto.BasicQueryParam, error) {
+	l := dto.NewBasicQueryParam()
+	if pageNo := r.FormValue("page"); pageNo != "" {
+		if v, err := strconv.Atoi(pageNo); err == nil {
+			l.Paginator.Page = v
+		}
+	}
+	if pageSize := r.FormValue("page_size"); pageSize != "" {
+		if v, err := strconv.Atoi(pageSize); err == nil {
+			l.Paginator.PageSize = v
+		}
+	}
+	orderBy := r.FormValue("orderby")
+	if orderBy == "" {
+		orderBy = defaultOrderBy
+	}
+	keys, err := sortable.Parse(orderBy)
+	if err != nil {
+		return nil, err
+	}
+	l.Filter.OrderBy = keys
+	return l, nil
+}
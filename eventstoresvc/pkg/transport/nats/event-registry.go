@@ -0,0 +1,68 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	svcconf "github.com/AyushSenapati/reactive-micro/eventstoresvc/conf"
+	svcevent "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/event"
+	cl "github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/eventstoresvc/pkg/service"
+	"github.com/nats-io/nats.go"
+)
+
+// streams lists the JetStream streams of all the services. The consumer of
+// the event store on each of them delivers all its events on {stream}.all.eventstoresvc
+var streams = []string{"authnsvc", "authzsvc", "ordersvc", "inventorysvc", "paymentsvc"}
+
+type EventHandlerFuncs struct {
+	EventHandler nats.Handler
+}
+
+func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IEventStoreService) *EventHandlerFuncs {
+	return &EventHandlerFuncs{
+		EventHandler: makeEventHandler(logger, svc),
+	}
+}
+
+func (ehf *EventHandlerFuncs) GetSubscription(nc *nats.EncodedConn) (subscriptions []*nats.Subscription, err error) {
+	// subscribe to the events of all the streams
+	for _, stream := range streams {
+		var s *nats.Subscription
+		s, err = nc.Subscribe(getTargetSub(stream), ehf.EventHandler)
+		if err != nil {
+			return
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return
+}
+
+func makeEventHandler(logger *cl.CustomLogger, svc service.IEventStoreService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+
+		err := json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		if err != nil {
+			// a malformed event can never be stored, so don't let it be redelivered
+			logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", m.Subject, err))
+			m.Ack()
+			return
+		}
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		err = svc.HandleEvent(ctx, m.Subject, m.Data)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", e.Meta.Name, err))
+			return
+		}
+		m.Ack()
+	}
+}
+
+func getTargetSub(stream string) string {
+	return fmt.Sprintf("%s.all.eventstoresvc", stream)
+}
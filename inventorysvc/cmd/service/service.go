@@ -19,6 +19,7 @@ import (
 	"gorm.io/gorm"
 
 	svcep "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/endpoint"
+	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
 	svcpe "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
 	svcrepo "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/repo"
@@ -33,12 +34,13 @@ import (
 
 var fs = flag.NewFlagSet("inventorysvc", flag.ExitOnError)
 var httpAddr = fs.String("http-addr", ":8084", "HTTP listen address")
+var metricsAddr = fs.String("metrics-addr", "127.0.0.1:9084", "HTTP listen address of the internal metrics")
 
 // holds the name of the protected methods
-var securedMethods = []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct"}
+var securedMethods = []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct", "UpdateProduct", "DeleteProduct", "RestockProduct", "ListStockMovement"}
 
 // holds the name of all the endpoints that ther service supports
-var allMethods = []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct"}
+var allMethods = []string{"CreateMerchant", "ListMerchant", "CreateProduct", "ListProduct", "UpdateProduct", "DeleteProduct", "RestockProduct", "ListStockMovement", "ListEvents"}
 
 // holds the database table names that the service is dealing with
 var allResourceTypes = []string{"merchants", "products", "reserved_products"}
@@ -75,9 +77,19 @@ func Run() {
 	db := getDBConn(confObj.GetDSN())
 
 	// initialise service repo
-	repoObj := svcrepo.NewBasicOrderRepo(db)
-	if repoObj == nil {
-		logger.Info(ctx, "error in initialising service repository")
+	repoObj, err := svcrepo.NewBasicOrderRepo(db)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error in initialising service repository [%v]", err))
+		return
+	}
+
+	// initialise the scheduler of the delayed events
+	scheduler, err := svcevent.NewScheduler(logger, db, nc,
+		svcevent.WithPollInterval(confObj.Scheduler.PollInterval),
+		svcevent.WithLease(confObj.Scheduler.Lease),
+	)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising event scheduler [%v]", err))
 		return
 	}
 
@@ -94,6 +106,10 @@ func Run() {
 		service.WithRepo(repoObj),
 		service.WithNATSEncodedConn(nc),
 		service.WithPolicyStorage(ps),
+		service.WithScheduler(scheduler),
+		service.WithPriceTolerance(confObj.Reservation.PriceTolerance),
+		service.WithReservationTTL(confObj.Reservation.TTL),
+		service.WithMinReservationTTL(confObj.Reservation.MinTTL),
 	}
 	svc := service.New(logger, getServiceMiddleware(confObj, ps), svcConfigs...)
 	if svc == nil {
@@ -101,12 +117,30 @@ func Run() {
 		return
 	}
 
+	// initialise the releaser of the expired reservations
+	releaser, err := service.NewReleaser(logger, svc, confObj.Reservation.ReleaseInterval)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising reservation releaser [%v]", err))
+		return
+	}
+
 	// initialise endpoint
 	eps := svcep.New(svc, getEndpointMW(confObj))
 
+	// project the policies granted before the ACL projection was deployed
+	n, err := svc.BackfillACL(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error backfilling the acl projection [%v]", err))
+		return
+	}
+	logger.Info(ctx, fmt.Sprintf("backfilled the acl projection with %d policies", n))
+
 	g := &run.Group{}
 	initEventHandler(logger, svc, nc, g)
+	g.Add(scheduler.Execute, scheduler.Interrupt)
+	g.Add(releaser.Execute, releaser.Interrupt)
 	initHttpHandler(logger, eps, g)
+	initMetricsHandler(logger, g)
 	initCancelInterrupt(g)
 	err = g.Run()
 	if err != nil {
@@ -168,6 +202,22 @@ func initHttpHandler(logger *cl.CustomLogger, endpoints svcep.Endpoints, g *run.
 	})
 }
 
+func initMetricsHandler(logger *cl.CustomLogger, g *run.Group) {
+	nl, err := net.Listen("tcp", *metricsAddr)
+	if err != nil {
+		logger.Error(context.TODO(), "transport [HTTP metrics]: err during listing on specified address")
+		return
+	}
+	g.Add(func() error {
+		logger.Info(context.TODO(), fmt.Sprintf("transport [HTTP metrics]: listening at %s", *metricsAddr))
+		return http.Serve(nl, httptransport.NewMetricsHandler())
+	}, func(err error) {
+		logger.Error(context.TODO(), fmt.Sprintf("transport [HTTP metrics]: %v", err))
+		nl.Close()
+		logger.Info(context.TODO(), "transport [HTTP metrics]: closed the HTTP listener")
+	})
+}
+
 func initCancelInterrupt(g *run.Group) {
 	cancelInterrupt := make(chan struct{})
 	g.Add(func() error {
@@ -29,6 +29,16 @@ var (
 			"access_kid":        "id_at",
 			"refresh_kid":       "id_rt",
 		},
+		"scheduler": map[string]interface{}{
+			"poll_interval": time.Second,
+			"lease":         time.Second * 30,
+		},
+		"reservation": map[string]interface{}{
+			"price_tolerance":  0.0,
+			"ttl":              time.Minute * 15,
+			"min_ttl":          time.Minute * 6,
+			"release_interval": time.Minute,
+		},
 	}
 )
 
@@ -64,6 +74,28 @@ type Config struct {
 		AccessKID       string        `mapstructure:"access_kid"`
 		RefreshKID      string        `mapstructure:"refresh_kid"`
 	} `mapstructure:"auth"`
+
+	// Scheduler configures the poller of the scheduled events
+	Scheduler struct {
+		PollInterval time.Duration `mapstructure:"poll_interval"`
+		Lease        time.Duration `mapstructure:"lease"`
+	} `mapstructure:"scheduler"`
+
+	// Reservation configures the reservation of the ordered products
+	Reservation struct {
+		// the fraction by which the price of a product may move away from the
+		// price quoted on the order before the order is rejected, e.g. 0.05 for 5%
+		PriceTolerance float64 `mapstructure:"price_tolerance"`
+		// for how long the products stay reserved for an order which is neither
+		// approved nor canceled, unless its merchant or product overrides it
+		TTL time.Duration `mapstructure:"ttl"`
+		// the TTLs are never shorter than this, so that the order saga times out
+		// waiting for the reservation and the payment before the reservation expires.
+		// It must be at least the reserve_timeout plus the payment_timeout of ordersvc.
+		MinTTL time.Duration `mapstructure:"min_ttl"`
+		// how often the releaser looks for the expired reservations
+		ReleaseInterval time.Duration `mapstructure:"release_interval"`
+	} `mapstructure:"reservation"`
 }
 
 func (c *Config) Load(confFname string) error {
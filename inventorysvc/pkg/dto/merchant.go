@@ -4,6 +4,8 @@ import "github.com/google/uuid"
 
 type CreateMerchantRequest struct {
 	Name string `json:"name"`
+	// ReservationTTL is in seconds, 0 for the default of the service
+	ReservationTTL int `json:"reservation_ttl"`
 }
 
 type CreateMerchantResponse struct {
@@ -23,6 +25,7 @@ type MerchantDetailResponse struct {
 
 type ListMerchantResponse struct {
 	Merchants []MerchantDetailResponse `json:"merchants,omitempty"`
+	Page      *Page                    `json:"page,omitempty"`
 	Err       error                    `json:"err,omitempty"`
 }
 
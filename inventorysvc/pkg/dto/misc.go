@@ -1,6 +1,12 @@
 package dto
 
-import stdjwt "github.com/dgrijalva/jwt-go"
+import (
+	stdjwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/paging"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/sorting"
+)
 
 // CustomClaim defines the claim to be used in JWT
 type CustomClaim struct {
@@ -10,23 +16,33 @@ type CustomClaim struct {
 	Role    string `json:"role"`
 }
 
-// BasicQueryParam should be used to param basic pagination and orderby queryparams
+// BasicQueryParam should be used to param basic pagination, orderby and filter queryparams
 type BasicQueryParam struct {
 	Paginator struct {
-		Page     int
+		Cursor   string
 		PageSize int
+		Total    bool
 	}
 	Filter struct {
-		OrederBy []string
+		OrderBy []sorting.Key
+		Conds   []filter.Cond
 	}
 }
 
 func NewBasicQueryParam() *BasicQueryParam {
-	return &BasicQueryParam{
-		Paginator: struct {
-			Page     int
-			PageSize int
-		}{},
-		Filter: struct{ OrederBy []string }{},
+	return &BasicQueryParam{}
+}
+
+// PageQuery returns the query of the page asked for, the rows are identified by idColumn
+func (qp *BasicQueryParam) PageQuery(idColumn string) paging.Query {
+	return paging.Query{
+		Keys:     qp.Filter.OrderBy,
+		IDColumn: idColumn,
+		Cursor:   qp.Paginator.Cursor,
+		Size:     qp.Paginator.PageSize,
+		Total:    qp.Paginator.Total,
 	}
 }
+
+// Page is the envelope of the list responses
+type Page = paging.Page
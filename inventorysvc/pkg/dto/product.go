@@ -8,6 +8,8 @@ type CreateProductRequest struct {
 	Desc  string    `json:"description"`
 	Qty   int       `json:"qty"`
 	Price float32   `json:"price"`
+	// ReservationTTL is in seconds, 0 for the one of the merchant
+	ReservationTTL int `json:"reservation_ttl"`
 }
 
 type CreateProductResponse struct {
@@ -19,6 +21,39 @@ func (resp CreateProductResponse) Failed() error {
 	return resp.Err
 }
 
+// UpdateProductRequest replaces the product on PUT, which requires its name, qty and price,
+// and changes the fields which are given only on PATCH
+type UpdateProductRequest struct {
+	PID     uuid.UUID `json:"-"`
+	Replace bool      `json:"-"`
+	AccntID uint      `json:"-"`
+
+	Name  *string  `json:"name"`
+	Desc  *string  `json:"description"`
+	Qty   *int     `json:"qty"`
+	Price *float32 `json:"price"`
+	// ReservationTTL is in seconds, 0 for the one of the merchant
+	ReservationTTL *int `json:"reservation_ttl"`
+}
+
+type UpdateProductResponse struct {
+	ID      uuid.UUID `json:"id,omitempty"`
+	Version int       `json:"version,omitempty"`
+	Err     error     `json:"err,omitempty"`
+}
+
+func (resp UpdateProductResponse) Failed() error {
+	return resp.Err
+}
+
+type DeleteProductResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (resp DeleteProductResponse) Failed() error {
+	return resp.Err
+}
+
 type ProductDetailsResponse struct {
 	ID       uuid.UUID `json:"id,omitempty"`
 	Name     string    `json:"name,omitempty"`
@@ -30,6 +65,7 @@ type ProductDetailsResponse struct {
 
 type ListProductResponse struct {
 	Products []ProductDetailsResponse `json:"products,omitempty"`
+	Page     *Page                    `json:"page,omitempty"`
 	Err      error                    `json:"error,omitempty"`
 }
 
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RestockProductRequest struct {
+	PID    uuid.UUID `json:"-"`
+	Qty    int       `json:"qty"`
+	Reason string    `json:"reason"`
+}
+
+type RestockProductResponse struct {
+	ID      uuid.UUID `json:"id,omitempty"`
+	Qty     int       `json:"qty"`
+	Version int       `json:"version,omitempty"`
+	Err     error     `json:"err,omitempty"`
+}
+
+func (resp RestockProductResponse) Failed() error {
+	return resp.Err
+}
+
+type ListStockMovementRequest struct {
+	PID uuid.UUID
+	QP  *BasicQueryParam
+}
+
+type StockMovementResponse struct {
+	ID        uint       `json:"id"`
+	Kind      string     `json:"kind"`
+	Qty       int        `json:"qty"`
+	Delta     int        `json:"delta"`
+	Balance   int        `json:"balance"`
+	OrderID   *uuid.UUID `json:"order_id,omitempty"`
+	Actor     string     `json:"actor,omitempty"`
+	RequestID string     `json:"request_id,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ListStockMovementResponse struct {
+	Movements []StockMovementResponse `json:"movements"`
+	Page      *Page                   `json:"page,omitempty"`
+	Err       error                   `json:"err,omitempty"`
+}
+
+func (resp ListStockMovementResponse) Failed() error {
+	return resp.Err
+}
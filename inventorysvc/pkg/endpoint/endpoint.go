@@ -13,6 +13,11 @@ type Endpoints struct {
 	ListMerchantEndpoint   endpoint.Endpoint
 	CreateProductEndpoint  endpoint.Endpoint
 	ListProductEndpoint    endpoint.Endpoint
+	UpdateProductEndpoint  endpoint.Endpoint
+	DeleteProductEndpoint  endpoint.Endpoint
+
+	RestockProductEndpoint    endpoint.Endpoint
+	ListStockMovementEndpoint endpoint.Endpoint
 }
 
 // New returns a Endpoints struct that wraps the provided service, and wires in all of the
@@ -23,6 +28,11 @@ func New(s service.IInventoryService, mdw map[string][]endpoint.Middleware) Endp
 		ListMerchantEndpoint:   makeListMerchantEndpoint(s),
 		CreateProductEndpoint:  makeCreateProductEndpoint(s),
 		ListProductEndpoint:    makeListProductEndpoint(s),
+		UpdateProductEndpoint:  makeUpdateProductEndpoint(s),
+		DeleteProductEndpoint:  makeDeleteProductEndpoint(s),
+
+		RestockProductEndpoint:    makeRestockProductEndpoint(s),
+		ListStockMovementEndpoint: makeListStockMovementEndpoint(s),
 	}
 
 	// apply transport middlewares
@@ -42,5 +52,21 @@ func New(s service.IInventoryService, mdw map[string][]endpoint.Middleware) Endp
 		eps.ListProductEndpoint = m(eps.ListProductEndpoint)
 	}
 
+	for _, m := range mdw["UpdateProduct"] {
+		eps.UpdateProductEndpoint = m(eps.UpdateProductEndpoint)
+	}
+
+	for _, m := range mdw["DeleteProduct"] {
+		eps.DeleteProductEndpoint = m(eps.DeleteProductEndpoint)
+	}
+
+	for _, m := range mdw["RestockProduct"] {
+		eps.RestockProductEndpoint = m(eps.RestockProductEndpoint)
+	}
+
+	for _, m := range mdw["ListStockMovement"] {
+		eps.ListStockMovementEndpoint = m(eps.ListStockMovementEndpoint)
+	}
+
 	return eps
 }
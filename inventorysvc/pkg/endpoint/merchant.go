@@ -2,13 +2,14 @@ package endpoint
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/service"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
-	"github.com/google/uuid"
 )
 
 func makeCreateMerchantEndpoint(s service.IInventoryService) endpoint.Endpoint {
@@ -22,12 +23,18 @@ func makeCreateMerchantEndpoint(s service.IInventoryService) endpoint.Endpoint {
 			return dto.CreateMerchantResponse{Err: ce.ErrInvalidReqBody}, nil
 		}
 
-		return s.CreateMerchant(ctx, claim.AccntID, reqObj.Name), nil
+		ttl := time.Duration(reqObj.ReservationTTL) * time.Second
+		return s.CreateMerchant(ctx, claim.AccntID, reqObj.Name, ttl), nil
 	}
 }
 
 func makeListMerchantEndpoint(s service.IInventoryService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		return s.ListMerchant(ctx, []uuid.UUID{}), nil
+		qp, _ := request.(*dto.BasicQueryParam)
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.ListMerchantResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+		return s.ListMerchant(ctx, fmt.Sprint(claim.AccntID), qp), nil
 	}
 }
@@ -2,6 +2,8 @@ package endpoint
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
@@ -23,7 +25,8 @@ func makeCreateProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
 			return dto.CreateProductResponse{Err: ce.ErrInvalidReqBody}, nil
 		}
 
-		return s.CreateProduct(ctx, claim.AccntID, reqObj.MID, reqObj.Name, reqObj.Desc, reqObj.Qty, reqObj.Price), nil
+		ttl := time.Duration(reqObj.ReservationTTL) * time.Second
+		return s.CreateProduct(ctx, claim.AccntID, reqObj.MID, reqObj.Name, reqObj.Desc, reqObj.Qty, reqObj.Price, ttl), nil
 	}
 
 }
@@ -31,6 +34,66 @@ func makeCreateProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
 func makeListProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		qp, _ := request.(*dto.BasicQueryParam)
-		return s.ListProduct(ctx, []uuid.UUID{}, qp), nil
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.ListProductResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+		return s.ListProduct(ctx, fmt.Sprint(claim.AccntID), qp), nil
+	}
+}
+
+func makeUpdateProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.UpdateProductResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+
+		reqObj, ok := request.(dto.UpdateProductRequest)
+		if !ok {
+			return dto.UpdateProductResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		reqObj.AccntID = claim.AccntID
+		return s.UpdateProduct(ctx, reqObj), nil
+	}
+}
+
+func makeDeleteProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.DeleteProductResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+
+		pid, ok := request.(uuid.UUID)
+		if !ok {
+			return dto.DeleteProductResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.DeleteProduct(ctx, claim.AccntID, pid), nil
+	}
+}
+
+func makeRestockProductEndpoint(s service.IInventoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.RestockProductResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+
+		reqObj, ok := request.(dto.RestockProductRequest)
+		if !ok {
+			return dto.RestockProductResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.RestockProduct(ctx, claim.AccntID, reqObj.PID, reqObj.Qty, reqObj.Reason), nil
+	}
+}
+
+func makeListStockMovementEndpoint(s service.IInventoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqObj, ok := request.(dto.ListStockMovementRequest)
+		if !ok {
+			return dto.ListStockMovementResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.ListStockMovement(ctx, reqObj.PID, reqObj.QP), nil
 	}
 }
@@ -21,6 +21,14 @@ var (
 	// ErrInvalidReqBody should be used when request body
 	// does not match expected fields
 	ErrInvalidReqBody = errors.New("invalid request body")
+
+	// ErrProductReserved should be used when a product can't be deleted
+	// as some of its stock is reserved for the orders in progress
+	ErrProductReserved = errors.New("product has active reservations")
+
+	// ErrReservationTTLTooShort should be used when a reservation TTL would expire
+	// the reservation before the order saga is done waiting for the payment
+	ErrReservationTTLTooShort = errors.New("reservation ttl is shorter than the minimum")
 )
 
 type ResourceNotFoundErr struct {
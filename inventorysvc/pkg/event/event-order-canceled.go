@@ -8,6 +8,7 @@ const EventOrderCanceled EventName = "EventOrderCanceled"
 func init() {
 	Registry.register(EventOrderCanceled, EventInfo{
 		ReqChan: "ordersvc.EventOrderCanceled",
+		Payload: EventOrderCanceledPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventOrderCanceledPayload)
 			return ok
@@ -16,6 +17,6 @@ func init() {
 }
 
 type EventOrderCanceledPayload struct {
-	OID     uuid.UUID `json:"order_id"`
-	AccntID uint      `json:"account_id"`
+	OID     uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
 }
@@ -0,0 +1,29 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventPriceMismatch EventName = "EventPriceMismatch"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventPriceMismatch, EventInfo{
+		ReqChan: "inventorysvc.EventPriceMismatch",
+		Payload: EventPriceMismatchPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventPriceMismatchPayload)
+			return ok
+		},
+	})
+}
+
+type EventPriceMismatchPayload struct {
+	OrderID uuid.UUID                `json:"order_id" validate:"required"`
+	Lines   []EventPriceMismatchLine `json:"lines" validate:"min=1"`
+}
+
+// EventPriceMismatchLine is a product whose price moved away from the quoted one
+type EventPriceMismatchLine struct {
+	ProductID   uuid.UUID `json:"product_id" validate:"required"`
+	QuotedPrice float32   `json:"quoted_price" validate:"min=0"`
+	Price       float32   `json:"price" validate:"min=0"`
+}
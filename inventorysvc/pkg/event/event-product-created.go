@@ -0,0 +1,26 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventProductCreated EventName = "EventProductCreated"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventProductCreated, EventInfo{
+		ReqChan: "inventorysvc.EventProductCreated",
+		Payload: EventProductCreatedPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventProductCreatedPayload)
+			return ok
+		},
+	})
+}
+
+type EventProductCreatedPayload struct {
+	ProductID  uuid.UUID `json:"product_id" validate:"required"`
+	MerchantID uuid.UUID `json:"merchant_id" validate:"required"`
+	Name       string    `json:"name" validate:"required"`
+	Price      float32   `json:"price" validate:"min=0"`
+	Qty        int       `json:"qty" validate:"min=0"`
+	Version    int       `json:"version" validate:"min=1"`
+}
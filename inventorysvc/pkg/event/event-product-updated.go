@@ -0,0 +1,27 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventProductUpdated EventName = "EventProductUpdated"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventProductUpdated, EventInfo{
+		ReqChan: "inventorysvc.EventProductUpdated",
+		Payload: EventProductUpdatedPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventProductUpdatedPayload)
+			return ok
+		},
+	})
+}
+
+// EventProductUpdatedPayload carries the whole product as of its version
+type EventProductUpdatedPayload struct {
+	ProductID  uuid.UUID `json:"product_id" validate:"required"`
+	MerchantID uuid.UUID `json:"merchant_id" validate:"required"`
+	Name       string    `json:"name" validate:"required"`
+	Price      float32   `json:"price" validate:"min=0"`
+	Qty        int       `json:"qty" validate:"min=0"`
+	Version    int       `json:"version" validate:"min=1"`
+}
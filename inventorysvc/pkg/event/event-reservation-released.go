@@ -0,0 +1,21 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventReservationReleased EventName = "EventReservationReleased"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventReservationReleased, EventInfo{
+		ReqChan: "inventorysvc.EventReservationReleased",
+		Payload: EventReservationReleasedPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventReservationReleasedPayload)
+			return ok
+		},
+	})
+}
+
+type EventReservationReleasedPayload struct {
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+}
@@ -0,0 +1,247 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	cl "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultLease        = 30 * time.Second
+	defaultBatchSize    = 100
+)
+
+// ScheduledEvent is a row of the schedule table. It holds a serialised event
+// which is to be published at PublishAt, unless it is canceled by its Key before that.
+type ScheduledEvent struct {
+	// ID is the ID of the event, it is used as the Nats-Msg-Id of the published
+	// message so that JetStream drops the duplicates of a republished event
+	ID        string    `gorm:"primaryKey"`
+	Key       string    `gorm:"index"`
+	Name      string    `gorm:"index"`
+	Data      []byte    `gorm:"not null"`
+	PublishAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	// the replica which is publishing the event holds the row till LockedUntil
+	LockedBy    string
+	LockedUntil time.Time `gorm:"index"`
+}
+
+// Scheduler persists events in the schedule table of the service and publishes
+// them once they are due. Being backed by the DB, scheduled events survive
+// restarts and any number of replicas can poll the same table, as a due event
+// is claimed by a single replica for a lease before being published.
+type Scheduler struct {
+	cl  *cl.CustomLogger
+	db  *gorm.DB
+	js  nats.JetStreamContext
+	id  string
+	now func() time.Time
+
+	pollInterval time.Duration
+	lease        time.Duration
+	batchSize    int
+
+	cancel chan struct{}
+}
+
+type SchedulerConf func(*Scheduler) error
+
+// WithPollInterval sets how often the schedule table is checked for due events
+func WithPollInterval(d time.Duration) SchedulerConf {
+	return func(s *Scheduler) error {
+		if d <= 0 {
+			return errors.New("scheduler: poll interval must be positive")
+		}
+		s.pollInterval = d
+		return nil
+	}
+}
+
+// WithLease sets for how long a replica holds a due event while publishing it.
+// If the replica dies in between, the event is published by another one after the lease.
+func WithLease(d time.Duration) SchedulerConf {
+	return func(s *Scheduler) error {
+		if d <= 0 {
+			return errors.New("scheduler: lease must be positive")
+		}
+		s.lease = d
+		return nil
+	}
+}
+
+// NewScheduler returns a Scheduler which stores the scheduled events in db
+// and publishes them to JetStream through nc. It auto-migrates the schedule table.
+func NewScheduler(logger *cl.CustomLogger, db *gorm.DB, nc *nats.EncodedConn, confs ...SchedulerConf) (*Scheduler, error) {
+	if db == nil {
+		return nil, errors.New("scheduler: db not provided")
+	}
+	if nc == nil {
+		return nil, ErrNilNATSConnObj
+	}
+	js, err := nc.Conn.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: error getting JetStream context [%v]", err)
+	}
+
+	hostname, _ := os.Hostname()
+	s := &Scheduler{
+		cl:           logger,
+		db:           db,
+		js:           js,
+		id:           fmt.Sprintf("%s-%s", hostname, uuid.New().String()),
+		now:          func() time.Time { return time.Now().UTC() },
+		pollInterval: defaultPollInterval,
+		lease:        defaultLease,
+		batchSize:    defaultBatchSize,
+		cancel:       make(chan struct{}),
+	}
+	for _, configure := range confs {
+		if err := configure(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.AutoMigrate(&ScheduledEvent{}); err != nil {
+		return nil, fmt.Errorf("scheduler: error migrating schedule table [%v]", err)
+	}
+	return s, nil
+}
+
+// Schedule persists the event to be published at the given time. key groups the
+// scheduled events of an entity (e.g. order ID) so that they can be canceled together.
+func (s *Scheduler) Schedule(ctx context.Context, e IEvent, key string, at time.Time) error {
+	if e == nil {
+		return errors.New("scheduler: event not provided")
+	}
+	ev, ok := e.(*Event)
+	if !ok {
+		return fmt.Errorf("scheduler: unsupported event type %T", e)
+	}
+	// fail early instead of when the event is due
+	if _, err := Registry.GetEventInfo(EventName(ev.Meta.Name)); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("scheduler: error serialising event: %s [%v]", ev.Name(), err)
+	}
+	row := ScheduledEvent{
+		ID:        ev.Meta.ID,
+		Key:       key,
+		Name:      ev.Meta.Name,
+		Data:      data,
+		PublishAt: at.UTC(),
+	}
+	return s.db.WithContext(ctx).Create(&row).Error
+}
+
+// Cancel deletes the events scheduled with the given key. If names are given
+// only those events are canceled. It returns the number of canceled events.
+// An event which is already being published can't be canceled.
+func (s *Scheduler) Cancel(ctx context.Context, key string, names ...EventName) (int64, error) {
+	tx := s.db.WithContext(ctx).Where("key = ? AND locked_until < ?", key, s.now())
+	if len(names) > 0 {
+		tx = tx.Where("name IN ?", names)
+	}
+	res := tx.Delete(&ScheduledEvent{})
+	return res.RowsAffected, res.Error
+}
+
+// PublishDue publishes the events which are due by now and
+// returns the number of events published by this replica
+func (s *Scheduler) PublishDue(ctx context.Context) (int, error) {
+	now := s.now()
+
+	var due []ScheduledEvent
+	err := s.db.WithContext(ctx).
+		Where("publish_at <= ? AND locked_until < ?", now, now).
+		Order("publish_at").Limit(s.batchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, se := range due {
+		// claim the event, another replica might have done it meanwhile
+		res := s.db.WithContext(ctx).Model(&ScheduledEvent{}).
+			Where("id = ? AND locked_until < ?", se.ID, now).
+			Updates(map[string]interface{}{"locked_by": s.id, "locked_until": now.Add(s.lease)})
+		if res.Error != nil {
+			return published, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		// the event is deleted only once JetStream acked it,
+		// on failure it is retried once the lease expires
+		if err := s.publish(se); err != nil {
+			s.cl.Error(ctx, fmt.Sprintf("scheduler: error publishing event: %s [%v]", se.Name, err))
+			continue
+		}
+
+		err := s.db.WithContext(ctx).
+			Where("id = ? AND locked_by = ?", se.ID, s.id).
+			Delete(&ScheduledEvent{}).Error
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (s *Scheduler) publish(se ScheduledEvent) error {
+	t, err := Registry.GetEventInfo(EventName(se.Name))
+	if err != nil {
+		return err
+	}
+	if t.ReqChan == "" {
+		return &ErrEventReqChNotSet{EventName(se.Name)}
+	}
+	msg := nats.NewMsg(t.ReqChan)
+	msg.Data = se.Data
+	msg.Header.Set(nats.MsgIdHdr, se.ID)
+	_, err = s.js.PublishMsg(msg)
+	return err
+}
+
+// Execute polls the schedule table till the scheduler is interrupted
+func (s *Scheduler) Execute() error {
+	s.cl.Info(context.TODO(), "scheduler: initialised")
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.cancel:
+			s.cl.Info(context.TODO(), "scheduler: closed")
+			return nil
+		case <-ticker.C:
+			n, err := s.PublishDue(context.TODO())
+			if err != nil {
+				s.cl.Error(context.TODO(), fmt.Sprintf("scheduler: %v", err))
+			}
+			if n > 0 {
+				s.cl.Debug(context.TODO(), fmt.Sprintf("scheduler: published %d events", n))
+			}
+		}
+	}
+}
+
+func (s *Scheduler) Interrupt(err error) {
+	close(s.cancel)
+}
@@ -20,6 +20,11 @@ type Policy struct {
 	sub, rtype, rid, act string
 }
 
+func (p Policy) Sub() string          { return p.sub }
+func (p Policy) ResourceType() string { return p.rtype }
+func (p Policy) ResourceID() string   { return p.rid }
+func (p Policy) Action() string       { return p.act }
+
 func sliceIndex(limit int, predicate func(i int) bool) int {
 	for i := 0; i < limit; i++ {
 		if predicate(i) {
@@ -110,12 +115,14 @@ func getPolicyFromString(s string) (Policy, error) {
 }
 
 type policiesResponse struct {
-	Policies []string `json:"policies"`
+	Policies   []string `json:"policies"`
+	NextCursor string   `json:"next_cursor"`
 }
 
 type getPoliciesRequest struct {
 	Sub          string `json:"sub"`
 	ResourceType string `json:"resource_type"`
+	Cursor       string `json:"cursor,omitempty"`
 }
 
 type PolicyStorageMW func(PolicyStorage) PolicyStorage
@@ -123,6 +130,9 @@ type PolicyStorageMW func(PolicyStorage) PolicyStorage
 type PolicyStorage interface {
 	GetPolicyForSub(ctx context.Context, sub string) []Policy
 	UpdatePolicy(method, sub, rtype, rid, act string) error
+	// ListPolicy lists the policies of all the subjects on the resource types
+	// supported by this service, e.g. to build a projection of them
+	ListPolicy(ctx context.Context) ([]Policy, error)
 }
 
 type cachedPolicyStorage struct {
@@ -178,6 +188,53 @@ func (cps *cachedPolicyStorage) FetchPolicyForSub(ctx context.Context, sub strin
 	return ep
 }
 
+func (cps *cachedPolicyStorage) ListPolicy(ctx context.Context) (fPolicies []Policy, err error) {
+	// an empty sub gets the policies of all the subjects, a page at a time
+	for _, rtype := range cps.rtypes {
+		cursor := ""
+		for {
+			respBody, err := cps.listPolicyPage(ctx, rtype, cursor)
+			if err != nil {
+				return nil, err
+			}
+			for _, rp := range respBody.Policies {
+				fp, err := getPolicyFromString(rp)
+				if err != nil {
+					continue
+				}
+				fPolicies = append(fPolicies, fp)
+			}
+			if respBody.NextCursor == "" {
+				break
+			}
+			cursor = respBody.NextCursor
+		}
+	}
+	return fPolicies, nil
+}
+
+func (cps *cachedPolicyStorage) listPolicyPage(ctx context.Context, rtype, cursor string) (*policiesResponse, error) {
+	body := &getPoliciesRequest{Sub: "", ResourceType: rtype, Cursor: cursor}
+	jsonbody, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, "GET", cps.url, bytes.NewBuffer(jsonbody))
+	if reqID, ok := ctx.Value(svcconf.C.ReqIDKey).(string); ok {
+		req.Header.Set(svcconf.C.ReqIDKey, reqID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed listing policies of rtype-%s [got status code: %d]", rtype, resp.StatusCode)
+	}
+	respBody := &policiesResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+		return nil, err
+	}
+	return respBody, nil
+}
+
 func (cps *cachedPolicyStorage) GetPolicyForSub(ctx context.Context, sub string) (fPolicies []Policy) {
 	cachedEPolicy, found := cps.cache.Get(sub) // gets ePolicy obj
 
@@ -0,0 +1,13 @@
+package model
+
+import "github.com/google/uuid"
+
+// ACLEntry is the local projection of a policy of authzsvc granting an action on
+// a single resource. It is kept up to date from the policy events, so that the
+// resources an account may get are listed by a join rather than a list of IDs.
+type ACLEntry struct {
+	Subject      string    `gorm:"primaryKey"`
+	ResourceType string    `gorm:"primaryKey"`
+	ResourceID   uuid.UUID `gorm:"primaryKey"`
+	Action       string    `gorm:"primaryKey"`
+}
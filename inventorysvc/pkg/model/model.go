@@ -10,6 +10,10 @@ type Merchant struct {
 	ID      uuid.UUID `gorm:"primaryKey"`
 	Name    string    `gorm:"unique"`
 	AdminID uint      `json:"admin_id,omitempty"`
+
+	// ReservationTTL is for how long the products of the merchant stay reserved
+	// for an order which is neither approved nor canceled, 0 for the default
+	ReservationTTL time.Duration
 }
 
 type Product struct {
@@ -23,10 +27,24 @@ type Product struct {
 	Qty        int
 	Price      float32
 	Desc       string
+
+	// ReservationTTL overrides the one of the merchant for the product, 0 for none
+	ReservationTTL time.Duration
+
+	// Version is bumped with every change of the product, so that the
+	// consumers of the product events can drop the ones which are stale
+	Version int `gorm:"not null;default:1"`
 }
 
+// ReservedProduct is a line of an order reserved from the stock of the product
 type ReservedProduct struct {
 	OID uuid.UUID `gorm:"primaryKey"`
-	PID uuid.UUID
+	PID uuid.UUID `gorm:"primaryKey"`
 	Qty int
+	// UnitPrice is the price the line is charged at, as quoted on the order
+	UnitPrice float32
+
+	ReservedAt time.Time `gorm:"autoCreateTime"`
+	// ExpiresAt is when the releaser gives the stock back unless the order got approved or canceled
+	ExpiresAt time.Time `gorm:"index"`
 }
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockMovementKind tells why the stock of a product moved
+type StockMovementKind string
+
+const (
+	StockMovementInitial    StockMovementKind = "initial"
+	StockMovementRestock    StockMovementKind = "restock"
+	StockMovementReserve    StockMovementKind = "reserve"
+	StockMovementRelease    StockMovementKind = "release"
+	StockMovementSale       StockMovementKind = "sale"
+	StockMovementAdjustment StockMovementKind = "adjustment"
+)
+
+// SystemActor is the actor of the movements made on the events or by the releaser
+// rather than on the request of an account
+const SystemActor = "system"
+
+// StockMovement is an entry of the append-only ledger of the stock of the products.
+// It is written in the same transaction as the change of the qty of the product,
+// so that the deltas of the movements of a product sum up to its qty.
+type StockMovement struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	PID       uuid.UUID `gorm:"index"`
+	Kind      StockMovementKind
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	// Qty is the number of units moved, Delta is the change of the qty of the product
+	// it made. A sale moves the units reserved already, so its delta is 0.
+	Qty   int
+	Delta int
+	// Balance is the qty of the product after the movement
+	Balance int
+	// OID is the order the units were reserved, released or sold for, if any
+	OID *uuid.UUID `gorm:"index"`
+
+	MovementCause `gorm:"embedded"`
+}
+
+// MovementCause tells who moved the stock of a product, the account or the SystemActor,
+// the request it was made on and the reason if there is one
+type MovementCause struct {
+	Actor     string
+	RequestID string
+	Reason    string
+}
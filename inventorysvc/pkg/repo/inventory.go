@@ -2,140 +2,393 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/paging"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
 )
 
+// ErrInsufficientStock is returned when a product of an order can't be reserved
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// PriceMismatch is a product whose price moved away from the quoted one
+type PriceMismatch struct {
+	PID         uuid.UUID
+	QuotedPrice float32
+	Price       float32
+}
+
+// ErrPriceMismatch is returned when the prices of some products of an order
+// moved away from the quoted ones beyond the tolerance
+type ErrPriceMismatch struct {
+	Lines []PriceMismatch
+}
+
+func (e *ErrPriceMismatch) Error() string {
+	return fmt.Sprintf("price of %d product(s) changed since quoted", len(e.Lines))
+}
+
 // InventoryRepository defines all the DB operations that the service supports
 type InventoryRepository interface {
-	CreateMerchant(ctx context.Context, merchantName string, adminID uint) (uuid.UUID, error)
-	ListMerchant(ctx context.Context) (merchants []model.Merchant, err error)
-	ListMerchantByIDs(ctx context.Context, mids []uuid.UUID) ([]model.Merchant, error)
+	CreateMerchant(ctx context.Context, merchantName string, adminID uint, ttl time.Duration) (uuid.UUID, error)
+	ListMerchant(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Merchant, *dto.Page, error)
+	// ListMerchantGrantedTo lists the merchants the subject may get as per the ACL projection
+	ListMerchantGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) ([]model.Merchant, *dto.Page, error)
 
-	CreateProduct(ctx context.Context, name, desc string, mid uuid.UUID, qty int, price float32) (uuid.UUID, error)
-	ListProduct(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Product, error)
-	ListProductByIDs(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) ([]model.Product, error)
-	ReserveProduct(ctx context.Context, oid, pid uuid.UUID, qty int) (payble float32, err error)
-	RemoveReservedProduct(ctx context.Context, oid uuid.UUID) error
-	UndoReserveProduct(ctx context.Context, oid uuid.UUID) error
+	// CreateProduct creates the product and records its initial stock in the ledger
+	CreateProduct(ctx context.Context, name, desc string, mid uuid.UUID, qty int, price float32, ttl time.Duration, cause model.MovementCause) (uuid.UUID, error)
+	ListProduct(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Product, *dto.Page, error)
+	ListProductByIDs(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) ([]model.Product, *dto.Page, error)
+	// UpdateProduct sets the given columns of the product and returns it as of its new version.
+	// A change of its qty is recorded in the ledger as an adjustment.
+	UpdateProduct(ctx context.Context, pid uuid.UUID, columns map[string]interface{}, cause model.MovementCause) (model.Product, error)
+	// DeleteProduct deletes the product unless some of its stock is reserved, and returns it
+	// as of the version of its deletion. The stock left is recorded in the ledger as an adjustment.
+	DeleteProduct(ctx context.Context, pid uuid.UUID, cause model.MovementCause) (model.Product, error)
+	// ListProductGrantedTo lists the products the subject may get as per the ACL projection
+	ListProductGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) ([]model.Product, *dto.Page, error)
+	// ReserveProduct reserves every line of the order or none of them and returns the total payable.
+	// The lines are charged at their quoted UnitPrice, unless the current price moved away from it
+	// by more than the tolerance, a fraction of the quoted price. The lines not quoted are charged
+	// at the current price. A line expires after the TTL of its product, else of its merchant, else ttl,
+	// but not before minTTL.
+	// Reserving an order again returns the payable of its existing reservation.
+	ReserveProduct(ctx context.Context, oid uuid.UUID, lines []model.ReservedProduct, tolerance float64, ttl, minTTL time.Duration, cause model.MovementCause) (payble float32, err error)
+	// RemoveReservedProduct removes the reservation of a sold order, its lines are recorded as sales
+	RemoveReservedProduct(ctx context.Context, oid uuid.UUID, cause model.MovementCause) error
+	// UndoReserveProduct puts the reserved quantities back to the stock and returns the released lines.
+	// A line is released once, even by concurrent calls.
+	UndoReserveProduct(ctx context.Context, oid uuid.UUID, cause model.MovementCause) ([]model.ReservedProduct, error)
+	// ListExpiredReservations lists up to limit orders having a line reserved which expired before the given time
+	ListExpiredReservations(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+
+	// RestockProduct adds qty to the stock of the product and returns it as of its new version
+	RestockProduct(ctx context.Context, pid uuid.UUID, qty int, cause model.MovementCause) (model.Product, error)
+	// ListStockMovement lists the movements of the stock of the product from the ledger
+	ListStockMovement(ctx context.Context, pid uuid.UUID, qp *dto.BasicQueryParam) ([]model.StockMovement, *dto.Page, error)
+
+	// GrantACL and RevokeACL keep the ACL projection in step with the policy events
+	GrantACL(ctx context.Context, e model.ACLEntry) error
+	RevokeACL(ctx context.Context, e model.ACLEntry) error
 }
 
 type basicInventoryRepo struct {
 	db *gorm.DB
 }
 
-func NewBasicOrderRepo(db *gorm.DB) InventoryRepository {
+// NewBasicOrderRepo migrates the tables of the inventory and returns the repo.
+// It fails if any migration fails, as the service can't run on a partial schema.
+func NewBasicOrderRepo(db *gorm.DB) (InventoryRepository, error) {
 	if db == nil {
-		return nil
+		return nil, errors.New("repo: db not provided")
 	}
 
 	// auto-migrate tables
-	db.AutoMigrate(&model.Merchant{}, &model.Product{}, &model.ReservedProduct{})
+	err := db.AutoMigrate(&model.Merchant{}, &model.Product{}, &model.ReservedProduct{}, &model.ACLEntry{}, &model.StockMovement{})
+	if err != nil {
+		return nil, fmt.Errorf("repo: error migrating inventory tables [%v]", err)
+	}
+	if err := migrateReservedProductKey(db); err != nil {
+		return nil, fmt.Errorf("repo: error migrating reserved products key [%v]", err)
+	}
+	if err := openStockLedger(db); err != nil {
+		return nil, fmt.Errorf("repo: error opening the stock ledger [%v]", err)
+	}
 
 	return &basicInventoryRepo{
 		db: db,
-	}
+	}, nil
 }
 
-func orderBy(orderBy []string) func(tx *gorm.DB) *gorm.DB {
-	return func(tx *gorm.DB) *gorm.DB {
-		for _, o := range orderBy {
-			tx = tx.Order(o)
-		}
-		return tx
+// migrateReservedProductKey widens the primary key of reserved_products from the order
+// to the order line, as AutoMigrate does not alter the keys of an existing table
+func migrateReservedProductKey(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	var keyColumns int64
+	err := db.Raw(`select count(*) from information_schema.key_column_usage
+		where table_name = 'reserved_products' and constraint_name = 'reserved_products_pkey'`).
+		Scan(&keyColumns).Error
+	if err != nil || keyColumns != 1 {
+		return err
 	}
+	return db.Exec(`alter table reserved_products
+		drop constraint if exists reserved_products_pkey,
+		add primary key (o_id, p_id)`).Error
 }
 
-func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		if page == 0 {
-			page = 1
-		}
+// openStockLedger records the qty of the products created before the ledger was introduced
+// as their initial movement, so that the deltas of the movements of every product sum up to its qty
+func openStockLedger(db *gorm.DB) error {
+	return db.Exec(`insert into stock_movements (p_id, kind, created_at, qty, delta, balance, actor, reason)
+		select p.id, ?, ?, p.qty, p.qty, p.qty, ?, ? from products p
+		where not exists (select 1 from stock_movements m where m.p_id = p.id)`,
+		model.StockMovementInitial, time.Now(), model.SystemActor, "opening balance").Error
+}
 
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
-		}
+// merchantFilters are the fields which the merchants can be filtered by
+var merchantFilters = filter.Fields{
+	"id":       {Column: "id", Kind: filter.UUID},
+	"name":     {Column: "name", Kind: filter.String},
+	"admin_id": {Column: "admin_id", Kind: filter.Number},
+}
+
+// productFilters are the fields which the products can be filtered by
+var productFilters = filter.Fields{
+	"id":          {Column: "id", Kind: filter.UUID},
+	"name":        {Column: "name", Kind: filter.String},
+	"merchant_id": {Column: "merchant_id", Kind: filter.UUID},
+	"price":       {Column: "price", Kind: filter.Number},
+	"qty":         {Column: "qty", Kind: filter.Number},
+	"created_at":  {Column: "created_at", Kind: filter.Time},
+	"updated_at":  {Column: "updated_at", Kind: filter.Time},
+}
 
-		offset := (page - 1) * pageSize
-		return db.Offset(offset).Limit(pageSize)
+// filterBy applies the filters of the query params on the allowed fields
+func filterBy(fields filter.Fields, qp *dto.BasicQueryParam) func(tx *gorm.DB) *gorm.DB {
+	if qp == nil {
+		return func(tx *gorm.DB) *gorm.DB { return tx }
 	}
+	return fields.Scope(qp.Filter.Conds)
 }
 
-func (b *basicInventoryRepo) CreateMerchant(ctx context.Context, name string, adminID uint) (uuid.UUID, error) {
+func (b *basicInventoryRepo) CreateMerchant(ctx context.Context, name string, adminID uint, ttl time.Duration) (uuid.UUID, error) {
 	mid := uuid.New()
-	mo := model.Merchant{ID: mid, AdminID: adminID, Name: name}
+	mo := model.Merchant{ID: mid, AdminID: adminID, Name: name, ReservationTTL: ttl}
 	err := b.db.Create(&mo).Error
 	return mo.ID, err
 }
 
-func (b *basicInventoryRepo) ListMerchant(ctx context.Context) (merchants []model.Merchant, err error) {
-	err = b.db.Debug().Find(&merchants).Error
+func (b *basicInventoryRepo) ListMerchant(ctx context.Context, qp *dto.BasicQueryParam) (merchants []model.Merchant, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Merchant{}).Scopes(filterBy(merchantFilters, qp))
+	if qp == nil {
+		err = tx.Find(&merchants).Error
+		return
+	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &merchants)
 	return
 }
 
-func (b *basicInventoryRepo) ListMerchantByIDs(ctx context.Context, mids []uuid.UUID) (merchants []model.Merchant, err error) {
-	err = b.db.Debug().Find(&merchants, mids).Error
+func (b *basicInventoryRepo) ListMerchantGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) (merchants []model.Merchant, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Merchant{}).Scopes(grantedTo(b.db, sub, "merchants", "id"), filterBy(merchantFilters, qp))
+	if qp == nil {
+		err = tx.Find(&merchants).Error
+		return
+	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &merchants)
 	return
 }
 
-func (b *basicInventoryRepo) CreateProduct(ctx context.Context, name, desc string, mid uuid.UUID, qty int, price float32) (uuid.UUID, error) {
+func (b *basicInventoryRepo) CreateProduct(ctx context.Context, name, desc string, mid uuid.UUID, qty int, price float32, ttl time.Duration, cause model.MovementCause) (uuid.UUID, error) {
 	pid := uuid.New()
-	po := model.Product{ID: pid, Name: name, MerchantID: mid, Qty: qty, Price: price, Desc: desc}
-	err := b.db.Create(&po).Error
+	po := model.Product{ID: pid, Name: name, MerchantID: mid, Qty: qty, Price: price, Desc: desc, ReservationTTL: ttl, Version: 1}
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&po).Error; err != nil {
+			return err
+		}
+		return recordMovement(tx, model.StockMovement{
+			PID: pid, Kind: model.StockMovementInitial, Qty: qty, Delta: qty, MovementCause: cause,
+		})
+	})
 	return po.ID, err
 }
 
-func (b *basicInventoryRepo) ListProduct(ctx context.Context, qp *dto.BasicQueryParam) (products []model.Product, err error) {
-	if qp != nil {
-		err = b.db.Debug().Scopes(
-			orderBy(qp.Filter.OrederBy),
-			Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
-		).Find(&products).Error
-	} else {
-		err = b.db.Find(&products).Error
+func (b *basicInventoryRepo) UpdateProduct(ctx context.Context, pid uuid.UUID, columns map[string]interface{}, cause model.MovementCause) (po model.Product, err error) {
+	err = b.db.Transaction(func(tx *gorm.DB) error {
+		// bumping the version locks the product, so that its qty doesn't change
+		// between reading it and recording the adjustment
+		result := tx.Model(&model.Product{}).Where("id = ?", pid).
+			UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &ce.ResourceNotFoundErr{Type: "products", ID: pid.String()}
+		}
+
+		var before model.Product
+		if err := tx.Where("id = ?", pid).Take(&before).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Product{}).Where("id = ?", pid).Updates(columns).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", pid).Take(&po).Error; err != nil {
+			return err
+		}
+
+		if delta := po.Qty - before.Qty; delta != 0 {
+			qty := delta
+			if qty < 0 {
+				qty = -qty
+			}
+			return recordMovement(tx, model.StockMovement{
+				PID: pid, Kind: model.StockMovementAdjustment, Qty: qty, Delta: delta, MovementCause: cause,
+			})
+		}
+		return nil
+	})
+	return
+}
+
+func (b *basicInventoryRepo) DeleteProduct(ctx context.Context, pid uuid.UUID, cause model.MovementCause) (po model.Product, err error) {
+	err = b.db.Transaction(func(tx *gorm.DB) error {
+		// bumping the version locks the product, so that it can't get reserved
+		// between counting its reservations and deleting it
+		result := tx.Model(&model.Product{}).Where("id = ?", pid).
+			UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &ce.ResourceNotFoundErr{Type: "products", ID: pid.String()}
+		}
+
+		var reserved int64
+		if err := tx.Model(&model.ReservedProduct{}).Where("p_id = ?", pid).Count(&reserved).Error; err != nil {
+			return err
+		}
+		if reserved > 0 {
+			return ce.ErrProductReserved
+		}
+
+		if err := tx.Where("id = ?", pid).Take(&po).Error; err != nil {
+			return err
+		}
+
+		// close the ledger of the product, so that its deltas sum up to 0
+		err := tx.Create(&model.StockMovement{
+			PID: pid, Kind: model.StockMovementAdjustment, Qty: po.Qty, Delta: -po.Qty, Balance: 0, MovementCause: cause,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", pid).Delete(&model.Product{}).Error
+	})
+	return
+}
+
+func (b *basicInventoryRepo) ListProduct(ctx context.Context, qp *dto.BasicQueryParam) (products []model.Product, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Product{}).Scopes(filterBy(productFilters, qp))
+	if qp == nil {
+		err = tx.Find(&products).Error
+		return
 	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &products)
 	return
 }
 
-func (b *basicInventoryRepo) ListProductByIDs(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) (products []model.Product, err error) {
-	// err = b.db.Debug().Find(&products).Error
-	values := []string{}
-	for _, pid := range pids {
-		values = append(values, fmt.Sprintf("('%s')", pid.String()))
+func (b *basicInventoryRepo) ListProductByIDs(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) (products []model.Product, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Product{}).Where("id IN ?", pids).Scopes(filterBy(productFilters, qp))
+	if qp == nil {
+		err = tx.Find(&products).Error
+		return
 	}
-	q := fmt.Sprintf("select * from products p where p.id = any ( values %s )", strings.Join(values, ","))
-	err = b.db.Debug().Raw(q, values).Scan(&products).Error
+	page, err = paging.Find(tx, qp.PageQuery("id"), &products)
 	return
 }
 
-func (b *basicInventoryRepo) ReserveProduct(ctx context.Context, oid, pid uuid.UUID, qty int) (float32, error) {
-	po := model.Product{ID: pid}
+func (b *basicInventoryRepo) ListProductGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) (products []model.Product, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Product{}).Scopes(grantedTo(b.db, sub, "products", "id"), filterBy(productFilters, qp))
+	if qp == nil {
+		err = tx.Find(&products).Error
+		return
+	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &products)
+	return
+}
+
+func (b *basicInventoryRepo) ReserveProduct(ctx context.Context, oid uuid.UUID, lines []model.ReservedProduct, tolerance float64, ttl, minTTL time.Duration, cause model.MovementCause) (float32, error) {
+	var payble float32
+	mismatchErr := &ErrPriceMismatch{}
+
+	// lock the products in the same order to avoid deadlocks with concurrent reservations
+	lines = append([]model.ReservedProduct{}, lines...)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].PID.String() < lines[j].PID.String() })
 
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Debug().Find(&po).Error; err != nil {
+		// the order is reserved already, e.g. EventOrderCreated got redelivered
+		var reserved []model.ReservedProduct
+		if err := tx.Where("o_id = ?", oid).Find(&reserved).Error; err != nil {
 			return err
 		}
-
-		result := tx.Debug().Model(&po).Where("qty > 0").Update("qty", gorm.Expr("qty - ?", qty))
-		if result.RowsAffected == 0 {
-			return fmt.Errorf("failed updating prodID: %v [err: %v]", pid, result.Error)
+		if len(reserved) > 0 {
+			lines = reserved
 		}
 
-		rpo := model.ReservedProduct{OID: oid, PID: pid, Qty: qty}
-		if err := tx.Debug().Create(&rpo).Error; err != nil {
-			return err
+		for _, rpo := range lines {
+			po := model.Product{}
+			result := tx.Where("id = ?", rpo.PID).Limit(1).Find(&po)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: product %v not found", ErrInsufficientStock, rpo.PID)
+			}
+			if rpo.UnitPrice == 0 {
+				rpo.UnitPrice = po.Price
+			}
+			payble += float32(rpo.Qty) * rpo.UnitPrice
+
+			if len(reserved) > 0 {
+				continue
+			}
+			if math.Abs(float64(po.Price-rpo.UnitPrice)) > tolerance*float64(rpo.UnitPrice) {
+				mismatchErr.Lines = append(mismatchErr.Lines, PriceMismatch{
+					PID: rpo.PID, QuotedPrice: rpo.UnitPrice, Price: po.Price,
+				})
+				continue
+			}
+			result = tx.Model(&model.Product{}).
+				Where("id = ? AND qty >= ?", rpo.PID, rpo.Qty).
+				UpdateColumns(map[string]interface{}{
+					"qty":     gorm.Expr("qty - ?", rpo.Qty),
+					"version": gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: product %v", ErrInsufficientStock, rpo.PID)
+			}
+			err := recordMovement(tx, model.StockMovement{
+				PID: rpo.PID, Kind: model.StockMovementReserve, Qty: rpo.Qty, Delta: -rpo.Qty, OID: &oid, MovementCause: cause,
+			})
+			if err != nil {
+				return err
+			}
+
+			lineTTL, err := reservationTTL(tx, po, ttl)
+			if err != nil {
+				return err
+			}
+			// the TTLs set before the minimum was raised
+			if lineTTL < minTTL {
+				lineTTL = minTTL
+			}
+			rpo.OID = oid
+			rpo.ReservedAt = time.Now()
+			rpo.ExpiresAt = rpo.ReservedAt.Add(lineTTL)
+			if err := tx.Create(&rpo).Error; err != nil {
+				return err
+			}
 		}
 
+		// the reserved lines get rolled back along with the mismatching ones
+		if len(mismatchErr.Lines) > 0 {
+			return mismatchErr
+		}
 		// returning nil will commit the whole transaction
 		return nil
 	})
@@ -143,37 +396,103 @@ func (b *basicInventoryRepo) ReserveProduct(ctx context.Context, oid, pid uuid.U
 	if err != nil {
 		return 0, err
 	}
-	return float32(qty) * po.Price, nil
+	return payble, nil
 }
 
-func (b *basicInventoryRepo) RemoveReservedProduct(ctx context.Context, oid uuid.UUID) error {
-	rpo := model.ReservedProduct{OID: oid}
-	return b.db.Debug().Delete(&rpo, "o_id = ?", oid).Error
+// reservationTTL returns the TTL of the product, else of its merchant, else the default
+func reservationTTL(tx *gorm.DB, po model.Product, ttl time.Duration) (time.Duration, error) {
+	if po.ReservationTTL > 0 {
+		return po.ReservationTTL, nil
+	}
+	mo := model.Merchant{}
+	if err := tx.Where("id = ?", po.MerchantID).Limit(1).Find(&mo).Error; err != nil {
+		return 0, err
+	}
+	if mo.ReservationTTL > 0 {
+		return mo.ReservationTTL, nil
+	}
+	return ttl, nil
 }
 
-func (b *basicInventoryRepo) UndoReserveProduct(ctx context.Context, oid uuid.UUID) error {
-	err := b.db.Transaction(func(tx *gorm.DB) error {
-		rpo := model.ReservedProduct{OID: oid}
-		result := tx.Debug().Find(&rpo)
-		if result.RowsAffected == 0 {
-			return &ce.ResourceNotFoundErr{Type: "reserved_products", ID: oid.String()}
+func (b *basicInventoryRepo) RemoveReservedProduct(ctx context.Context, oid uuid.UUID, cause model.MovementCause) error {
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		var reserved []model.ReservedProduct
+		if err := tx.Where("o_id = ?", oid).Find(&reserved).Error; err != nil {
+			return err
 		}
+
+		// as on releasing, a line is deleted first, so that it is either sold or released
+		for _, rpo := range reserved {
+			result := tx.Where("o_id = ? AND p_id = ?", oid, rpo.PID).Delete(&model.ReservedProduct{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			err := recordMovement(tx, model.StockMovement{
+				PID: rpo.PID, Kind: model.StockMovementSale, Qty: rpo.Qty, OID: &oid, MovementCause: cause,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *basicInventoryRepo) UndoReserveProduct(ctx context.Context, oid uuid.UUID, cause model.MovementCause) (released []model.ReservedProduct, err error) {
+	err = b.db.Transaction(func(tx *gorm.DB) error {
+		var reserved []model.ReservedProduct
+		result := tx.Where("o_id = ?", oid).Find(&reserved)
 		if result.Error != nil {
 			return result.Error
 		}
-		err := tx.Debug().Delete(&rpo).Error
-		if err != nil {
-			return err
+		if result.RowsAffected == 0 {
+			return &ce.ResourceNotFoundErr{Type: "reserved_products", ID: oid.String()}
 		}
 
-		po := model.Product{ID: rpo.PID}
-		err = tx.Debug().Model(&po).UpdateColumn("qty", gorm.Expr("qty + ?", rpo.Qty)).Error
-		if err != nil {
-			return err
-		}
+		// put the reserved quantity of every line back to its product. The line is deleted
+		// first, so that of the concurrent releases (e.g. the releaser and a cancellation)
+		// only the one which deleted it puts its quantity back.
+		for _, rpo := range reserved {
+			result := tx.Where("o_id = ? AND p_id = ?", oid, rpo.PID).Delete(&model.ReservedProduct{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			released = append(released, rpo)
 
+			err := tx.Model(&model.Product{}).Where("id = ?", rpo.PID).
+				UpdateColumns(map[string]interface{}{
+					"qty":     gorm.Expr("qty + ?", rpo.Qty),
+					"version": gorm.Expr("version + 1"),
+				}).Error
+			if err != nil {
+				return err
+			}
+			err = recordMovement(tx, model.StockMovement{
+				PID: rpo.PID, Kind: model.StockMovementRelease, Qty: rpo.Qty, Delta: rpo.Qty, OID: &oid, MovementCause: cause,
+			})
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+func (b *basicInventoryRepo) ListExpiredReservations(ctx context.Context, before time.Time, limit int) (oids []uuid.UUID, err error) {
+	err = b.db.Model(&model.ReservedProduct{}).
+		Where("expires_at <= ?", before).
+		Distinct("o_id").Limit(limit).
+		Pluck("o_id", &oids).Error
+	return
 }
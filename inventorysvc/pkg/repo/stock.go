@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/paging"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+)
+
+// stockMovementFilters are the fields which the stock movements can be filtered by
+var stockMovementFilters = filter.Fields{
+	"kind":       {Column: "kind", Kind: filter.String},
+	"order_id":   {Column: "o_id", Kind: filter.UUID},
+	"actor":      {Column: "actor", Kind: filter.String},
+	"created_at": {Column: "created_at", Kind: filter.Time},
+}
+
+// recordMovement appends the movement to the ledger along with the qty of its product
+// after it. It is to be called in the transaction which moved the stock, once the
+// qty of the product is updated.
+func recordMovement(tx *gorm.DB, m model.StockMovement) error {
+	var po model.Product
+	if err := tx.Select("qty").Where("id = ?", m.PID).Limit(1).Find(&po).Error; err != nil {
+		return err
+	}
+	m.Balance = po.Qty
+	return tx.Create(&m).Error
+}
+
+func (b *basicInventoryRepo) RestockProduct(ctx context.Context, pid uuid.UUID, qty int, cause model.MovementCause) (po model.Product, err error) {
+	err = b.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Product{}).Where("id = ?", pid).
+			UpdateColumns(map[string]interface{}{
+				"qty":     gorm.Expr("qty + ?", qty),
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &ce.ResourceNotFoundErr{Type: "products", ID: pid.String()}
+		}
+
+		err := recordMovement(tx, model.StockMovement{
+			PID: pid, Kind: model.StockMovementRestock, Qty: qty, Delta: qty, MovementCause: cause,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", pid).Take(&po).Error
+	})
+	return
+}
+
+func (b *basicInventoryRepo) ListStockMovement(ctx context.Context, pid uuid.UUID, qp *dto.BasicQueryParam) (movements []model.StockMovement, page *dto.Page, err error) {
+	tx := b.db.Model(&model.StockMovement{}).Where("p_id = ?", pid).Scopes(filterBy(stockMovementFilters, qp))
+	if qp == nil {
+		err = tx.Order("id").Find(&movements).Error
+		return
+	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &movements)
+	return
+}
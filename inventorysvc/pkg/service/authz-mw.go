@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
 	svcpe "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/policy-enforcer"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/google/uuid"
@@ -30,8 +32,12 @@ func (m *authzMW) HandlePolicyUpdatedEvent(ctx context.Context, t, sub, rtype, r
 	return m.next.HandlePolicyUpdatedEvent(ctx, t, sub, rtype, rid, act)
 }
 
-func (m *authzMW) HandleOrderCreatedEvent(ctx context.Context, oid, pid uuid.UUID, status string, qty int, aid uint) error {
-	return m.next.HandleOrderCreatedEvent(ctx, oid, pid, status, qty, aid)
+func (m *authzMW) BackfillACL(ctx context.Context) (int, error) {
+	return m.next.BackfillACL(ctx)
+}
+
+func (m *authzMW) HandleOrderCreatedEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventOrderLine, status string, aid uint) error {
+	return m.next.HandleOrderCreatedEvent(ctx, oid, lines, status, aid)
 }
 
 func (m *authzMW) HandleOrderApprovedEvent(ctx context.Context, rpid uuid.UUID) error {
@@ -42,72 +48,105 @@ func (m *authzMW) HandleOrderCanceledEvent(ctx context.Context, rpid uuid.UUID)
 	return m.next.HandleOrderCanceledEvent(ctx, rpid)
 }
 
-func (m *authzMW) CreateMerchant(ctx context.Context, aid uint, name string) dto.CreateMerchantResponse {
+func (m *authzMW) CreateMerchant(ctx context.Context, aid uint, name string, ttl time.Duration) dto.CreateMerchantResponse {
 	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", aid, "merchants", "post", "*")
 	if !m.pe.Enforce(ctx, reqPolicy, nil) {
 		fmt.Println(ce.ErrInsufficientPerm)
 		return dto.CreateMerchantResponse{Err: ce.ErrInsufficientPerm}
 	}
-	return m.next.CreateMerchant(ctx, aid, name)
+	return m.next.CreateMerchant(ctx, aid, name, ttl)
 }
 
-func (m *authzMW) ListMerchant(ctx context.Context, mids []uuid.UUID) dto.ListMerchantResponse {
+func (m *authzMW) ListMerchant(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListMerchantResponse {
 	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	if !ok {
 		return dto.ListMerchantResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
-	rids := m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "merchants", "*")
-	rids = append(rids, m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "merchants", "get")...)
-	if len(rids) <= 0 {
+	// the callers list for themselves only
+	if sub != fmt.Sprint(claim.AccntID) {
 		return dto.ListMerchantResponse{Err: ce.ErrInsufficientPerm}
 	}
-	for _, rid := range rids {
-		if rid == "*" {
-			return m.next.ListMerchant(ctx, []uuid.UUID{})
-		}
-		id, err := uuid.Parse(rid)
-		if err != nil {
-			continue
-		}
-		mids = append(mids, id)
+	// who may get any merchant lists all of them, the others only the ones granted to them
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", sub, "merchants", "get", "*")
+	if m.pe.Enforce(ctx, reqPolicy, nil) {
+		return m.next.ListMerchant(ctx, "", qp)
 	}
-
-	return m.next.ListMerchant(ctx, mids)
+	return m.next.ListMerchant(ctx, sub, qp)
 }
 
-func (m *authzMW) CreateProduct(ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32) dto.CreateProductResponse {
+func (m *authzMW) CreateProduct(ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32, ttl time.Duration) dto.CreateProductResponse {
 	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", aid, "products", "post", "*")
 	if !m.pe.Enforce(ctx, reqPolicy, nil) {
 		return dto.CreateProductResponse{Err: ce.ErrInsufficientPerm}
 	}
-	return m.next.CreateProduct(ctx, aid, mid, name, desc, qty, price)
+	return m.next.CreateProduct(ctx, aid, mid, name, desc, qty, price, ttl)
 }
 
-func (m *authzMW) ListProduct(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListProductResponse {
+func (m *authzMW) ListProduct(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListProductResponse {
 	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	if !ok {
 		return dto.ListProductResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
+	// the callers list for themselves only
+	if sub != fmt.Sprint(claim.AccntID) {
+		return dto.ListProductResponse{Err: ce.ErrInsufficientPerm}
+	}
 	// customers are allowed to view all the products
 	if claim.Role == "customer" {
-		return m.next.ListProduct(ctx, []uuid.UUID{}, qp)
+		return m.next.ListProduct(ctx, "", qp)
 	}
 	// sellers are allowed to view which they have created
-	rids := m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "products", "*")
-	rids = append(rids, m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "products", "get")...)
-	if len(rids) <= 0 {
-		return dto.ListProductResponse{Err: ce.ErrInsufficientPerm}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", sub, "products", "get", "*")
+	if m.pe.Enforce(ctx, reqPolicy, nil) {
+		return m.next.ListProduct(ctx, "", qp)
 	}
-	for _, rid := range rids {
-		if rid == "*" {
-			return m.next.ListProduct(ctx, []uuid.UUID{}, qp)
-		}
-		id, err := uuid.Parse(rid)
-		if err != nil {
-			continue
-		}
-		pids = append(pids, id)
+	return m.next.ListProduct(ctx, sub, qp)
+}
+
+func (m *authzMW) UpdateProduct(ctx context.Context, req dto.UpdateProductRequest) dto.UpdateProductResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.UpdateProductResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
+	act := "patch"
+	if req.Replace {
+		act = "put"
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "products", act, req.PID)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.UpdateProductResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.UpdateProduct(ctx, req)
+}
+
+func (m *authzMW) DeleteProduct(ctx context.Context, aid uint, pid uuid.UUID) dto.DeleteProductResponse {
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", aid, "products", "delete", pid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.DeleteProductResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.DeleteProduct(ctx, aid, pid)
+}
+
+func (m *authzMW) RestockProduct(ctx context.Context, aid uint, pid uuid.UUID, qty int, reason string) dto.RestockProductResponse {
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", aid, "products", "restock", pid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.RestockProductResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.RestockProduct(ctx, aid, pid, qty, reason)
+}
+
+func (m *authzMW) ListStockMovement(ctx context.Context, pid uuid.UUID, qp *dto.BasicQueryParam) dto.ListStockMovementResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.ListStockMovementResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "products", "get", pid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.ListStockMovementResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.ListStockMovement(ctx, pid, qp)
+}
 
-	return m.next.ListProduct(ctx, pids, qp)
+func (m *authzMW) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	return m.next.ReleaseExpiredReservations(ctx)
 }
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
 	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/repo"
 	"github.com/google/uuid"
 )
 
@@ -48,16 +52,73 @@ func (svc *basicInventoryService) HandleAccountCreatedEvent(ctx context.Context,
 }
 
 func (svc *basicInventoryService) HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error {
+	if err := svc.projectPolicy(ctx, method, sub, rtype, rid, act); err != nil {
+		return err
+	}
 	return svc.ps.UpdatePolicy(method, sub, rtype, rid, act)
 }
 
-func (svc *basicInventoryService) HandleOrderCreatedEvent(ctx context.Context, oid, pid uuid.UUID, status string, qty int, aid uint) error {
-	price, err := svc.repo.ReserveProduct(ctx, oid, pid, qty)
+// projectPolicy keeps the ACL projection in step with the policies on a single merchant or product.
+// The wildcard policies are left to the policy enforcer.
+func (svc *basicInventoryService) projectPolicy(ctx context.Context, method, sub, rtype, rid, act string) error {
+	id, err := uuid.Parse(rid)
+	if (rtype != "merchants" && rtype != "products") || err != nil {
+		return nil
+	}
+	e := model.ACLEntry{Subject: sub, ResourceType: rtype, ResourceID: id, Action: act}
+	if method == "delete" {
+		return svc.repo.RevokeACL(ctx, e)
+	}
+	return svc.repo.GrantACL(ctx, e)
+}
+
+// BackfillACL grants the policies which authzsvc holds on the resources of the service,
+// as the policy updates made before the projection was deployed are not in the streams
+// anymore. It is to be called before consuming the policy updates, so that the ones
+// made meanwhile apply on top of it. A policy already projected is granted again as a no-op.
+func (svc *basicInventoryService) BackfillACL(ctx context.Context) (int, error) {
+	policies, err := svc.ps.ListPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range policies {
+		err := svc.projectPolicy(ctx, "put", p.Sub(), p.ResourceType(), p.ResourceID(), p.Action())
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(policies), nil
+}
+
+func (svc *basicInventoryService) HandleOrderCreatedEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventOrderLine, status string, aid uint) error {
+	reserve := []model.ReservedProduct{}
+	for _, l := range lines {
+		reserve = append(reserve, model.ReservedProduct{OID: oid, PID: l.ProductID, Qty: l.Qty, UnitPrice: l.UnitPrice})
+	}
+	price, err := svc.repo.ReserveProduct(ctx, oid, reserve, svc.priceTolerance, svc.reservationTTL, svc.minReservationTTL,
+		movementCause(ctx, fmt.Sprint(aid), ""))
 
 	eventPublisher := svcevent.NewEventPublisher()
 	var eventErr error
+	var mismatchErr *repo.ErrPriceMismatch
 
-	if err != nil {
+	if errors.As(err, &mismatchErr) {
+		// the customer is not charged a price other than the quoted one,
+		// fire EventPriceMismatch to reject the order instead
+		mismatched := []svcevent.EventPriceMismatchLine{}
+		for _, l := range mismatchErr.Lines {
+			mismatched = append(mismatched, svcevent.EventPriceMismatchLine{
+				ProductID: l.PID, QuotedPrice: l.QuotedPrice, Price: l.Price,
+			})
+		}
+		eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
+			ctx, svcevent.EventPriceMismatch,
+			svcevent.EventPriceMismatchPayload{
+				OrderID: oid,
+				Lines:   mismatched,
+			},
+		))
+	} else if err != nil {
 		// if there was error in reserving specified product quantity,
 		// fire EventErrReservingProduct event
 		eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
@@ -86,16 +147,60 @@ func (svc *basicInventoryService) HandleOrderCreatedEvent(ctx context.Context, o
 			"published events: %v", eventPublisher.GetEventNames()))
 	}
 
+	if err == nil {
+		pids := []uuid.UUID{}
+		for _, l := range lines {
+			pids = append(pids, l.ProductID)
+		}
+		svc.publishProductUpdates(ctx, pids)
+	}
+
+	// retrying would not bring the stock back nor the price, the order fails instead
+	if errors.Is(err, repo.ErrInsufficientStock) || mismatchErr != nil {
+		svc.cl.Info(ctx, fmt.Sprintf("order-%s: %v", oid, err))
+		err = nil
+	}
+
 	return err
 }
 
 func (svc *basicInventoryService) HandleOrderApprovedEvent(ctx context.Context, oid uuid.UUID) error {
-	// if order is approved then just remove the reserved product
-	return svc.repo.RemoveReservedProduct(ctx, oid)
+	// if order is approved then just remove the reserved product, it is sold
+	return svc.repo.RemoveReservedProduct(ctx, oid, movementCause(ctx, model.SystemActor, "order approved"))
 }
 
 func (svc *basicInventoryService) HandleOrderCanceledEvent(ctx context.Context, oid uuid.UUID) error {
 	// if for any reason order is canceled/failed undo the reserve product
 	// operation by adding the reserved product qty back to the products
-	return svc.repo.UndoReserveProduct(ctx, oid)
+	released, err := svc.repo.UndoReserveProduct(ctx, oid, movementCause(ctx, model.SystemActor, "order canceled"))
+
+	// nothing was reserved for the order or it got released already
+	var notFoundErr *ce.ResourceNotFoundErr
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return err
+	}
+	if len(released) > 0 {
+		pids := []uuid.UUID{}
+		for _, rpo := range released {
+			pids = append(pids, rpo.PID)
+		}
+		svc.publishProductUpdates(ctx, pids)
+	}
+
+	// let the order saga know that nothing is held for the order anymore
+	e, eventErr := svcevent.NewEvent(
+		ctx, svcevent.EventReservationReleased,
+		svcevent.EventReservationReleasedPayload{OrderID: oid})
+	if eventErr != nil {
+		return eventErr
+	}
+	// on failure the event is redelivered and, the reservation being released
+	// already, only the release is published again
+	eventErr = e.Publish(svc.nc)
+	if eventErr != nil {
+		return eventErr
+	}
+	svc.cl.Debug(ctx, fmt.Sprintf("published events: %s", e.Name()))
+
+	return nil
 }
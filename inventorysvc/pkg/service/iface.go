@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
 	svcpe "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/repo"
@@ -20,15 +23,32 @@ type IInventoryService interface {
 	// Handlers of the events
 	HandleAccountCreatedEvent(ctx context.Context, aid uint, role string) error
 	HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error
-	HandleOrderCreatedEvent(ctx context.Context, oid, pid uuid.UUID, status string, qty int, aid uint) error
+	// BackfillACL projects the policies granted before the ACL projection was deployed,
+	// see README.md. It returns the number of policies it went through.
+	BackfillACL(ctx context.Context) (int, error)
+	HandleOrderCreatedEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventOrderLine, status string, aid uint) error
 	HandleOrderApprovedEvent(ctx context.Context, oid uuid.UUID) error
 	HandleOrderCanceledEvent(ctx context.Context, oid uuid.UUID) error
 
-	CreateMerchant(ctx context.Context, aid uint, name string) dto.CreateMerchantResponse
-	ListMerchant(ctx context.Context, mids []uuid.UUID) dto.ListMerchantResponse
-
-	CreateProduct(ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32) dto.CreateProductResponse
-	ListProduct(ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListProductResponse
+	// CreateMerchant creates a merchant whose products stay reserved for ttl, 0 for the default
+	CreateMerchant(ctx context.Context, aid uint, name string, ttl time.Duration) dto.CreateMerchantResponse
+	// ListMerchant lists the merchants granted to the subject, all of them if sub is empty
+	ListMerchant(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListMerchantResponse
+
+	// CreateProduct creates a product which stays reserved for ttl, 0 for the one of the merchant
+	CreateProduct(ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32, ttl time.Duration) dto.CreateProductResponse
+	// ListProduct lists the products granted to the subject, all of them if sub is empty
+	ListProduct(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListProductResponse
+	UpdateProduct(ctx context.Context, req dto.UpdateProductRequest) dto.UpdateProductResponse
+	// DeleteProduct deletes the product unless some of its stock is reserved,
+	// closes its ledger and removes the policies granted on it
+	DeleteProduct(ctx context.Context, aid uint, pid uuid.UUID) dto.DeleteProductResponse
+	// RestockProduct adds qty to the stock of the product and records it in the ledger
+	RestockProduct(ctx context.Context, aid uint, pid uuid.UUID, qty int, reason string) dto.RestockProductResponse
+	// ListStockMovement lists the movements of the stock of the product from the ledger
+	ListStockMovement(ctx context.Context, pid uuid.UUID, qp *dto.BasicQueryParam) dto.ListStockMovementResponse
+
+	ReleaseExpiredReservations(ctx context.Context) (int, error)
 }
 
 type basicInventoryService struct {
@@ -36,11 +56,21 @@ type basicInventoryService struct {
 	repo repo.InventoryRepository
 	nc   *nats.EncodedConn
 	ps   svcpe.PolicyStorage
+
+	// schedules the events which are to be fired later
+	scheduler *svcevent.Scheduler
+
+	// the fraction by which a price may move away from the quoted one
+	priceTolerance float64
+	// for how long the products stay reserved by default
+	reservationTTL time.Duration
+	// the shortest reservation TTL, see WithMinReservationTTL
+	minReservationTTL time.Duration
 }
 
 // NewBasicInventoryService returns a naive, stateless implementation of IInventoryService
 func NewBasicInventoryService() *basicInventoryService {
-	return &basicInventoryService{}
+	return &basicInventoryService{reservationTTL: 15 * time.Minute}
 }
 
 type SvcConf func(*basicInventoryService) error
@@ -72,6 +102,66 @@ func WithPolicyStorage(ps svcpe.PolicyStorage) SvcConf {
 	}
 }
 
+func WithScheduler(s *svcevent.Scheduler) SvcConf {
+	return func(svc *basicInventoryService) error {
+		if s == nil {
+			return errors.New("event scheduler not provided")
+		}
+		svc.scheduler = s
+		return nil
+	}
+}
+
+// WithPriceTolerance sets the fraction by which the price of a product may move away
+// from the price quoted on an order before the order is rejected. It is 0 by default.
+func WithPriceTolerance(tolerance float64) SvcConf {
+	return func(svc *basicInventoryService) error {
+		if tolerance < 0 {
+			return errors.New("price tolerance can't be negative")
+		}
+		svc.priceTolerance = tolerance
+		return nil
+	}
+}
+
+// WithReservationTTL sets for how long the products stay reserved for an order which is
+// neither approved nor canceled, unless its merchant or product overrides it
+func WithReservationTTL(ttl time.Duration) SvcConf {
+	return func(svc *basicInventoryService) error {
+		if ttl <= 0 {
+			return errors.New("reservation ttl must be positive")
+		}
+		svc.reservationTTL = ttl
+		return nil
+	}
+}
+
+// WithMinReservationTTL sets the shortest TTL of the reservations. A reservation which
+// expires while the order saga is still waiting for the payment gives the stock back
+// to an order which may yet be paid, so the minimum must be at least the reserve and
+// payment timeouts of the saga. The TTLs of the merchants and products below it are
+// refused, the older ones are raised to it.
+func WithMinReservationTTL(ttl time.Duration) SvcConf {
+	return func(svc *basicInventoryService) error {
+		if ttl < 0 {
+			return errors.New("min reservation ttl can't be negative")
+		}
+		if ttl > svc.reservationTTL {
+			return errors.New("min reservation ttl can't be longer than the reservation ttl")
+		}
+		svc.minReservationTTL = ttl
+		return nil
+	}
+}
+
+// checkReservationTTL validates the TTL of a merchant or product, 0 being the default one
+func (svc *basicInventoryService) checkReservationTTL(ttl time.Duration) error {
+	if ttl > 0 && ttl < svc.minReservationTTL {
+		return ce.ErrReservationTTLTooShort
+	}
+	return nil
+}
+
 // New returns a InventoryService implementation with
 // all of the expected config/middleware wired in.
 func New(logger *cl.CustomLogger, mws []Middleware, svcconfs ...SvcConf) IInventoryService {
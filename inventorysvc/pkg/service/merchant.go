@@ -3,15 +3,18 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
-	"github.com/google/uuid"
 )
 
-func (svc *basicInventoryService) CreateMerchant(ctx context.Context, aid uint, name string) dto.CreateMerchantResponse {
-	mid, err := svc.repo.CreateMerchant(ctx, name, aid)
+func (svc *basicInventoryService) CreateMerchant(ctx context.Context, aid uint, name string, ttl time.Duration) dto.CreateMerchantResponse {
+	if err := svc.checkReservationTTL(ttl); err != nil {
+		return dto.CreateMerchantResponse{Err: err}
+	}
+	mid, err := svc.repo.CreateMerchant(ctx, name, aid, ttl)
 	if err != nil {
 		return dto.CreateMerchantResponse{Err: err}
 	}
@@ -50,14 +53,15 @@ func (svc *basicInventoryService) CreateMerchant(ctx context.Context, aid uint,
 	return dto.CreateMerchantResponse{ID: mid, Err: err}
 }
 
-func (svc *basicInventoryService) ListMerchant(ctx context.Context, mids []uuid.UUID) dto.ListMerchantResponse {
+func (svc *basicInventoryService) ListMerchant(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListMerchantResponse {
 	var merchantObjs []model.Merchant
+	var page *dto.Page
 	var err error
 
-	if len(mids) > 0 {
-		merchantObjs, err = svc.repo.ListMerchantByIDs(ctx, mids)
+	if sub != "" {
+		merchantObjs, page, err = svc.repo.ListMerchantGrantedTo(ctx, sub, qp)
 	} else {
-		merchantObjs, err = svc.repo.ListMerchant(ctx)
+		merchantObjs, page, err = svc.repo.ListMerchant(ctx, qp)
 	}
 
 	if err != nil {
@@ -72,5 +76,5 @@ func (svc *basicInventoryService) ListMerchant(ctx context.Context, mids []uuid.
 		})
 	}
 
-	return dto.ListMerchantResponse{Merchants: merchants}
+	return dto.ListMerchantResponse{Merchants: merchants, Page: page}
 }
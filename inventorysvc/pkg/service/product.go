@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
@@ -11,9 +12,12 @@ import (
 )
 
 func (svc *basicInventoryService) CreateProduct(
-	ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32) dto.CreateProductResponse {
+	ctx context.Context, aid uint, mid uuid.UUID, name, desc string, qty int, price float32, ttl time.Duration) dto.CreateProductResponse {
 
-	pid, err := svc.repo.CreateProduct(ctx, name, desc, mid, qty, price)
+	if err := svc.checkReservationTTL(ttl); err != nil {
+		return dto.CreateProductResponse{Err: err}
+	}
+	pid, err := svc.repo.CreateProduct(ctx, name, desc, mid, qty, price, ttl, movementCause(ctx, fmt.Sprint(aid), ""))
 	if err != nil {
 		return dto.CreateProductResponse{Err: err}
 	}
@@ -30,6 +34,20 @@ func (svc *basicInventoryService) CreateProduct(
 	))
 	svc.cl.LogIfError(ctx, eventErr)
 
+	// let the other services keep their projection of the products
+	eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
+		ctx, svcevent.EventProductCreated,
+		svcevent.EventProductCreatedPayload{
+			ProductID:  pid,
+			MerchantID: mid,
+			Name:       name,
+			Price:      price,
+			Qty:        qty,
+			Version:    1,
+		},
+	))
+	svc.cl.LogIfError(ctx, eventErr)
+
 	eventErr = eventPublisher.Publish(svc.nc)
 	svc.cl.LogIfError(ctx, eventErr)
 	if eventErr == nil {
@@ -41,15 +59,16 @@ func (svc *basicInventoryService) CreateProduct(
 }
 
 func (svc *basicInventoryService) ListProduct(
-	ctx context.Context, pids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListProductResponse {
+	ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListProductResponse {
 
 	var prodObjs []model.Product
+	var page *dto.Page
 	var err error
 
-	if len(pids) > 0 {
-		prodObjs, err = svc.repo.ListProductByIDs(ctx, pids, qp)
+	if sub != "" {
+		prodObjs, page, err = svc.repo.ListProductGrantedTo(ctx, sub, qp)
 	} else {
-		prodObjs, err = svc.repo.ListProduct(ctx, qp)
+		prodObjs, page, err = svc.repo.ListProduct(ctx, qp)
 	}
 
 	if err != nil {
@@ -73,5 +92,115 @@ func (svc *basicInventoryService) ListProduct(
 		products = append(products, tmpProd)
 	}
 
-	return dto.ListProductResponse{Products: products}
+	return dto.ListProductResponse{Products: products, Page: page}
+}
+
+func (svc *basicInventoryService) UpdateProduct(ctx context.Context, req dto.UpdateProductRequest) dto.UpdateProductResponse {
+	columns := map[string]interface{}{}
+	if req.Name != nil {
+		columns["name"] = *req.Name
+	}
+	if req.Desc != nil {
+		columns["desc"] = *req.Desc
+	}
+	if req.Qty != nil {
+		columns["qty"] = *req.Qty
+	}
+	if req.Price != nil {
+		columns["price"] = *req.Price
+	}
+	if req.ReservationTTL != nil {
+		ttl := time.Duration(*req.ReservationTTL) * time.Second
+		if err := svc.checkReservationTTL(ttl); err != nil {
+			return dto.UpdateProductResponse{Err: err}
+		}
+		columns["reservation_ttl"] = ttl
+	}
+
+	po, err := svc.repo.UpdateProduct(ctx, req.PID, columns, movementCause(ctx, fmt.Sprint(req.AccntID), ""))
+	if err != nil {
+		return dto.UpdateProductResponse{Err: err}
+	}
+	svc.publishProductUpdates(ctx, []uuid.UUID{po.ID})
+
+	return dto.UpdateProductResponse{ID: po.ID, Version: po.Version}
+}
+
+func (svc *basicInventoryService) DeleteProduct(ctx context.Context, aid uint, pid uuid.UUID) dto.DeleteProductResponse {
+	po, err := svc.repo.DeleteProduct(ctx, pid, movementCause(ctx, fmt.Sprint(aid), "product deleted"))
+	if err != nil {
+		return dto.DeleteProductResponse{Err: err}
+	}
+
+	// let the other services drop the product from their projection and ask authzsvc
+	// to remove every policy granted on the product, as found in its own store
+	events := []svcevent.IEvent{}
+	for _, e := range []struct {
+		name    svcevent.EventName
+		payload interface{}
+	}{
+		{svcevent.EventProductDeleted, svcevent.EventProductDeletedPayload{ProductID: po.ID, Version: po.Version}},
+		{svcevent.EventRemoveResourcePolicies, svcevent.EventRemoveResourcePoliciesPayload{ResourceType: "products", ResourceID: po.ID.String()}},
+	} {
+		event, err := svcevent.NewEvent(ctx, e.name, e.payload)
+		if err != nil {
+			return dto.DeleteProductResponse{Err: err}
+		}
+		events = append(events, event)
+	}
+
+	// the product is gone already, so the events which could not be published are
+	// left to the scheduler to retry rather than leaving the policies behind
+	for i, e := range events {
+		eventErr := e.Publish(svc.nc)
+		if eventErr == nil {
+			svc.cl.Debug(ctx, fmt.Sprintf("published events: %s", e.Name()))
+			continue
+		}
+		svc.cl.Error(ctx, fmt.Sprintf("error publishing event: %s [%v]", e.Name(), eventErr))
+		if svc.scheduler == nil {
+			return dto.DeleteProductResponse{Err: eventErr}
+		}
+		for _, e := range events[i:] {
+			if err := svc.scheduler.Schedule(ctx, e, po.ID.String(), time.Now()); err != nil {
+				return dto.DeleteProductResponse{Err: err}
+			}
+		}
+		break
+	}
+
+	return dto.DeleteProductResponse{}
+}
+
+// publishProductUpdates fires EventProductUpdated with the current state of the
+// given products, so that the other services see their stock changes
+func (svc *basicInventoryService) publishProductUpdates(ctx context.Context, pids []uuid.UUID) {
+	prodObjs, _, err := svc.repo.ListProductByIDs(ctx, pids, nil)
+	if err != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("err getting products [%v]", err))
+		return
+	}
+
+	eventPublisher := svcevent.NewEventPublisher()
+	for _, p := range prodObjs {
+		eventErr := eventPublisher.AddEvent(svcevent.NewEvent(
+			ctx, svcevent.EventProductUpdated,
+			svcevent.EventProductUpdatedPayload{
+				ProductID:  p.ID,
+				MerchantID: p.MerchantID,
+				Name:       p.Name,
+				Price:      p.Price,
+				Qty:        p.Qty,
+				Version:    p.Version,
+			},
+		))
+		svc.cl.LogIfError(ctx, eventErr)
+	}
+
+	eventErr := eventPublisher.Publish(svc.nc)
+	svc.cl.LogIfError(ctx, eventErr)
+	if eventErr == nil {
+		svc.cl.Debug(ctx, fmt.Sprintf(
+			"published events: %v", eventPublisher.GetEventNames()))
+	}
 }
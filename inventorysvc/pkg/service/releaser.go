@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	svcconf "github.com/AyushSenapati/reactive-micro/inventorysvc/conf"
+	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	cl "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	"github.com/google/uuid"
+)
+
+const releaseBatchSize = 100
+
+// releasedReservations counts the orders whose reservation expired, along with
+// the errors. It is exposed at /v1/inventorysvc/_metrics.
+var releasedReservations = expvar.NewMap("inventorysvc_released_reservations")
+
+// ReleaseExpiredReservations puts back the stock reserved for the orders which were
+// neither approved nor canceled before their reservation expired, e.g. because
+// ordersvc never got to them, and fires EventReservationExpired for each of them.
+func (svc *basicInventoryService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	oids, err := svc.repo.ListExpiredReservations(ctx, time.Now(), releaseBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, oid := range oids {
+		lines, err := svc.repo.UndoReserveProduct(ctx, oid, movementCause(ctx, model.SystemActor, "reservation expired"))
+		if err != nil {
+			releasedReservations.Add("errors", 1)
+			svc.cl.Error(ctx, fmt.Sprintf("releaser: error releasing order %s [%v]", oid, err))
+			continue
+		}
+		// the order got approved or canceled meanwhile
+		if len(lines) == 0 {
+			continue
+		}
+
+		pids := []uuid.UUID{}
+		for _, rpo := range lines {
+			pids = append(pids, rpo.PID)
+		}
+		svc.publishProductUpdates(ctx, pids)
+
+		// let the order saga fail the order
+		e, eventErr := svcevent.NewEvent(
+			ctx, svcevent.EventReservationExpired,
+			svcevent.EventReservationExpiredPayload{OrderID: oid})
+		if eventErr == nil {
+			eventErr = e.Publish(svc.nc)
+		}
+		svc.cl.LogIfError(ctx, eventErr)
+		if eventErr == nil {
+			svc.cl.Debug(ctx, fmt.Sprintf("published events: %s", e.Name()))
+		}
+
+		releasedReservations.Add("orders", 1)
+		svc.cl.Info(ctx, fmt.Sprintf("order-%s: reservation expired", oid))
+		released++
+	}
+	return released, nil
+}
+
+// Releaser periodically releases the expired reservations, see
+// IInventoryService.ReleaseExpiredReservations. Replicas can release concurrently,
+// as a reserved line is put back to the stock only once.
+type Releaser struct {
+	cl       *cl.CustomLogger
+	svc      IInventoryService
+	interval time.Duration
+
+	cancel chan struct{}
+}
+
+// NewReleaser returns a Releaser which releases the expired reservations every interval
+func NewReleaser(logger *cl.CustomLogger, svc IInventoryService, interval time.Duration) (*Releaser, error) {
+	if svc == nil {
+		return nil, errors.New("releaser: service not provided")
+	}
+	if interval <= 0 {
+		return nil, errors.New("releaser: interval must be positive")
+	}
+	return &Releaser{
+		cl:       logger,
+		svc:      svc,
+		interval: interval,
+		cancel:   make(chan struct{}),
+	}, nil
+}
+
+// Execute releases the expired reservations till the releaser is interrupted
+func (r *Releaser) Execute() error {
+	r.cl.Info(context.TODO(), "releaser: initialised")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.cancel:
+			r.cl.Info(context.TODO(), "releaser: closed")
+			return nil
+		case <-ticker.C:
+			ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, uuid.NewString())
+			n, err := r.svc.ReleaseExpiredReservations(ctx)
+			if err != nil {
+				r.cl.Error(ctx, fmt.Sprintf("releaser: %v", err))
+			}
+			if n > 0 {
+				r.cl.Info(ctx, fmt.Sprintf("releaser: released %d expired reservations", n))
+			}
+		}
+	}
+}
+
+func (r *Releaser) Interrupt(err error) {
+	close(r.cancel)
+}
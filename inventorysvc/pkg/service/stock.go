@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	svcconf "github.com/AyushSenapati/reactive-micro/inventorysvc/conf"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/model"
+	"github.com/google/uuid"
+)
+
+// movementCause tells who is moving the stock and why, along with the request from the context
+func movementCause(ctx context.Context, actor, reason string) model.MovementCause {
+	cause := model.MovementCause{Actor: actor, Reason: reason}
+	cause.RequestID, _ = ctx.Value(svcconf.C.ReqIDKey).(string)
+	return cause
+}
+
+func (svc *basicInventoryService) RestockProduct(
+	ctx context.Context, aid uint, pid uuid.UUID, qty int, reason string) dto.RestockProductResponse {
+
+	po, err := svc.repo.RestockProduct(ctx, pid, qty, movementCause(ctx, fmt.Sprint(aid), reason))
+	if err != nil {
+		return dto.RestockProductResponse{Err: err}
+	}
+	svc.publishProductUpdates(ctx, []uuid.UUID{po.ID})
+
+	return dto.RestockProductResponse{ID: po.ID, Qty: po.Qty, Version: po.Version}
+}
+
+func (svc *basicInventoryService) ListStockMovement(
+	ctx context.Context, pid uuid.UUID, qp *dto.BasicQueryParam) dto.ListStockMovementResponse {
+
+	movements, page, err := svc.repo.ListStockMovement(ctx, pid, qp)
+	if err != nil {
+		return dto.ListStockMovementResponse{Err: err}
+	}
+
+	resp := dto.ListStockMovementResponse{Movements: []dto.StockMovementResponse{}, Page: page}
+	for _, m := range movements {
+		resp.Movements = append(resp.Movements, dto.StockMovementResponse{
+			ID:        m.ID,
+			Kind:      string(m.Kind),
+			Qty:       m.Qty,
+			Delta:     m.Delta,
+			Balance:   m.Balance,
+			OrderID:   m.OID,
+			Actor:     m.Actor,
+			RequestID: m.RequestID,
+			Reason:    m.Reason,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"context"
+
+	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// makeListEventsHandler exposes the events registered by the service along with
+// their payload schema and active subscriptions, so that a deployment can be
+// checked against events.json
+func makeListEventsHandler(m *mux.Router, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/_events").Handler(
+		kithttp.NewServer(
+			func(ctx context.Context, request interface{}) (interface{}, error) {
+				return svcevent.Registry.Describe(), nil
+			},
+			kithttp.NopRequestDecoder,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
@@ -18,6 +18,12 @@ func NewHTTPHandler(endpoints endpoint.Endpoints, options map[string][]kithttp.S
 	makeListMerchantHandler(m, endpoints, options["ListMerchant"])
 	makeCreateProductHandler(m, endpoints, options["CreateProduct"])
 	makeListProductHandler(m, endpoints, options["ListProduct"])
+	makeUpdateProductHandler(m, endpoints, options["UpdateProduct"])
+	makeDeleteProductHandler(m, endpoints, options["DeleteProduct"])
+	makeRestockProductHandler(m, endpoints, options["RestockProduct"])
+	makeListStockMovementHandler(m, endpoints, options["ListStockMovement"])
+
+	makeListEventsHandler(m, options["ListEvents"])
 
 	return m
 }
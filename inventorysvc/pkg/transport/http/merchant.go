@@ -8,6 +8,7 @@ import (
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/endpoint"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/sorting"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
@@ -31,7 +32,7 @@ func decodeCreateMerchantRequest(_ context.Context, r *stdhttp.Request) (interfa
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	err := decoder.Decode(&req)
-	if err != nil {
+	if err != nil || req.ReservationTTL < 0 {
 		err = ce.ErrInvalidReqBody
 	}
 	return req, err
@@ -48,8 +49,13 @@ func makeListMerchantHandler(m *mux.Router, endpoints endpoint.Endpoints, option
 		))
 }
 
+// merchantSorts are the fields which the merchants can be sorted by
+var merchantSorts = sorting.Fields{
+	"name": {Column: "name"},
+}
+
 // decodeListMerchantRequest is a transport/http.DecodeRequestFunc that decodes a
 // JSON-encoded request from the HTTP request body.
 func decodeListMerchantRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
-	return r, nil
+	return processBasicQP(r, merchantSorts, "name")
 }
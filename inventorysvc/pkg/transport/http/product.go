@@ -8,6 +8,7 @@ import (
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/endpoint"
 	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/sorting"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -31,7 +32,7 @@ func decodeCreateProductRequest(_ context.Context, r *stdhttp.Request) (interfac
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	err := decoder.Decode(&req)
-	if err != nil {
+	if err != nil || req.ReservationTTL < 0 {
 		return nil, ce.ErrInvalidReqBody
 	}
 	vars := mux.Vars(r)
@@ -54,9 +55,87 @@ func makeListProductHandler(m *mux.Router, endpoints endpoint.Endpoints, options
 		))
 }
 
+// productSorts are the fields which the products can be sorted by
+var productSorts = sorting.Fields{
+	"created_at": {Column: "created_at"},
+	"updated_at": {Column: "updated_at"},
+	"name":       {Column: "name"},
+	"price":      {Column: "price"},
+	"qty":        {Column: "qty"},
+}
+
 // decodeListProductRequest is a transport/http.DecodeRequestFunc that decodes a
 // JSON-encoded request from the HTTP request body.
 func decodeListProductRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
-	qp := processBasicQP(r)
-	return qp, nil
+	return processBasicQP(r, productSorts, "updated_at__desc")
+}
+
+// makeUpdateProductHandler creates the handler logic, PUT replaces the product and PATCH changes it
+func makeUpdateProductHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("PUT", "PATCH").Path("/products/{pid}").Handler(
+		kithttp.NewServer(
+			endpoints.UpdateProductEndpoint,
+			decodeUpdateProductRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// decodeUpdateProductRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeUpdateProductRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	req := dto.UpdateProductRequest{Replace: r.Method == "PUT"}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, ce.ErrInvalidReqBody
+	}
+
+	if req.Replace {
+		// the whole product is given, the optional fields are reset if not
+		if req.Name == nil || req.Qty == nil || req.Price == nil {
+			return nil, ce.ErrInvalidReqBody
+		}
+		if req.Desc == nil {
+			req.Desc = new(string)
+		}
+		if req.ReservationTTL == nil {
+			req.ReservationTTL = new(int)
+		}
+	} else if req.Name == nil && req.Desc == nil && req.Qty == nil && req.Price == nil && req.ReservationTTL == nil {
+		return nil, ce.ErrInvalidReqBody
+	}
+	if (req.Name != nil && *req.Name == "") || (req.Qty != nil && *req.Qty < 0) ||
+		(req.Price != nil && *req.Price < 0) || (req.ReservationTTL != nil && *req.ReservationTTL < 0) {
+		return nil, ce.ErrInvalidReqBody
+	}
+
+	pid := mux.Vars(r)["pid"]
+	var err error
+	req.PID, err = uuid.Parse(pid)
+	if err != nil {
+		return nil, &ce.ResourceNotFoundErr{Type: "products", ID: pid}
+	}
+	return req, nil
+}
+
+// makeDeleteProductHandler creates the handler logic
+func makeDeleteProductHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("DELETE").Path("/products/{pid}").Handler(
+		kithttp.NewServer(
+			endpoints.DeleteProductEndpoint,
+			decodeDeleteProductRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// decodeDeleteProductRequest decodes the product ID from the path
+func decodeDeleteProductRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	pid := mux.Vars(r)["pid"]
+	id, err := uuid.Parse(pid)
+	if err != nil {
+		return nil, &ce.ResourceNotFoundErr{Type: "products", ID: pid}
+	}
+	return id, nil
 }
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	stdhttp "net/http"
+
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/endpoint"
+	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/sorting"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// makeRestockProductHandler creates the handler logic
+func makeRestockProductHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("POST").Path("/products/{pid}/restock").Handler(
+		kithttp.NewServer(
+			endpoints.RestockProductEndpoint,
+			decodeRestockProductRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// decodeRestockProductRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeRestockProductRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	req := dto.RestockProductRequest{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+	if err != nil || req.Qty <= 0 {
+		return nil, ce.ErrInvalidReqBody
+	}
+	pid := mux.Vars(r)["pid"]
+	req.PID, err = uuid.Parse(pid)
+	if err != nil {
+		return nil, &ce.ResourceNotFoundErr{Type: "products", ID: pid}
+	}
+	return req, nil
+}
+
+// makeListStockMovementHandler creates the handler logic
+func makeListStockMovementHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/products/{pid}/movements").Handler(
+		kithttp.NewServer(
+			endpoints.ListStockMovementEndpoint,
+			decodeListStockMovementRequest,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// stockMovementSorts are the fields which the stock movements can be sorted by
+var stockMovementSorts = sorting.Fields{
+	"created_at": {Column: "created_at"},
+}
+
+// decodeListStockMovementRequest decodes the product ID from the path and the query params
+func decodeListStockMovementRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	pid := mux.Vars(r)["pid"]
+	id, err := uuid.Parse(pid)
+	if err != nil {
+		return nil, &ce.ResourceNotFoundErr{Type: "products", ID: pid}
+	}
+	qp, err := processBasicQP(r, stockMovementSorts, "created_at__desc")
+	if err != nil {
+		return nil, err
+	}
+	return dto.ListStockMovementRequest{PID: id, QP: qp}, nil
+}
@@ -3,11 +3,9 @@ package nats
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	svcconf "github.com/AyushSenapati/reactive-micro/inventorysvc/conf"
-	ce "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/error"
 	svcevent "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/event"
 	pe "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/inventorysvc/pkg/logger"
@@ -27,6 +25,19 @@ func getTargetSub(reqChan, svcName string) string {
 	return reqChan + "." + svcName
 }
 
+// ackInvalid validates the payload of the event and acks the message if it is invalid,
+// as an invalid payload would never be processed successfully, instead of letting it
+// be redelivered. It returns true if the message got acked.
+func ackInvalid(ctx context.Context, logger *cl.CustomLogger, m *nats.Msg, name svcevent.EventName, p interface{}) bool {
+	err := svcevent.ValidatePayload(name, p)
+	if err == nil {
+		return false
+	}
+	logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", name, err))
+	m.Ack()
+	return true
+}
+
 func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IInventoryService) *EventHandlerFuncs {
 	return &EventHandlerFuncs{
 		EventAccountCreatedHandler: makeEventAccountCreatedHandler(logger, svc),
@@ -121,6 +132,10 @@ func makeEventAccountCreatedHandler(logger *cl.CustomLogger, svc service.IInvent
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventAccountCreated, p) {
+			return
+		}
+
 		err = svc.HandleAccountCreatedEvent(ctx, p.AccntID, p.Role)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventAccountCreated] err: %v", err))
@@ -151,6 +166,10 @@ func makeEventPolicyUpdatedHandler(logger *cl.CustomLogger, svc service.IInvento
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventPolicyUpdated, p) {
+			return
+		}
+
 		err = svc.HandlePolicyUpdatedEvent(ctx, p.Method, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if (err == nil) || (err == pe.ErrUnsupportedRtype) || (err == pe.ErrSubNotCached) {
 			m.Ack() // if no error occurred processing event ack it
@@ -181,7 +200,11 @@ func makeEventOrderCreatedHandler(logger *cl.CustomLogger, svc service.IInventor
 			return
 		}
 
-		err = svc.HandleOrderCreatedEvent(ctx, p.OrderID, p.ProductID, p.OrderStatus, p.Qty, p.AccntID)
+		if ackInvalid(ctx, logger, m, svcevent.EventOrderCreated, p) {
+			return
+		}
+
+		err = svc.HandleOrderCreatedEvent(ctx, p.OrderID, p.Lines, p.OrderStatus, p.AccntID)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderCreated] err: %v", err))
 			return
@@ -211,6 +234,10 @@ func makeEventOrderApprovedHandler(logger *cl.CustomLogger, svc service.IInvento
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventOrderApproved, p) {
+			return
+		}
+
 		err = svc.HandleOrderApprovedEvent(ctx, p.OID)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderApproved] err: %v", err))
@@ -241,11 +268,11 @@ func makeEventOrderCanceledHandler(logger *cl.CustomLogger, svc service.IInvento
 			return
 		}
 
-		err = svc.HandleOrderCanceledEvent(ctx, p.OID)
-		if errors.Is(err, &ce.ResourceNotFoundErr{}) {
-			m.Ack()
+		if ackInvalid(ctx, logger, m, svcevent.EventOrderCanceled, p) {
 			return
 		}
+
+		err = svc.HandleOrderCanceledEvent(ctx, p.OID)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderCanceled] err: %v", err))
 			return
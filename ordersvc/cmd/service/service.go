@@ -18,7 +18,10 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
 	svcep "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/endpoint"
+	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/idempotency"
 	svcpe "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
 	svcrepo "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/repo"
@@ -33,12 +36,13 @@ import (
 
 var fs = flag.NewFlagSet("ordersvc", flag.ExitOnError)
 var httpAddr = fs.String("http-addr", ":8082", "HTTP listen address")
+var metricsAddr = fs.String("metrics-addr", "127.0.0.1:9082", "HTTP listen address of the internal metrics")
 
 // holds the name of the protected methods
-var securedMethods = []string{"CreateOrder", "ListOrder"}
+var securedMethods = []string{"CreateOrder", "ListOrder", "GetOrder", "GetOrderHistory", "CancelOrder", "GetSaga", "StreamOrderStatus"}
 
 // holds the name of all the endpoints that ther service supports
-var allMethods = []string{"CreateOrder", "ListOrder"}
+var allMethods = []string{"CreateOrder", "ListOrder", "GetOrder", "GetOrderHistory", "CancelOrder", "GetSaga", "StreamOrderStatus", "ListEvents"}
 
 // holds the database table names that the service is dealing with
 var allResourceTypes = []string{"orders"}
@@ -75,9 +79,21 @@ func Run() {
 	db := getDBConn(confObj.GetDSN())
 
 	// initialise service repo
-	repoObj := svcrepo.NewBasicOrderRepo(db)
-	if repoObj == nil {
-		logger.Info(ctx, "error in initialising service repository")
+	repoObj, err := svcrepo.NewBasicOrderRepo(db)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error in initialising service repository [%v]", err))
+		return
+	}
+	sagaRepo := svcrepo.NewBasicSagaRepo(db)
+	productRepo := svcrepo.NewBasicProductRepo(db)
+
+	// initialise the scheduler of the delayed events
+	scheduler, err := svcevent.NewScheduler(logger, db, nc,
+		svcevent.WithPollInterval(confObj.Scheduler.PollInterval),
+		svcevent.WithLease(confObj.Scheduler.Lease),
+	)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising event scheduler [%v]", err))
 		return
 	}
 
@@ -94,6 +110,14 @@ func Run() {
 		service.WithRepo(repoObj),
 		service.WithNATSEncodedConn(nc),
 		service.WithPolicyStorage(ps),
+		service.WithScheduler(scheduler),
+		service.WithSagaRepo(sagaRepo),
+		service.WithProductRepo(productRepo),
+		service.WithSagaTimeouts(confObj.Saga.ReserveTimeout, confObj.Saga.PaymentTimeout),
+		service.WithCancelableStatuses(confObj.Order.CancelableStatuses),
+		service.WithSweepThresholds(confObj.Sweeper.Thresholds),
+		service.WithStreamPollInterval(confObj.Stream.PollInterval),
+		service.WithStreamCommitWindow(confObj.Stream.CommitWindow),
 	}
 	svc := service.New(logger, getServiceMiddleware(confObj, ps), svcConfigs...)
 	if svc == nil {
@@ -101,12 +125,39 @@ func Run() {
 		return
 	}
 
+	// initialise the sweeper of the stale orders
+	sweeper, err := service.NewSweeper(logger, svc, confObj.Sweeper.Interval)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising order sweeper [%v]", err))
+		return
+	}
+
 	// initialise endpoint
-	eps := svcep.New(svc, getEndpointMW(confObj))
+	idempotencyStore := idempotency.NewGormStore(db, confObj.Idempotency.TTL, confObj.Idempotency.Lease)
+	eps := svcep.New(svc, getEndpointMW(confObj, idempotencyStore))
+
+	// initialise the purger of the expired idempotency keys
+	purger, err := idempotency.NewPurger(logger, idempotencyStore, confObj.Idempotency.PurgeInterval)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising idempotency purger [%v]", err))
+		return
+	}
+
+	// project the policies granted before the ACL projection was deployed
+	n, err := svc.BackfillACL(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error backfilling the acl projection [%v]", err))
+		return
+	}
+	logger.Info(ctx, fmt.Sprintf("backfilled the acl projection with %d policies", n))
 
 	g := &run.Group{}
 	initEventHandler(logger, svc, nc, g)
+	g.Add(purger.Execute, purger.Interrupt)
+	g.Add(scheduler.Execute, scheduler.Interrupt)
+	g.Add(sweeper.Execute, sweeper.Interrupt)
 	initHttpHandler(logger, eps, g)
+	initMetricsHandler(logger, g)
 	initCancelInterrupt(g)
 	err = g.Run()
 	if err != nil {
@@ -129,9 +180,13 @@ func getServiceMiddleware(c *svcconf.Config, ps svcpe.PolicyStorage) (mw []servi
 	return
 }
 
-func getEndpointMW(c *svcconf.Config) (mw map[string][]kitep.Middleware) {
+func getEndpointMW(c *svcconf.Config, is idempotency.Store) (mw map[string][]kitep.Middleware) {
 	mw = map[string][]kitep.Middleware{}
 
+	// replay the retries of the requests made with an Idempotency-Key,
+	// the jwt token parsing middleware wraps it to tell whose key it is
+	mw["CreateOrder"] = append(mw["CreateOrder"], svcep.NewIdempotencyMW(is, "CreateOrder", dto.CreateOrderResponse{}))
+
 	// enforce jwt token parsing middleware on secure endpoints
 	for _, method := range securedMethods {
 		mw[method] = append(
@@ -151,6 +206,7 @@ func initHttpHandler(logger *cl.CustomLogger, endpoints svcep.Endpoints, g *run.
 		options[method] = append(
 			options[method], kithttp.ServerBefore(kitjwt.HTTPToContext()))
 	}
+	options["CreateOrder"] = append(options["CreateOrder"], kithttp.ServerBefore(idempotency.HTTPToContext()))
 
 	httpHandler := httptransport.NewHTTPHandler(endpoints, options)
 	nl, err := net.Listen("tcp", *httpAddr)
@@ -168,6 +224,22 @@ func initHttpHandler(logger *cl.CustomLogger, endpoints svcep.Endpoints, g *run.
 	})
 }
 
+func initMetricsHandler(logger *cl.CustomLogger, g *run.Group) {
+	nl, err := net.Listen("tcp", *metricsAddr)
+	if err != nil {
+		logger.Error(context.TODO(), "transport [HTTP metrics]: err during listing on specified address")
+		return
+	}
+	g.Add(func() error {
+		logger.Info(context.TODO(), fmt.Sprintf("transport [HTTP metrics]: listening at %s", *metricsAddr))
+		return http.Serve(nl, httptransport.NewMetricsHandler())
+	}, func(err error) {
+		logger.Error(context.TODO(), fmt.Sprintf("transport [HTTP metrics]: %v", err))
+		nl.Close()
+		logger.Info(context.TODO(), "transport [HTTP metrics]: closed the HTTP listener")
+	})
+}
+
 func initCancelInterrupt(g *run.Group) {
 	cancelInterrupt := make(chan struct{})
 	g.Add(func() error {
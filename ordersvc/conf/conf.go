@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"time"
 
@@ -29,6 +30,33 @@ var (
 			"access_kid":        "id_at",
 			"refresh_kid":       "id_rt",
 		},
+		"scheduler": map[string]interface{}{
+			"poll_interval": time.Second,
+			"lease":         time.Second * 30,
+		},
+		"saga": map[string]interface{}{
+			"reserve_timeout": time.Minute,
+			"payment_timeout": time.Minute * 5,
+		},
+		"sweeper": map[string]interface{}{
+			"interval": time.Minute,
+			"thresholds": map[string]interface{}{
+				"pending":         time.Minute * 10,
+				"payment_pending": time.Minute * 30,
+			},
+		},
+		"stream": map[string]interface{}{
+			"poll_interval": time.Second,
+			"commit_window": time.Second * 10,
+		},
+		"order": map[string]interface{}{
+			"cancelable_statuses": []string{"pending", "payment_pending", "paid"},
+		},
+		"idempotency": map[string]interface{}{
+			"ttl":            time.Hour * 24,
+			"purge_interval": time.Hour,
+			"lease":          time.Minute,
+		},
 	}
 )
 
@@ -64,12 +92,54 @@ type Config struct {
 		AccessKID       string        `mapstructure:"access_kid"`
 		RefreshKID      string        `mapstructure:"refresh_kid"`
 	} `mapstructure:"auth"`
+
+	// Idempotency configures for how long the Idempotency-Key of a request is kept,
+	// how often the expired keys are purged and for how long a running request holds its key
+	Idempotency struct {
+		TTL           time.Duration `mapstructure:"ttl"`
+		PurgeInterval time.Duration `mapstructure:"purge_interval"`
+		Lease         time.Duration `mapstructure:"lease"`
+	} `mapstructure:"idempotency"`
+
+	// Scheduler configures the poller of the scheduled events
+	Scheduler struct {
+		PollInterval time.Duration `mapstructure:"poll_interval"`
+		Lease        time.Duration `mapstructure:"lease"`
+	} `mapstructure:"scheduler"`
+
+	// Saga configures for how long the order saga waits for the reply of a step
+	Saga struct {
+		ReserveTimeout time.Duration `mapstructure:"reserve_timeout"`
+		PaymentTimeout time.Duration `mapstructure:"payment_timeout"`
+	} `mapstructure:"saga"`
+
+	// Sweeper configures the job which fails the orders stuck in a status
+	Sweeper struct {
+		Interval time.Duration `mapstructure:"interval"`
+		// for how long an order can be in a status before it is failed, by status
+		Thresholds map[string]time.Duration `mapstructure:"thresholds"`
+	} `mapstructure:"sweeper"`
+
+	// Stream configures the order status streams
+	Stream struct {
+		// how often a stream looks for the new status changes
+		PollInterval time.Duration `mapstructure:"poll_interval"`
+		// for how long after it is logged a status change may still be committing,
+		// i.e. the longest transaction which changes the status of an order
+		CommitWindow time.Duration `mapstructure:"commit_window"`
+	} `mapstructure:"stream"`
+
+	// Order configures the order life cycle
+	Order struct {
+		// statuses in which the customer can cancel an order
+		CancelableStatuses []string `mapstructure:"cancelable_statuses"`
+	} `mapstructure:"order"`
 }
 
 func (c *Config) Load(confFname string) error {
 	v := viper.New()
 
-	if *c != (Config{}) {
+	if !reflect.DeepEqual(*c, Config{}) {
 		return ErrAlreadyLoaded
 	}
 
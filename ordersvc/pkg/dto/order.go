@@ -1,8 +1,20 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// CreateOrderRequest holds the lines of the order. An order of a single
+// product can still be requested with product_id and qty.
 type CreateOrderRequest struct {
+	Lines []OrderLine `json:"lines,omitempty"`
+	PID   uuid.UUID   `json:"product_id,omitempty"`
+	Qty   int         `json:"qty,omitempty"`
+}
+
+type OrderLine struct {
 	PID uuid.UUID `json:"product_id"`
 	Qty int       `json:"qty"`
 }
@@ -16,18 +28,114 @@ func (resp CreateOrderResponse) Failed() error {
 	return resp.Err
 }
 
+type CancelOrderResponse struct {
+	OID    uuid.UUID `json:"order_id,omitempty"`
+	Status string    `json:"status,omitempty"`
+	Err    error     `json:"error,omitempty"`
+}
+
+func (resp CancelOrderResponse) Failed() error {
+	return resp.Err
+}
+
+// OrderLineResponse is a line of the order along with the product details
+// known to ordersvc, which are left empty for the products it doesn't know yet
+type OrderLineResponse struct {
+	PID        uuid.UUID  `json:"product_id"`
+	Qty        int        `json:"qty"`
+	ProdName   string     `json:"product_name,omitempty"`
+	UnitPrice  float32    `json:"unit_price,omitempty"`
+	MerchantID *uuid.UUID `json:"merchant_id,omitempty"`
+}
+
 type GetOrderResponse struct {
-	OID      string `json:"order_id"`
-	Status   string `json:"status"`
-	Qty      int    `json:"qty"`
-	ProdName string `json:"product_name"`
+	OID    string              `json:"order_id,omitempty"`
+	Status string              `json:"status,omitempty"`
+	Lines  []OrderLineResponse `json:"lines,omitempty"`
+	Total  float32             `json:"total,omitempty"`
+	Err    error               `json:"error,omitempty"`
+}
+
+func (resp GetOrderResponse) Failed() error {
+	return resp.Err
 }
 
 type ListOrderResponse struct {
 	Orders []GetOrderResponse `json:"orders,omitempty"`
+	Page   *Page              `json:"page,omitempty"`
 	Err    error              `json:"error,omitempty"`
 }
 
 func (resp ListOrderResponse) Failed() error {
 	return resp.Err
 }
+
+// StreamOrderStatusRequest tells from where to stream the status changes.
+// Without Resume only the changes from now on are streamed.
+type StreamOrderStatusRequest struct {
+	LastEventID uint
+	Resume      bool
+}
+
+// OrderStatusEvent is a status change of an order pushed to the client
+type OrderStatusEvent struct {
+	ID     uint      `json:"-"`
+	OID    uuid.UUID `json:"order_id"`
+	From   string    `json:"from,omitempty"`
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// StreamOrderStatusResponse holds the status changes of the orders as they are
+// logged. Events is closed once the client goes away.
+type StreamOrderStatusResponse struct {
+	Events <-chan OrderStatusEvent `json:"-"`
+	Err    error                   `json:"error,omitempty"`
+}
+
+func (resp StreamOrderStatusResponse) Failed() error {
+	return resp.Err
+}
+
+// OrderStatusChangeResponse is a change of the order status along with what caused it
+type OrderStatusChangeResponse struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Time      time.Time `json:"time"`
+	EventID   string    `json:"event_id,omitempty"`
+	EventName string    `json:"event_name,omitempty"`
+	RequestID string    `json:"req_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+type GetOrderHistoryResponse struct {
+	OID     uuid.UUID                   `json:"order_id,omitempty"`
+	History []OrderStatusChangeResponse `json:"history,omitempty"`
+	Err     error                       `json:"error,omitempty"`
+}
+
+func (resp GetOrderHistoryResponse) Failed() error {
+	return resp.Err
+}
+
+type SagaTransitionResponse struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Trigger   string    `json:"trigger"`
+	RequestID string    `json:"req_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type GetSagaResponse struct {
+	SagaID    uuid.UUID                `json:"saga_id,omitempty"`
+	State     string                   `json:"state,omitempty"`
+	CreatedAt time.Time                `json:"created_at,omitempty"`
+	UpdatedAt time.Time                `json:"updated_at,omitempty"`
+	History   []SagaTransitionResponse `json:"history,omitempty"`
+	Err       error                    `json:"error,omitempty"`
+}
+
+func (resp GetSagaResponse) Failed() error {
+	return resp.Err
+}
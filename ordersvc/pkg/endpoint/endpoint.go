@@ -9,16 +9,28 @@ import (
 // meant to be used as a helper struct, to collect all of the endpoints into a
 // single parameter.
 type Endpoints struct {
-	CreateOrderEndpoint endpoint.Endpoint
-	ListOrderEndpoint   endpoint.Endpoint
+	CreateOrderEndpoint     endpoint.Endpoint
+	ListOrderEndpoint       endpoint.Endpoint
+	GetOrderEndpoint        endpoint.Endpoint
+	GetOrderHistoryEndpoint endpoint.Endpoint
+	CancelOrderEndpoint     endpoint.Endpoint
+	GetSagaEndpoint         endpoint.Endpoint
+
+	StreamOrderStatusEndpoint endpoint.Endpoint
 }
 
 // New returns a Endpoints struct that wraps the provided service, and wires in all of the
 // expected endpoint middlewares
 func New(s service.IOrderService, mdw map[string][]endpoint.Middleware) Endpoints {
 	eps := Endpoints{
-		CreateOrderEndpoint: MakeCreatedOrderEndpoint(s),
-		ListOrderEndpoint:   MakeListOrderEndpoint(s),
+		CreateOrderEndpoint:     MakeCreatedOrderEndpoint(s),
+		ListOrderEndpoint:       MakeListOrderEndpoint(s),
+		GetOrderEndpoint:        MakeGetOrderEndpoint(s),
+		GetOrderHistoryEndpoint: MakeGetOrderHistoryEndpoint(s),
+		CancelOrderEndpoint:     MakeCancelOrderEndpoint(s),
+		GetSagaEndpoint:         MakeGetSagaEndpoint(s),
+
+		StreamOrderStatusEndpoint: MakeStreamOrderStatusEndpoint(s),
 	}
 
 	// apply transport middlewares
@@ -28,6 +40,21 @@ func New(s service.IOrderService, mdw map[string][]endpoint.Middleware) Endpoint
 	for _, m := range mdw["ListOrder"] {
 		eps.ListOrderEndpoint = m(eps.ListOrderEndpoint)
 	}
+	for _, m := range mdw["GetOrder"] {
+		eps.GetOrderEndpoint = m(eps.GetOrderEndpoint)
+	}
+	for _, m := range mdw["GetOrderHistory"] {
+		eps.GetOrderHistoryEndpoint = m(eps.GetOrderHistoryEndpoint)
+	}
+	for _, m := range mdw["CancelOrder"] {
+		eps.CancelOrderEndpoint = m(eps.CancelOrderEndpoint)
+	}
+	for _, m := range mdw["GetSaga"] {
+		eps.GetSagaEndpoint = m(eps.GetSagaEndpoint)
+	}
+	for _, m := range mdw["StreamOrderStatus"] {
+		eps.StreamOrderStatusEndpoint = m(eps.StreamOrderStatusEndpoint)
+	}
 
 	return eps
 }
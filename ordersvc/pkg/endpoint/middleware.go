@@ -1,7 +1,11 @@
 package endpoint
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/idempotency"
 	stdjwt "github.com/dgrijalva/jwt-go"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	kitep "github.com/go-kit/kit/endpoint"
@@ -15,3 +19,16 @@ func NewJWTTokenParsingMW(secretKey string) kitep.Middleware {
 	claimFactory := func() stdjwt.Claims { return &dto.CustomClaim{} }
 	return kitjwt.NewParser(kf, stdjwt.SigningMethodHS256, claimFactory)
 }
+
+// NewIdempotencyMW executes the requests of an account made with the same
+// Idempotency-Key only once. It must be wrapped by the jwt token parsing middleware.
+func NewIdempotencyMW(store idempotency.Store, method string, prototype interface{}) kitep.Middleware {
+	subject := func(ctx context.Context) (string, bool) {
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprint(claim.AccntID), true
+	}
+	return idempotency.NewMiddleware(store, method, subject, prototype)
+}
@@ -2,10 +2,12 @@ package endpoint
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/service"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/google/uuid"
 )
@@ -28,7 +30,11 @@ func MakeCreatedOrderEndpoint(s service.IOrderService) endpoint.Endpoint {
 			return dto.CreateOrderResponse{Err: ce.ErrInvalidReqBody}, nil
 		}
 
-		oid, err := s.CreateOrder(ctx, reqObj.PID, reqObj.Qty)
+		lines := reqObj.Lines
+		if len(lines) == 0 && reqObj.PID != uuid.Nil {
+			lines = []dto.OrderLine{{PID: reqObj.PID, Qty: reqObj.Qty}}
+		}
+		oid, err := s.CreateOrder(ctx, lines)
 		return dto.CreateOrderResponse{OID: oid, Err: err}, nil
 	}
 }
@@ -36,6 +42,50 @@ func MakeCreatedOrderEndpoint(s service.IOrderService) endpoint.Endpoint {
 func MakeListOrderEndpoint(s service.IOrderService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		qp, _ := request.(*dto.BasicQueryParam)
-		return s.ListOrder(ctx, []uuid.UUID{}, qp), nil
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.ListOrderResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+		return s.ListOrder(ctx, fmt.Sprint(claim.AccntID), qp), nil
+	}
+}
+
+func MakeGetOrderEndpoint(s service.IOrderService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		oid, ok := request.(uuid.UUID)
+		if !ok {
+			return dto.GetOrderResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.GetOrder(ctx, oid), nil
+	}
+}
+
+func MakeGetOrderHistoryEndpoint(s service.IOrderService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		oid, ok := request.(uuid.UUID)
+		if !ok {
+			return dto.GetOrderHistoryResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.GetOrderHistory(ctx, oid), nil
+	}
+}
+
+func MakeCancelOrderEndpoint(s service.IOrderService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		oid, ok := request.(uuid.UUID)
+		if !ok {
+			return dto.CancelOrderResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.CancelOrder(ctx, oid), nil
+	}
+}
+
+func MakeStreamOrderStatusEndpoint(s service.IOrderService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(dto.StreamOrderStatusRequest)
+		if !ok {
+			return dto.StreamOrderStatusResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.StreamOrderStatus(ctx, req), nil
 	}
 }
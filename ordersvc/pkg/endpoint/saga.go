@@ -0,0 +1,21 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/service"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+)
+
+func MakeGetSagaEndpoint(s service.IOrderService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		oid, ok := request.(uuid.UUID)
+		if !ok {
+			return dto.GetSagaResponse{Err: ce.ErrInvalidReqBody}, nil
+		}
+		return s.GetSaga(ctx, oid), nil
+	}
+}
@@ -18,4 +18,18 @@ var (
 	// ErrInvalidReqBody should be used when request body
 	// does not match expected fields
 	ErrInvalidReqBody = errors.New("invalid request body")
+
+	// ErrInvalidOrderLines should be used when an order has no lines, too many lines,
+	// a line without product or quantity or the same product in several lines
+	ErrInvalidOrderLines = errors.New("invalid order lines")
+
+	// ErrUnknownProduct should be used when an order has a product which ordersvc
+	// doesn't know of, or which got deleted
+	ErrUnknownProduct = errors.New("unknown product")
+
+	// ErrProductOutOfStock should be used when an order has more of a product than in its stock
+	ErrProductOutOfStock = errors.New("product out of stock")
+
+	// ErrOrderNotCancelable should be used when the order is past the statuses in which it can be canceled
+	ErrOrderNotCancelable = errors.New("order can't be canceled anymore")
 )
@@ -0,0 +1,87 @@
+package event
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// EventDescription describes a registered event and the active subscriptions of the service to it
+type EventDescription struct {
+	Name          EventName          `json:"name"`
+	ReqChan       string             `json:"req_chan"`
+	RespChan      string             `json:"resp_chan,omitempty"`
+	Schema        *Schema            `json:"schema"`
+	Subscriptions []SubscriptionInfo `json:"subscriptions,omitempty"`
+}
+
+// SubscriptionInfo holds the details of an active subscription and the JetStream consumer behind it
+type SubscriptionInfo struct {
+	Subject       string `json:"subject"`
+	Consumer      string `json:"consumer,omitempty"`
+	Pending       int    `json:"pending"`         // delivered to the service but not yet handled
+	NumPending    uint64 `json:"num_pending"`     // yet to be delivered by the consumer
+	NumAckPending int    `json:"num_ack_pending"` // delivered by the consumer but not yet acked
+}
+
+// SetSubscriptions records the active subscriptions of the service,
+// so that they can be described along with the registered events
+func (er *EventRegistry) SetSubscriptions(nc *nats.Conn, subs []*nats.Subscription) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.nc = nc
+	er.subs = subs
+}
+
+// Describe lists all the registered events sorted by name along with their payload schema
+// and the active subscriptions of the service. Consumer details are fetched from JetStream
+// assuming the stream of an event is named after the first token of its request channel.
+func (er *EventRegistry) Describe() []EventDescription {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var js nats.JetStreamContext
+	if er.nc != nil {
+		js, _ = er.nc.JetStream()
+	}
+	consumers := map[string]map[string]*nats.ConsumerInfo{} // stream -> deliver subject -> consumer
+
+	descriptions := []EventDescription{}
+	for name, info := range er.Registry {
+		d := EventDescription{
+			Name:     name,
+			ReqChan:  info.ReqChan,
+			RespChan: info.RespChan,
+			Schema:   SchemaOf(info.Payload),
+		}
+
+		for _, s := range er.subs {
+			if !strings.HasPrefix(s.Subject, info.ReqChan+".") {
+				continue
+			}
+			si := SubscriptionInfo{Subject: s.Subject}
+			si.Pending, _, _ = s.Pending()
+
+			stream := strings.Split(info.ReqChan, ".")[0]
+			if _, found := consumers[stream]; !found && js != nil {
+				consumers[stream] = map[string]*nats.ConsumerInfo{}
+				for ci := range js.ConsumersInfo(stream) {
+					consumers[stream][ci.Config.DeliverSubject] = ci
+				}
+			}
+			if ci, found := consumers[stream][s.Subject]; found {
+				si.Consumer = ci.Name
+				si.NumPending = ci.NumPending
+				si.NumAckPending = ci.NumAckPending
+			}
+			d.Subscriptions = append(d.Subscriptions, si)
+		}
+		descriptions = append(descriptions, d)
+	}
+
+	sort.Slice(descriptions, func(i, j int) bool {
+		return descriptions[i].Name < descriptions[j].Name
+	})
+	return descriptions
+}
@@ -6,6 +6,7 @@ const EventAccountCreated EventName = "EventAccountCreated"
 func init() {
 	Registry.register(EventAccountCreated, EventInfo{
 		ReqChan: "authnsvc.EventAccountCreated",
+		Payload: EventAccountCreatedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventAccountCreatedPayload)
 			return ok
@@ -14,6 +15,6 @@ func init() {
 }
 
 type EventAccountCreatedPayload struct {
-	AccntID uint   `json:"accnt_id"`
-	Role    string `json:"role"`
+	AccntID uint   `json:"accnt_id" validate:"required"`
+	Role    string `json:"role" validate:"required"`
 }
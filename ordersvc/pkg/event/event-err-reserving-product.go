@@ -8,6 +8,7 @@ const EventErrReservingProduct EventName = "EventErrReservingProduct"
 func init() {
 	Registry.register(EventErrReservingProduct, EventInfo{
 		ReqChan: "inventorysvc.EventErrReservingProduct",
+		Payload: EventErrReservingProductPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventErrReservingProductPayload)
 			return ok
@@ -16,5 +17,5 @@ func init() {
 }
 
 type EventErrReservingProductPayload struct {
-	OrderID uuid.UUID `json:"order_id"`
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
 }
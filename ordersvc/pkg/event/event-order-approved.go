@@ -8,6 +8,7 @@ const EventOrderApproved EventName = "EventOrderApproved"
 func init() {
 	Registry.register(EventOrderApproved, EventInfo{
 		ReqChan: "ordersvc.EventOrderApproved",
+		Payload: EventOrderApprovedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventOrderApprovedPayload)
 			return ok
@@ -16,6 +17,6 @@ func init() {
 }
 
 type EventOrderApprovedPayload struct {
-	OID     uuid.UUID `json:"order_id"`
-	AccntID uint      `json:"account_id"`
+	OID     uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
 }
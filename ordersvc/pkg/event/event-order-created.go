@@ -8,6 +8,7 @@ const EventOrderCreated EventName = "EventOrderCreated"
 func init() {
 	Registry.register(EventOrderCreated, EventInfo{
 		ReqChan: "ordersvc.EventOrderCreated",
+		Payload: EventOrderCreatedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventOrderCreatedPayload)
 			return ok
@@ -16,9 +17,17 @@ func init() {
 }
 
 type EventOrderCreatedPayload struct {
-	OrderID     uuid.UUID `json:"order_id"`
-	OrderStatus string    `json:"order_status"`
-	AccntID     uint      `json:"account_id"`
-	ProductID   uuid.UUID `json:"product_id"`
-	Qty         int       `json:"quantity"`
+	OrderID     uuid.UUID        `json:"order_id" validate:"required"`
+	OrderStatus string           `json:"order_status" validate:"required"`
+	AccntID     uint             `json:"account_id" validate:"required"`
+	Lines       []EventOrderLine `json:"lines" validate:"min=1"`
+	// Total is the payable quoted to the customer
+	Total float32 `json:"total" validate:"min=0"`
+}
+
+// EventOrderLine is a product of the order along with its quantity and quoted unit price
+type EventOrderLine struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Qty       int       `json:"quantity" validate:"min=1"`
+	UnitPrice float32   `json:"unit_price" validate:"min=0"`
 }
@@ -0,0 +1,22 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventProductDeleted EventName = "EventProductDeleted"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventProductDeleted, EventInfo{
+		ReqChan: "inventorysvc.EventProductDeleted",
+		Payload: EventProductDeletedPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventProductDeletedPayload)
+			return ok
+		},
+	})
+}
+
+type EventProductDeletedPayload struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Version   int       `json:"version" validate:"min=1"`
+}
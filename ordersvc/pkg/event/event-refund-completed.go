@@ -0,0 +1,24 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventRefundCompleted EventName = "EventRefundCompleted"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventRefundCompleted, EventInfo{
+		ReqChan: "paymentsvc.EventRefundCompleted",
+		Payload: EventRefundCompletedPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventRefundCompletedPayload)
+			return ok
+		},
+	})
+}
+
+type EventRefundCompletedPayload struct {
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
+	TXID    uuid.UUID `json:"transaction_id" validate:"required"` // the refund transaction
+	Amount  float32   `json:"amount" validate:"min=0"`
+}
@@ -0,0 +1,21 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventReservationExpired EventName = "EventReservationExpired"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventReservationExpired, EventInfo{
+		ReqChan: "inventorysvc.EventReservationExpired",
+		Payload: EventReservationExpiredPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventReservationExpiredPayload)
+			return ok
+		},
+	})
+}
+
+type EventReservationExpiredPayload struct {
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+}
@@ -0,0 +1,22 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventSagaTimedOut EventName = "EventSagaTimedOut"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventSagaTimedOut, EventInfo{
+		ReqChan: "ordersvc.EventSagaTimedOut",
+		Payload: EventSagaTimedOutPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventSagaTimedOutPayload)
+			return ok
+		},
+	})
+}
+
+type EventSagaTimedOutPayload struct {
+	SagaID uuid.UUID `json:"saga_id" validate:"required"`
+	State  string    `json:"state" validate:"oneof=reserving|paying"` // the state which timed out
+}
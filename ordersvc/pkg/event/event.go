@@ -3,6 +3,7 @@ package event
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	svcconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
@@ -22,11 +23,19 @@ var Registry = &EventRegistry{
 // It helps in verifying the event name and getting their request/response channel
 type EventRegistry struct {
 	Registry map[EventName]EventInfo
+
+	// active subscriptions of the service, see SetSubscriptions
+	mu   sync.RWMutex
+	nc   *nats.Conn
+	subs []*nats.Subscription
 }
 
 type EventInfo struct {
-	ReqChan        string
-	RespChan       string
+	ReqChan  string
+	RespChan string
+	// Payload is the zero value of the payload struct of the event.
+	// It describes the fields which the service writes/reads for the event
+	Payload        interface{}
 	isValidPayload func(interface{}) bool
 }
 
@@ -84,6 +93,20 @@ type EventMeta struct {
 	RequestID string    `json:"req_id"`
 }
 
+type eventMetaKey struct{}
+
+// WithEventMeta returns a copy of the context carrying the meta of the event being handled,
+// so that the changes made on the event can be traced back to it
+func WithEventMeta(ctx context.Context, meta EventMeta) context.Context {
+	return context.WithValue(ctx, eventMetaKey{}, meta)
+}
+
+// EventMetaFromContext returns the meta of the event being handled, if any
+func EventMetaFromContext(ctx context.Context) (EventMeta, bool) {
+	meta, ok := ctx.Value(eventMetaKey{}).(EventMeta)
+	return meta, ok
+}
+
 func getEventMeta(ctx context.Context, name string) EventMeta {
 	reqID := ctx.Value(svcconf.C.ReqIDKey)
 	if reqID == nil {
@@ -116,6 +139,11 @@ func NewEvent(ctx context.Context, name EventName, payload interface{}) (IEvent,
 		return nil, fmt.Errorf("payload checker is not set for event: %s", name)
 	}
 
+	// check the field level validation rules of the payload
+	if err := ValidatePayload(name, payload); err != nil {
+		return nil, err
+	}
+
 	e := &Event{Meta: getEventMeta(ctx, string(name)), Payload: payload}
 
 	return e, nil
@@ -132,7 +160,7 @@ func NewEventPublisher() *EventPublisher {
 
 func (ep *EventPublisher) AddEvent(e IEvent, err error) error {
 	if e == nil || err != nil {
-		err := fmt.Errorf("event publisher: err adding event [err: %v]", err)
+		err := fmt.Errorf("event publisher: err adding event [err: %w]", err)
 		return err
 	}
 	ep.events = append(ep.events, e)
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidatePayload(t *testing.T) {
+	valid := EventOrderCreatedPayload{
+		OrderID:     uuid.New(),
+		OrderStatus: "pending",
+		AccntID:     7,
+		Lines:       []EventOrderLine{{ProductID: uuid.New(), Qty: 2}},
+	}
+	withLine := func(l EventOrderLine) EventOrderCreatedPayload {
+		p := valid
+		p.Lines = []EventOrderLine{valid.Lines[0], l}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		event   EventName
+		payload interface{}
+		field   string
+	}{
+		{"valid", EventOrderCreated, valid, ""},
+		{"no lines", EventOrderCreated, func() EventOrderCreatedPayload { p := valid; p.Lines = nil; return p }(), "lines"},
+		{"negative qty", EventOrderCreated, withLine(EventOrderLine{ProductID: uuid.New(), Qty: -5}), "lines[1].quantity"},
+		{"nil product id", EventOrderCreated, withLine(EventOrderLine{Qty: 1}), "lines[1].product_id"},
+		{"empty account id", EventOrderCreated, func() EventOrderCreatedPayload { p := valid; p.AccntID = 0; return p }(), "account_id"},
+		{"unknown payment status", EventPayment, EventPaymentPayload{OrderID: uuid.New(), AccntID: 7, Status: "paid"}, "status"},
+		{"valid payment status", EventPayment, EventPaymentPayload{OrderID: uuid.New(), AccntID: 7, Status: "payment_failed"}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePayload(tc.event, tc.payload)
+			if tc.field == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var fieldErr *ErrInvalidField
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("want ErrInvalidField, got %v", err)
+			}
+			if fieldErr.Field != tc.field {
+				t.Errorf("want offending field %s, got %s", tc.field, fieldErr.Field)
+			}
+			if !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("ErrInvalidField must wrap ErrInvalidPayload")
+			}
+		})
+	}
+}
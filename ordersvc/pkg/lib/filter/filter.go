@@ -0,0 +1,188 @@
+// Package filter implements the filter grammar of the list endpoints, e.g.
+// `?status=in:paid,failed&created_at=gte:2026-01-01&price=lt:20`. Every query
+// param is a condition on a field, written as `op:value` or just `value` for eq.
+// The conditions are applied to the gorm queries only on the fields which the
+// repo of the listed resource allows.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFilter is returned for the filters which can't be parsed or applied
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Op is an operator of the filter grammar
+type Op string
+
+const (
+	OpEq       Op = "eq"
+	OpNe       Op = "ne"
+	OpGt       Op = "gt"
+	OpGte      Op = "gte"
+	OpLt       Op = "lt"
+	OpLte      Op = "lte"
+	OpIn       Op = "in"
+	OpContains Op = "contains"
+)
+
+// sqlOps maps the comparison operators to SQL
+var sqlOps = map[Op]string{
+	OpEq: "=", OpNe: "<>", OpGt: ">", OpGte: ">=", OpLt: "<", OpLte: "<=",
+}
+
+// Cond is a condition on a field. Only OpIn takes more than one value.
+type Cond struct {
+	Field  string
+	Op     Op
+	Values []string
+}
+
+// Parse parses the value of the query param of a field
+func Parse(field, expr string) (Cond, error) {
+	c := Cond{Field: field, Op: OpEq}
+	if i := strings.Index(expr, ":"); i > 0 {
+		op := Op(expr[:i])
+		if _, ok := sqlOps[op]; ok || op == OpIn || op == OpContains {
+			c.Op, expr = op, expr[i+1:]
+		}
+	}
+
+	if c.Op == OpIn {
+		c.Values = strings.Split(expr, ",")
+	} else {
+		c.Values = []string{expr}
+	}
+	for _, v := range c.Values {
+		if v == "" {
+			return c, fmt.Errorf("%w: %s has no value", ErrInvalidFilter, field)
+		}
+	}
+	return c, nil
+}
+
+// Kind is the type of the values of a field
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Time
+	UUID
+	Bool
+)
+
+// Field is a filterable field, Column is what its conditions are applied on
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Fields are the filterable fields of a resource by their query param
+type Fields map[string]Field
+
+// Scope returns a gorm scope applying the conditions. The query fails with
+// ErrInvalidFilter for the fields which are not allowed and the values which
+// don't suit the field.
+func (fs Fields) Scope(conds []Cond) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		exprs, err := fs.build(conds)
+		if err != nil {
+			tx.AddError(err)
+			return tx
+		}
+		for _, e := range exprs {
+			tx = tx.Where(e.query, e.arg)
+		}
+		return tx
+	}
+}
+
+// likeEscaper escapes the wildcards and the escape character of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type expr struct {
+	query string
+	arg   interface{}
+}
+
+func (fs Fields) build(conds []Cond) ([]expr, error) {
+	exprs := []expr{}
+	for _, c := range conds {
+		f, ok := fs[c.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %s, allowed are %s", ErrInvalidFilter, c.Field, fs.names())
+		}
+		if !f.allows(c.Op) {
+			return nil, fmt.Errorf("%w: %s doesn't support %s", ErrInvalidFilter, c.Field, c.Op)
+		}
+
+		args := []interface{}{}
+		for _, v := range c.Values {
+			arg, err := f.parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s has invalid value %s", ErrInvalidFilter, c.Field, v)
+			}
+			args = append(args, arg)
+		}
+
+		switch c.Op {
+		case OpIn:
+			exprs = append(exprs, expr{f.Column + " IN ?", args})
+		case OpContains:
+			// the wildcards of the value are matched literally
+			exprs = append(exprs, expr{
+				"LOWER(" + f.Column + ") LIKE ? ESCAPE '\\'", "%" + likeEscaper.Replace(strings.ToLower(c.Values[0])) + "%"})
+		default:
+			exprs = append(exprs, expr{f.Column + " " + sqlOps[c.Op] + " ?", args[0]})
+		}
+	}
+	return exprs, nil
+}
+
+func (fs Fields) names() string {
+	names := []string{}
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (f Field) allows(op Op) bool {
+	switch op {
+	case OpEq, OpNe:
+		return true
+	case OpIn:
+		return f.Kind != Bool
+	case OpContains:
+		return f.Kind == String
+	default:
+		return f.Kind == Number || f.Kind == Time
+	}
+}
+
+func (f Field) parse(v string) (interface{}, error) {
+	switch f.Kind {
+	case Number:
+		return strconv.ParseFloat(v, 64)
+	case Time:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", v)
+	case UUID:
+		return uuid.Parse(v)
+	case Bool:
+		return strconv.ParseBool(v)
+	}
+	return v, nil
+}
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store for the tests
+type memStore map[string]*Record
+
+func (m memStore) Begin(ctx context.Context, subject, key, fingerprint string) (Record, bool, error) {
+	if rec, ok := m[subject+key]; ok {
+		return *rec, false, nil
+	}
+	m[subject+key] = &Record{Subject: subject, Key: key, Fingerprint: fingerprint}
+	return *m[subject+key], true, nil
+}
+
+func (m memStore) Complete(ctx context.Context, subject, key string, response []byte) error {
+	m[subject+key].Response = response
+	m[subject+key].Completed = true
+	return nil
+}
+
+func (m memStore) Release(ctx context.Context, subject, key string) error {
+	delete(m, subject+key)
+	return nil
+}
+
+func (m memStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	for k, rec := range m {
+		if !rec.ExpiresAt.After(before) {
+			delete(m, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+type testRequest struct {
+	Amount int `json:"amount"`
+}
+
+type testResponse struct {
+	ID  int   `json:"id"`
+	Err error `json:"-"`
+}
+
+func (resp testResponse) Failed() error {
+	return resp.Err
+}
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	var fail error
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		calls++
+		return testResponse{ID: calls, Err: fail}, nil
+	}
+	subject := func(ctx context.Context) (string, bool) { return "1", true }
+	ep := NewMiddleware(memStore{}, "Test", subject, testResponse{})(next)
+	withKey := func(key string) context.Context {
+		return context.WithValue(context.Background(), keyCtxKey{}, key)
+	}
+
+	// retries get the response of the first request
+	for i := 0; i < 2; i++ {
+		resp, err := ep(withKey("a"), testRequest{Amount: 1})
+		if err != nil || resp.(testResponse).ID != 1 {
+			t.Fatalf("retry %d: unexpected response %+v, err %v", i, resp, err)
+		}
+	}
+	if _, err := ep(withKey("a"), testRequest{Amount: 2}); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("key reused with another request: want ErrKeyReused, got %v", err)
+	}
+
+	// the requests without a key are executed every time
+	ep(context.Background(), testRequest{Amount: 1})
+	if calls != 2 {
+		t.Errorf("calls: want 2, got %d", calls)
+	}
+
+	// a failed request is executed again on retry
+	fail = errors.New("failed")
+	ep(withKey("b"), testRequest{Amount: 1})
+	fail = nil
+	resp, _ := ep(withKey("b"), testRequest{Amount: 1})
+	if resp.(testResponse).ID != 4 {
+		t.Errorf("retry of failed request: want it executed, got %+v", resp)
+	}
+}
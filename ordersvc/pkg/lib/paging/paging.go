@@ -0,0 +1,277 @@
+// Package paging implements the cursor pagination of the list endpoints. A page
+// carries opaque cursors to the pages next to it. A cursor encodes the sort keys
+// and the ID of the row the page starts after, so that the rows inserted or
+// deleted meanwhile don't shift the pages like an offset does.
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/sorting"
+)
+
+// ErrInvalidCursor is returned for the cursors which can't be decoded
+// or were issued for another order of the list
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	// DefaultSize is the page size when none is asked for
+	DefaultSize = 10
+	// MaxSize is the largest page size
+	MaxSize = 100
+)
+
+// Page is the envelope of the list responses
+type Page struct {
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
+	PageSize     int    `json:"page_size"`
+	TotalRecords *int64 `json:"total_records,omitempty"`
+}
+
+// Query is the page to be fetched
+type Query struct {
+	// Keys are the sort keys, the ID column is appended as the last one
+	Keys     []sorting.Key
+	IDColumn string
+	// Cursor is the one of the page next to this, the first page if empty
+	Cursor string
+	Size   int
+	// Total counts the rows of all the pages
+	Total bool
+}
+
+// cursor is the position of a row in a sorted list
+type cursor struct {
+	OrderBy  string  `json:"o"`
+	Values   []value `json:"v"`
+	Backward bool    `json:"b,omitempty"`
+}
+
+// value is a typed value of a sort key, so that it gets compared as the same type
+type value struct {
+	Type string `json:"t"`
+	V    string `json:"v"`
+}
+
+// Find fetches the page of the query into dest, a pointer to a slice of the rows
+func Find(tx *gorm.DB, q Query, dest interface{}) (*Page, error) {
+	keys := append(append([]sorting.Key{}, q.Keys...), sorting.Key{Column: q.IDColumn})
+	if len(q.Keys) > 0 {
+		keys[len(keys)-1].Desc = q.Keys[len(q.Keys)-1].Desc
+	}
+	page := &Page{PageSize: q.Size}
+	switch {
+	case page.PageSize > MaxSize:
+		page.PageSize = MaxSize
+	case page.PageSize <= 0:
+		page.PageSize = DefaultSize
+	}
+
+	var c *cursor
+	if q.Cursor != "" {
+		var err error
+		if c, err = decode(q.Cursor, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Total {
+		var total int64
+		if err := tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.TotalRecords = &total
+	}
+
+	backward := c != nil && c.Backward
+	if c != nil {
+		where, args, err := after(keys, c)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(where, args...)
+	}
+	// a page before the cursor is fetched in the reverse order
+	order := keys
+	if backward {
+		order = make([]sorting.Key, len(keys))
+		for i, k := range keys {
+			order[i] = sorting.Key{Column: k.Column, Desc: !k.Desc}
+		}
+	}
+	// the row past the page tells whether there is a page after it
+	if err := tx.Scopes(sorting.Scope(order)).Limit(page.PageSize + 1).Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	more := rows.Len() > page.PageSize
+	if more {
+		rows.Set(rows.Slice(0, page.PageSize))
+	}
+	if backward {
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			ri, rj := rows.Index(i).Interface(), rows.Index(j).Interface()
+			rows.Index(i).Set(reflect.ValueOf(rj))
+			rows.Index(j).Set(reflect.ValueOf(ri))
+		}
+	}
+	if rows.Len() == 0 {
+		return page, nil
+	}
+
+	// the page of the cursor is next to a page fetched backward
+	hasNext, hasPrev := more, c != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	var err error
+	if hasNext {
+		if page.NextCursor, err = encode(tx, keys, rows.Index(rows.Len()-1), false); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = encode(tx, keys, rows.Index(0), true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// after returns the condition of the rows after the cursor in the order of the keys,
+// e.g. `(a > ?) OR (a = ? AND id > ?)`, or before it if the cursor is backward
+func after(keys []sorting.Key, c *cursor) (string, []interface{}, error) {
+	values := []interface{}{}
+	for _, v := range c.Values {
+		arg, err := v.decode()
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		values = append(values, arg)
+	}
+
+	ors := []string{}
+	args := []interface{}{}
+	for i, k := range keys {
+		ands := []string{}
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if k.Desc != c.Backward {
+			op = "<"
+		}
+		ands = append(ands, k.Column+" "+op+" ?")
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args, nil
+}
+
+// orderBy describes the keys, a cursor is valid only for the same keys
+func orderBy(keys []sorting.Key) string {
+	desc := []string{}
+	for _, k := range keys {
+		if k.Desc {
+			desc = append(desc, k.Column+" desc")
+		} else {
+			desc = append(desc, k.Column)
+		}
+	}
+	return strings.Join(desc, ",")
+}
+
+var schemas = &sync.Map{}
+
+// encode encodes the cursor of the row
+func encode(tx *gorm.DB, keys []sorting.Key, row reflect.Value, backward bool) (string, error) {
+	s, err := schema.Parse(row.Interface(), schemas, tx.NamingStrategy)
+	if err != nil {
+		return "", err
+	}
+	c := cursor{OrderBy: orderBy(keys), Backward: backward}
+	for _, k := range keys {
+		// the columns of the keys can be qualified with the table, e.g. u.id
+		name := k.Column[strings.LastIndex(k.Column, ".")+1:]
+		f := s.LookUpField(name)
+		if f == nil {
+			return "", fmt.Errorf("paging: %s has no field of column %s", s.Name, name)
+		}
+		v, _ := f.ValueOf(row)
+		c.Values = append(c.Values, newValue(v))
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decode(s string, keys []sorting.Key) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.OrderBy != orderBy(keys) || len(c.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: it is of another orderby", ErrInvalidCursor)
+	}
+	return &c, nil
+}
+
+func newValue(v interface{}) value {
+	switch t := v.(type) {
+	case time.Time:
+		return value{"time", t.Format(time.RFC3339Nano)}
+	case uuid.UUID:
+		return value{"uuid", t.String()}
+	case string:
+		return value{"string", t}
+	case bool:
+		return value{"bool", strconv.FormatBool(t)}
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value{"float", strconv.FormatFloat(rv.Float(), 'g', -1, 64)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value{"int", strconv.FormatUint(rv.Uint(), 10)}
+	}
+	return value{"int", fmt.Sprint(v)}
+}
+
+func (v value) decode() (interface{}, error) {
+	switch v.Type {
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.V)
+	case "uuid":
+		return uuid.Parse(v.V)
+	case "string":
+		return v.V, nil
+	case "bool":
+		return strconv.ParseBool(v.V)
+	case "float":
+		return strconv.ParseFloat(v.V, 64)
+	case "int":
+		return strconv.ParseInt(v.V, 10, 64)
+	}
+	return nil, fmt.Errorf("unknown type %s", v.Type)
+}
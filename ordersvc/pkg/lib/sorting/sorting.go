@@ -0,0 +1,101 @@
+// Package sorting implements the orderby query param of the list endpoints,
+// e.g. `?orderby=created_at__desc,status`. Every list endpoint declares the
+// fields it can be sorted by and the queries are ordered only by their columns.
+package sorting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidSort is returned for the orderby keys which are not allowed
+var ErrInvalidSort = errors.New("invalid orderby")
+
+// Dir is a direction of sorting
+type Dir string
+
+const (
+	Asc  Dir = "asc"
+	Desc Dir = "desc"
+)
+
+// Field is a sortable field. Column is what it is sorted by and
+// Dirs are the directions it can be sorted in, both if empty.
+type Field struct {
+	Column string
+	Dirs   []Dir
+}
+
+// Fields are the sortable fields of a list endpoint by their name in orderby
+type Fields map[string]Field
+
+// Key is a key of the ORDER BY clause
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+// Parse parses the orderby query param, which is a comma separated list
+// of fields, each of them optionally followed by `__asc` or `__desc`
+func (fs Fields) Parse(orderby string) ([]Key, error) {
+	keys := []Key{}
+	for _, s := range strings.Split(orderby, ",") {
+		name, dir := s, Asc
+		if i := strings.Index(s, "__"); i >= 0 {
+			name, dir = s[:i], Dir(s[i+2:])
+		}
+
+		f, ok := fs[name]
+		if !ok || !f.allows(dir) {
+			return nil, fmt.Errorf("%w: %s, allowed are %s", ErrInvalidSort, s, fs.names())
+		}
+		keys = append(keys, Key{Column: f.Column, Desc: dir == Desc})
+	}
+	return keys, nil
+}
+
+// Scope returns a gorm scope ordering the query by the keys
+func Scope(keys []Key) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, k := range keys {
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: k.Column}, Desc: k.Desc})
+		}
+		return tx
+	}
+}
+
+func (f Field) allows(dir Dir) bool {
+	if len(f.Dirs) == 0 {
+		return dir == Asc || dir == Desc
+	}
+	for _, d := range f.Dirs {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// names lists every allowed field along with its directions, e.g. `name, name__desc`
+func (fs Fields) names() string {
+	names := []string{}
+	for name, f := range fs {
+		for _, dir := range []Dir{Asc, Desc} {
+			if !f.allows(dir) {
+				continue
+			}
+			if dir == Asc {
+				names = append(names, name)
+			} else {
+				names = append(names, name+"__"+string(dir))
+			}
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
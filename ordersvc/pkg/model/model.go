@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,17 +14,118 @@ const (
 	OrderStatusPaymentPending    = OrderStatus("payment_pending")
 	OrderStatusPaid              = OrderStatus("paid")
 	OrderStatusProductOutOfStock = OrderStatus("product_out_of_stock")
+	OrderStatusPriceChanged      = OrderStatus("price_changed")
 	OrderStatusCancelRequested   = OrderStatus("cancel_requested")
 	OrderStatusCanceled          = OrderStatus("canceled")
 	OrderStatusFailed            = OrderStatus("failed")
 )
 
+// orderTransitions is the graph of the allowed order status transitions.
+// Statuses which are not listed are terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {
+		OrderStatusPaymentPending,
+		// the payment can be seen before the reservation which triggered it
+		OrderStatusPaid,
+		OrderStatusProductOutOfStock,
+		OrderStatusPriceChanged,
+		OrderStatusCancelRequested,
+		OrderStatusFailed,
+	},
+	OrderStatusPaymentPending: {
+		OrderStatusPaid,
+		OrderStatusCancelRequested,
+		OrderStatusFailed,
+	},
+	OrderStatusPaid: {
+		OrderStatusCancelRequested,
+		// the reservation expired before the approval, the payment is refunded
+		OrderStatusFailed,
+	},
+	OrderStatusCancelRequested: {OrderStatusCanceled},
+}
+
+// CanTransitTo reports if an order can move from the status to the given one
+func (s OrderStatus) CanTransitTo(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedFrom returns the statuses from which an order can move to the status
+func (s OrderStatus) AllowedFrom() (from []OrderStatus) {
+	for status := range orderTransitions {
+		if status.CanTransitTo(s) {
+			from = append(from, status)
+		}
+	}
+	return
+}
+
+// ErrIllegalStatusTransition is returned when an order can't move from its current status to the requested one
+type ErrIllegalStatusTransition struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *ErrIllegalStatusTransition) Error() string {
+	return fmt.Sprintf("order: status can't change from %s to %s", e.From, e.To)
+}
+
 type Order struct {
 	ID        uuid.UUID `gorm:"primaryKey"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 	AccntID   uint
-	ProductID uuid.UUID
 	Status    string
+	Lines     []OrderLine `gorm:"foreignKey:OrderID"`
+	// Total is the payable quoted to the customer on creating the order
+	Total float32
+}
+
+// OrderLine is a product of an order along with its quantity
+type OrderLine struct {
+	ID        uint      `gorm:"primaryKey"`
+	OrderID   uuid.UUID `gorm:"index"`
+	ProductID uuid.UUID
 	Qty       int
+	// UnitPrice is the price of the product quoted on creating the order
+	UnitPrice float32
+}
+
+// TotalOf sums the quoted prices of the lines
+func TotalOf(lines []OrderLine) (total float32) {
+	for _, l := range lines {
+		total += float32(l.Qty) * l.UnitPrice
+	}
+	return
+}
+
+// OrderStatusChange is an entry of the append-only log of the order status changes.
+// Its ID orders the changes and identifies them to the clients streaming them.
+type OrderStatusChange struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	OrderID   uuid.UUID `gorm:"index"`
+	AccntID   uint      `gorm:"index"`
+	From      string
+	To        string
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	StatusChangeCause `gorm:"embedded"`
+}
+
+// StatusChangeCause tells what changed the status of an order: the event which
+// was being handled or the HTTP request, along with the reason if there is one
+type StatusChangeCause struct {
+	EventID   string
+	EventName string
+	RequestID string
+	Reason    string
+}
+
+func (OrderStatusChange) TableName() string {
+	return "order_status_history"
 }
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		ok       bool
+	}{
+		{OrderStatusPending, OrderStatusPaymentPending, true},
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPaymentPending, OrderStatusFailed, true},
+		{OrderStatusPaid, OrderStatusCancelRequested, true},
+		{OrderStatusPaid, OrderStatusFailed, true},
+		{OrderStatusCancelRequested, OrderStatusCanceled, true},
+		{OrderStatusPaid, OrderStatusProductOutOfStock, false},
+		{OrderStatusPaid, OrderStatusPending, false},
+		{OrderStatusFailed, OrderStatusPaid, false},
+		{OrderStatusCanceled, OrderStatusCancelRequested, false},
+	}
+	for _, tc := range tests {
+		if ok := tc.from.CanTransitTo(tc.to); ok != tc.ok {
+			t.Errorf("%s -> %s: want %v, got %v", tc.from, tc.to, tc.ok, ok)
+		}
+	}
+
+	if from := OrderStatusProductOutOfStock.AllowedFrom(); len(from) != 1 || from[0] != OrderStatusPending {
+		t.Errorf("product_out_of_stock must be reachable only from pending, got %v", from)
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Product is the local projection of a product of inventorysvc.
+// It is read-only for ordersvc and kept up to date from the inventory events.
+type Product struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	MerchantID uuid.UUID
+	Name       string
+	Price      float32
+	Qty        int
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	// Version of the product in inventorysvc the projection is at
+	Version int
+	// Deleted products are kept to show the details of their past orders
+	Deleted bool
+}
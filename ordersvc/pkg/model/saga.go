@@ -0,0 +1,242 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SagaState is the state of the saga which coordinates an order across
+// inventorysvc and paymentsvc. The saga ID is the ID of the order.
+type SagaState string
+
+const (
+	// waiting for inventorysvc to reserve the product
+	SagaStateReserving = SagaState("reserving")
+	// waiting for paymentsvc to deduct the payable
+	SagaStatePaying = SagaState("paying")
+	// the order is paid and approved
+	SagaStateCompleted = SagaState("completed")
+	// the product could not be reserved, nothing to compensate
+	SagaStateFailed = SagaState("failed")
+	// the price of a product moved away from the quoted one, nothing to compensate
+	SagaStateRejected = SagaState("rejected")
+	// the order is canceled and the completed steps are undone
+	SagaStateCompensated = SagaState("compensated")
+	// the customer canceled the order, waiting for the completed steps to be undone
+	SagaStateCanceling = SagaState("canceling")
+	// the completed steps of the order canceled by the customer are undone
+	SagaStateCanceled = SagaState("canceled")
+)
+
+// SagaTrigger is what moves a saga from one state to another,
+// i.e. the reply of a step or the timeout of a step
+type SagaTrigger string
+
+const (
+	// records the start of a saga in its history
+	SagaTriggerStarted = SagaTrigger("started")
+
+	SagaTriggerProductReserved     = SagaTrigger("product_reserved")
+	SagaTriggerErrReservingProduct = SagaTrigger("err_reserving_product")
+	SagaTriggerPriceMismatch       = SagaTrigger("price_mismatch")
+	SagaTriggerPaymentSuccessful   = SagaTrigger("payment_successful")
+	SagaTriggerPaymentFailed       = SagaTrigger("payment_failed")
+	SagaTriggerTimedOut            = SagaTrigger("timed_out")
+	SagaTriggerCancelRequested     = SagaTrigger("cancel_requested")
+	SagaTriggerProductReleased     = SagaTrigger("product_released")
+	SagaTriggerRefundCompleted     = SagaTrigger("refund_completed")
+	// inventorysvc gave the stock back as the order outlived its reservation
+	SagaTriggerReservationExpired = SagaTrigger("reservation_expired")
+)
+
+// SagaCommand is what the saga asks the other services to do after a transition
+type SagaCommand string
+
+const (
+	SagaCommandNone         = SagaCommand("")
+	SagaCommandApproveOrder = SagaCommand("approve_order")
+	// asks the services to undo what they did for the order
+	SagaCommandCancelOrder = SagaCommand("cancel_order")
+)
+
+// sagaTransitions is the state machine of the order saga. The replies of the steps
+// are consumed from different streams, so the payment might be seen before the
+// reservation which triggered it. Hence the payment replies are accepted in reserving as well.
+// A saga being canceled keeps consuming the replies, see Saga.Apply.
+var sagaTransitions = map[SagaState]map[SagaTrigger]SagaState{
+	SagaStateReserving: {
+		SagaTriggerProductReserved:     SagaStatePaying,
+		SagaTriggerErrReservingProduct: SagaStateFailed,
+		SagaTriggerPriceMismatch:       SagaStateRejected,
+		SagaTriggerPaymentSuccessful:   SagaStateCompleted,
+		SagaTriggerPaymentFailed:       SagaStateCompensated,
+		SagaTriggerTimedOut:            SagaStateCompensated,
+		SagaTriggerReservationExpired:  SagaStateCompensated,
+		SagaTriggerCancelRequested:     SagaStateCanceling,
+	},
+	SagaStatePaying: {
+		SagaTriggerPaymentSuccessful:  SagaStateCompleted,
+		SagaTriggerPaymentFailed:      SagaStateCompensated,
+		SagaTriggerTimedOut:           SagaStateCompensated,
+		SagaTriggerReservationExpired: SagaStateCompensated,
+		SagaTriggerCancelRequested:    SagaStateCanceling,
+	},
+	SagaStateCompleted: {
+		SagaTriggerCancelRequested: SagaStateCanceling,
+		// the stock was given back before inventorysvc got the approval,
+		// the order can't be fulfilled and its payment is refunded
+		SagaTriggerReservationExpired: SagaStateCompensated,
+	},
+	SagaStateCompensated: {
+		// inventorysvc confirms undoing the reservation
+		SagaTriggerProductReleased:    SagaStateCompensated,
+		SagaTriggerReservationExpired: SagaStateCompensated,
+		// paymentsvc confirms refunding the order failed after its payment
+		SagaTriggerRefundCompleted: SagaStateCompensated,
+		// the payment made after the order failed (e.g. after the saga timed
+		// out) is refunded by asking for the cancellation again
+		SagaTriggerPaymentSuccessful: SagaStateCompensated,
+	},
+	SagaStateCanceling: {
+		SagaTriggerProductReleased:     SagaStateCanceling,
+		SagaTriggerReservationExpired:  SagaStateCanceling,
+		SagaTriggerRefundCompleted:     SagaStateCanceling,
+		SagaTriggerProductReserved:     SagaStateCanceling,
+		SagaTriggerErrReservingProduct: SagaStateCanceling,
+		SagaTriggerPriceMismatch:       SagaStateCanceling,
+		SagaTriggerPaymentSuccessful:   SagaStateCanceling,
+		SagaTriggerPaymentFailed:       SagaStateCanceling,
+	},
+	SagaStateCanceled: {
+		SagaTriggerProductReleased:     SagaStateCanceled,
+		SagaTriggerReservationExpired:  SagaStateCanceled,
+		SagaTriggerRefundCompleted:     SagaStateCanceled,
+		SagaTriggerProductReserved:     SagaStateCanceled,
+		SagaTriggerErrReservingProduct: SagaStateCanceled,
+		SagaTriggerPriceMismatch:       SagaStateCanceled,
+		SagaTriggerPaymentSuccessful:   SagaStateCanceled,
+		SagaTriggerPaymentFailed:       SagaStateCanceled,
+	},
+}
+
+// Next returns the state the saga moves to on the given trigger.
+// It returns false if the trigger is not allowed in the current state.
+func (s SagaState) Next(t SagaTrigger) (SagaState, bool) {
+	next, ok := sagaTransitions[s][t]
+	return next, ok
+}
+
+// OrderStatus returns the status of the order while its saga is in the state
+func (s SagaState) OrderStatus() OrderStatus {
+	switch s {
+	case SagaStatePaying:
+		return OrderStatusPaymentPending
+	case SagaStateCompleted:
+		return OrderStatusPaid
+	case SagaStateFailed:
+		return OrderStatusProductOutOfStock
+	case SagaStateRejected:
+		return OrderStatusPriceChanged
+	case SagaStateCompensated:
+		return OrderStatusFailed
+	case SagaStateCanceling:
+		return OrderStatusCancelRequested
+	case SagaStateCanceled:
+		return OrderStatusCanceled
+	}
+	return OrderStatusPending
+}
+
+// ErrIllegalSagaTransition is returned when a trigger is not allowed in the current state of the saga
+type ErrIllegalSagaTransition struct {
+	State   SagaState
+	Trigger SagaTrigger
+}
+
+func (e *ErrIllegalSagaTransition) Error() string {
+	return fmt.Sprintf("saga: %s is not allowed in state %s", e.Trigger, e.State)
+}
+
+type Saga struct {
+	ID        uuid.UUID `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	AccntID   uint
+	State     string           `gorm:"index"`
+	History   []SagaTransition `gorm:"foreignKey:SagaID"`
+
+	// Version is bumped on every transition to detect concurrent updates
+	Version int
+
+	// Paid records that paymentsvc deducted the payable
+	Paid bool
+	// the undo steps a canceling saga is waiting for
+	AwaitingRelease bool
+	AwaitingRefund  bool
+}
+
+// Apply moves the saga on the trigger and returns the state it moved from
+// along with the command to be issued.
+//
+// A canceling saga waits for inventorysvc to release the product and, if the
+// order was paid, for paymentsvc to refund before moving to canceled. A reply
+// of a step which completes after the cancellation (e.g. a late reservation or
+// payment) asks for the cancellation again, so that its effect is undone too.
+func (s *Saga) Apply(t SagaTrigger) (SagaState, SagaCommand, error) {
+	from := SagaState(s.State)
+	to, ok := from.Next(t)
+	if !ok {
+		return from, SagaCommandNone, &ErrIllegalSagaTransition{State: from, Trigger: t}
+	}
+	s.State = string(to)
+
+	if t == SagaTriggerPaymentSuccessful {
+		s.Paid = true
+	}
+
+	cmd := SagaCommandNone
+	switch {
+	case to == SagaStateCompleted:
+		cmd = SagaCommandApproveOrder
+	case to == SagaStateCompensated && from != SagaStateCompensated:
+		cmd = SagaCommandCancelOrder
+	case to == SagaStateCompensated && t == SagaTriggerPaymentSuccessful:
+		cmd = SagaCommandCancelOrder
+	case to == SagaStateCanceling && from != SagaStateCanceling:
+		s.AwaitingRelease = true
+		s.AwaitingRefund = s.Paid
+		cmd = SagaCommandCancelOrder
+	case to == SagaStateCanceling || to == SagaStateCanceled:
+		switch t {
+		case SagaTriggerProductReleased, SagaTriggerReservationExpired:
+			s.AwaitingRelease = false
+		case SagaTriggerRefundCompleted:
+			s.AwaitingRefund = false
+		case SagaTriggerProductReserved:
+			s.AwaitingRelease = to == SagaStateCanceling
+			cmd = SagaCommandCancelOrder
+		case SagaTriggerPaymentSuccessful:
+			s.AwaitingRefund = to == SagaStateCanceling
+			cmd = SagaCommandCancelOrder
+		}
+	}
+
+	if SagaState(s.State) == SagaStateCanceling && !s.AwaitingRelease && !s.AwaitingRefund {
+		s.State = string(SagaStateCanceled)
+	}
+	return from, cmd, nil
+}
+
+// SagaTransition records a state change of a saga
+type SagaTransition struct {
+	ID        uint      `gorm:"primaryKey"`
+	SagaID    uuid.UUID `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	From      string
+	To        string
+	Trigger   string
+	RequestID string
+	Reason    string
+}
@@ -0,0 +1,103 @@
+package model
+
+import "testing"
+
+func TestSagaStateNext(t *testing.T) {
+	tests := []struct {
+		from    SagaState
+		trigger SagaTrigger
+		to      SagaState
+		ok      bool
+	}{
+		{SagaStateReserving, SagaTriggerProductReserved, SagaStatePaying, true},
+		{SagaStateReserving, SagaTriggerErrReservingProduct, SagaStateFailed, true},
+		{SagaStateReserving, SagaTriggerPriceMismatch, SagaStateRejected, true},
+		{SagaStateReserving, SagaTriggerPaymentSuccessful, SagaStateCompleted, true},
+		{SagaStatePaying, SagaTriggerPaymentFailed, SagaStateCompensated, true},
+		{SagaStatePaying, SagaTriggerTimedOut, SagaStateCompensated, true},
+		{SagaStatePaying, SagaTriggerReservationExpired, SagaStateCompensated, true},
+		{SagaStateCompleted, SagaTriggerReservationExpired, SagaStateCompensated, true},
+		{SagaStatePaying, SagaTriggerErrReservingProduct, "", false},
+		{SagaStateCompleted, SagaTriggerProductReserved, "", false},
+		{SagaStateCompensated, SagaTriggerPaymentSuccessful, SagaStateCompensated, true},
+		{SagaStateCompensated, SagaTriggerProductReserved, "", false},
+		{SagaStateCompleted, SagaTriggerCancelRequested, SagaStateCanceling, true},
+		{SagaStateFailed, SagaTriggerCancelRequested, "", false},
+		{SagaStateCanceled, SagaTriggerCancelRequested, "", false},
+	}
+
+	for _, tc := range tests {
+		to, ok := tc.from.Next(tc.trigger)
+		if to != tc.to || ok != tc.ok {
+			t.Errorf("%s on %s: want (%q, %v), got (%q, %v)", tc.from, tc.trigger, tc.to, tc.ok, to, ok)
+		}
+	}
+
+}
+
+func TestSagaApplyCancel(t *testing.T) {
+	type step struct {
+		trigger SagaTrigger
+		state   SagaState
+		cmd     SagaCommand
+	}
+	tests := map[string][]step{
+		"before reservation": {
+			{SagaTriggerCancelRequested, SagaStateCanceling, SagaCommandCancelOrder},
+			{SagaTriggerProductReleased, SagaStateCanceled, SagaCommandNone},
+		},
+		"after payment": {
+			{SagaTriggerProductReserved, SagaStatePaying, SagaCommandNone},
+			{SagaTriggerPaymentSuccessful, SagaStateCompleted, SagaCommandApproveOrder},
+			{SagaTriggerCancelRequested, SagaStateCanceling, SagaCommandCancelOrder},
+			{SagaTriggerRefundCompleted, SagaStateCanceling, SagaCommandNone},
+			{SagaTriggerProductReleased, SagaStateCanceled, SagaCommandNone},
+		},
+		"late reservation": {
+			{SagaTriggerCancelRequested, SagaStateCanceling, SagaCommandCancelOrder},
+			{SagaTriggerProductReleased, SagaStateCanceled, SagaCommandNone},
+			// the reservation got undone before it was made, undo it again
+			{SagaTriggerProductReserved, SagaStateCanceled, SagaCommandCancelOrder},
+		},
+		"late payment": {
+			{SagaTriggerProductReserved, SagaStatePaying, SagaCommandNone},
+			{SagaTriggerCancelRequested, SagaStateCanceling, SagaCommandCancelOrder},
+			{SagaTriggerPaymentSuccessful, SagaStateCanceling, SagaCommandCancelOrder},
+			{SagaTriggerProductReleased, SagaStateCanceling, SagaCommandNone},
+			{SagaTriggerRefundCompleted, SagaStateCanceled, SagaCommandNone},
+		},
+		"expired reservation": {
+			{SagaTriggerProductReserved, SagaStatePaying, SagaCommandNone},
+			{SagaTriggerCancelRequested, SagaStateCanceling, SagaCommandCancelOrder},
+			// the releaser gave the stock back before the cancellation did
+			{SagaTriggerReservationExpired, SagaStateCanceled, SagaCommandNone},
+		},
+		"reservation expired after payment": {
+			{SagaTriggerProductReserved, SagaStatePaying, SagaCommandNone},
+			{SagaTriggerPaymentSuccessful, SagaStateCompleted, SagaCommandApproveOrder},
+			// the stock was given back before the approval, the payment is refunded
+			{SagaTriggerReservationExpired, SagaStateCompensated, SagaCommandCancelOrder},
+			{SagaTriggerRefundCompleted, SagaStateCompensated, SagaCommandNone},
+		},
+		"payment after timeout": {
+			{SagaTriggerProductReserved, SagaStatePaying, SagaCommandNone},
+			{SagaTriggerTimedOut, SagaStateCompensated, SagaCommandCancelOrder},
+			// paymentsvc deducted the payable after the cancellation, it is refunded
+			{SagaTriggerPaymentSuccessful, SagaStateCompensated, SagaCommandCancelOrder},
+			{SagaTriggerRefundCompleted, SagaStateCompensated, SagaCommandNone},
+		},
+	}
+
+	for name, steps := range tests {
+		s := &Saga{State: string(SagaStateReserving)}
+		for _, st := range steps {
+			_, cmd, err := s.Apply(st.trigger)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", name, st.trigger, err)
+			}
+			if SagaState(s.State) != st.state || cmd != st.cmd {
+				t.Errorf("%s: %s: want (%s, %q), got (%s, %q)", name, st.trigger, st.state, st.cmd, s.State, cmd)
+			}
+		}
+	}
+}
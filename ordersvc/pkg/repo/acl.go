@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+func (b *basicOrderRepo) GrantACL(ctx context.Context, e model.ACLEntry) error {
+	// a redelivered policy event grants the same entry again
+	return b.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&e).Error
+}
+
+func (b *basicOrderRepo) RevokeACL(ctx context.Context, e model.ACLEntry) error {
+	return b.db.WithContext(ctx).Where(
+		"subject = ? AND resource_type = ? AND resource_id = ? AND action = ?",
+		e.Subject, e.ResourceType, e.ResourceID, e.Action,
+	).Delete(&model.ACLEntry{}).Error
+}
+
+// grantedTo scopes a query of the resources of rtype to the ones the subject may get
+func grantedTo(db *gorm.DB, sub, rtype, idColumn string) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		granted := db.Model(&model.ACLEntry{}).Select("resource_id").Where(
+			"subject = ? AND resource_type = ? AND action IN ?", sub, rtype, []string{"get", "*"})
+		return tx.Where(idColumn+" IN (?)", granted)
+	}
+}
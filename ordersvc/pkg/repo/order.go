@@ -2,106 +2,236 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/paging"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
 )
 
 // OrderRepository defines all the DB operations that the service supports
 type OrderRepository interface {
-	CreateOrder(ctx context.Context, aid uint, qty int, product_id uuid.UUID, status model.OrderStatus) (uuid.UUID, error)
-	ListOrder(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Order, error)
-	ListOrderByIDs(ctx context.Context, oids []uuid.UUID, qp *dto.BasicQueryParam) ([]model.Order, error)
+	CreateOrder(ctx context.Context, aid uint, lines []model.OrderLine, status model.OrderStatus, cause model.StatusChangeCause) (uuid.UUID, error)
+	ListOrder(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Order, *dto.Page, error)
+	// ListOrderGrantedTo lists the orders the subject may get as per the ACL projection
+	ListOrderGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) ([]model.Order, *dto.Page, error)
 	GetOrderByID(ctx context.Context, oid uuid.UUID) (model.Order, error)
-	UpdateOrderStatus(ctx context.Context, oid uuid.UUID, status model.OrderStatus) error
+	UpdateOrderStatus(ctx context.Context, oid uuid.UUID, status model.OrderStatus, cause model.StatusChangeCause) error
+	// ListStaleOrders returns at most limit orders which are in the status since before the given time
+	ListStaleOrders(ctx context.Context, status model.OrderStatus, before time.Time, limit int) ([]model.Order, error)
+	// ListStatusChanges returns at most limit status changes of the orders of the account logged after the given one
+	ListStatusChanges(ctx context.Context, aid uint, afterID uint, limit int) ([]model.OrderStatusChange, error)
+	// ListLateStatusChanges returns the status changes of the orders of the account before the given one
+	// which were logged since the given time, i.e. the ones which may have been committed after it
+	ListLateStatusChanges(ctx context.Context, aid uint, beforeID uint, since time.Time) ([]model.OrderStatusChange, error)
+	// ListOrderStatusHistory returns the status changes of the order in the order they happened
+	ListOrderStatusHistory(ctx context.Context, oid uuid.UUID) ([]model.OrderStatusChange, error)
+	// LastStatusChangeID returns the ID of the latest status change of any order
+	LastStatusChangeID(ctx context.Context) (uint, error)
+	// GrantACL and RevokeACL keep the ACL projection in step with the policy events
+	GrantACL(ctx context.Context, e model.ACLEntry) error
+	RevokeACL(ctx context.Context, e model.ACLEntry) error
 }
 
 type basicOrderRepo struct {
 	db *gorm.DB
 }
 
-func NewBasicOrderRepo(db *gorm.DB) OrderRepository {
+// NewBasicOrderRepo migrates the tables of the orders and returns the repo.
+// It fails if any migration fails, as the service can't run on a partial schema.
+func NewBasicOrderRepo(db *gorm.DB) (OrderRepository, error) {
 	if db == nil {
-		return nil
+		return nil, errors.New("repo: db not provided")
 	}
 
 	// auto-migrate tables
-	db.AutoMigrate(&model.Order{})
+	err := db.AutoMigrate(&model.Order{}, &model.OrderLine{}, &model.OrderStatusChange{}, &model.ACLEntry{})
+	if err != nil {
+		return nil, fmt.Errorf("repo: error migrating order tables [%v]", err)
+	}
+	if err := migrateOrderLines(db); err != nil {
+		return nil, fmt.Errorf("repo: error migrating order lines [%v]", err)
+	}
 
 	return &basicOrderRepo{
 		db: db,
-	}
+	}, nil
 }
 
-func orderBy(orderBy []string) func(tx *gorm.DB) *gorm.DB {
-	return func(tx *gorm.DB) *gorm.DB {
-		for _, o := range orderBy {
-			tx = tx.Order(o)
-		}
-		return tx
+// migrateOrderLines moves the product and quantity of the orders created
+// before the orders had lines to a line of their own
+func migrateOrderLines(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasColumn(&model.Order{}, "product_id") {
+		return nil
 	}
-}
-
-func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		if page == 0 {
-			page = 1
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`insert into order_lines (order_id, product_id, qty)
+			select o.id, o.product_id, o.qty from orders o
+			where not exists (select 1 from order_lines l where l.order_id = o.id)`).Error
+		if err != nil {
+			return err
 		}
-
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
+		if err := tx.Migrator().DropColumn(&model.Order{}, "product_id"); err != nil {
+			return err
 		}
+		return tx.Migrator().DropColumn(&model.Order{}, "qty")
+	})
+}
+
+// orderFilters are the fields which the orders can be filtered by
+var orderFilters = filter.Fields{
+	"id":         {Column: "id", Kind: filter.UUID},
+	"status":     {Column: "status", Kind: filter.String},
+	"created_at": {Column: "created_at", Kind: filter.Time},
+	"updated_at": {Column: "updated_at", Kind: filter.Time},
+}
 
-		offset := (page - 1) * pageSize
-		return db.Offset(offset).Limit(pageSize)
+// filterBy applies the filters of the query params on the allowed fields
+func filterBy(fields filter.Fields, qp *dto.BasicQueryParam) func(tx *gorm.DB) *gorm.DB {
+	if qp == nil {
+		return func(tx *gorm.DB) *gorm.DB { return tx }
 	}
+	return fields.Scope(qp.Filter.Conds)
 }
 
-func (b *basicOrderRepo) CreateOrder(ctx context.Context, aid uint, qty int, product_id uuid.UUID, status model.OrderStatus) (uuid.UUID, error) {
+func (b *basicOrderRepo) CreateOrder(ctx context.Context, aid uint, lines []model.OrderLine, status model.OrderStatus, cause model.StatusChangeCause) (oid uuid.UUID, err error) {
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		oid, err = createOrder(tx, aid, lines, status, cause)
+		return err
+	})
+	return oid, err
+}
+
+// createOrder creates the order along with its lines and logs its first status in the given transaction
+func createOrder(tx *gorm.DB, aid uint, lines []model.OrderLine, status model.OrderStatus, cause model.StatusChangeCause) (uuid.UUID, error) {
 	orderID := uuid.New()
-	orderObj := model.Order{ID: orderID, AccntID: aid, ProductID: product_id, Qty: qty, Status: string(status)}
-	err := b.db.Create(&orderObj).Error
-	return orderObj.ID, err
-}
-
-func (b *basicOrderRepo) ListOrder(ctx context.Context, qp *dto.BasicQueryParam) (orders []model.Order, err error) {
-	if qp != nil {
-		err = b.db.Scopes(
-			orderBy(qp.Filter.OrederBy),
-			Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
-		).Find(&orders).Error
-	} else {
-		err = b.db.Find(&orders).Error
+	orderObj := model.Order{ID: orderID, AccntID: aid, Lines: lines, Status: string(status), Total: model.TotalOf(lines)}
+	if err := tx.Create(&orderObj).Error; err != nil {
+		return uuid.Nil, err
 	}
+	err := tx.Create(&model.OrderStatusChange{
+		OrderID:           orderID,
+		AccntID:           aid,
+		To:                string(status),
+		StatusChangeCause: cause,
+	}).Error
+	return orderID, err
+}
+
+// orders queries the filtered orders along with their lines
+func (b *basicOrderRepo) orders(qp *dto.BasicQueryParam) *gorm.DB {
+	return b.db.Model(&model.Order{}).Preload("Lines", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Scopes(filterBy(orderFilters, qp))
+}
+
+func (b *basicOrderRepo) ListOrder(ctx context.Context, qp *dto.BasicQueryParam) (orders []model.Order, page *dto.Page, err error) {
+	if qp == nil {
+		err = b.orders(qp).Find(&orders).Error
+		return
+	}
+	page, err = paging.Find(b.orders(qp), qp.PageQuery("id"), &orders)
 	return
 }
 
-func (b *basicOrderRepo) ListOrderByIDs(ctx context.Context, oids []uuid.UUID, qp *dto.BasicQueryParam) ([]model.Order, error) {
-	var orders []model.Order
-	values := []string{}
-	for _, oid := range oids {
-		values = append(values, fmt.Sprintf("('%s')", oid.String()))
+func (b *basicOrderRepo) ListOrderGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) (orders []model.Order, page *dto.Page, err error) {
+	tx := b.orders(qp).Scopes(grantedTo(b.db, sub, "orders", "id"))
+	if qp == nil {
+		err = tx.Find(&orders).Error
+		return
 	}
-	q := fmt.Sprintf("select * from orders o where o.id = any ( values %s )", strings.Join(values, ","))
-	err := b.db.Debug().Raw(q, values).Scan(&orders).Error
-	return orders, err
+	page, err = paging.Find(tx, qp.PageQuery("id"), &orders)
+	return
 }
 
 func (b *basicOrderRepo) GetOrderByID(ctx context.Context, oid uuid.UUID) (model.Order, error) {
-	orderObj := model.Order{ID: oid}
-	err := b.db.Find(orderObj).Error
+	var orderObj model.Order
+	err := b.db.WithContext(ctx).Preload("Lines").First(&orderObj, "id = ?", oid).Error
 	return orderObj, err
 }
 
-func (b *basicOrderRepo) UpdateOrderStatus(ctx context.Context, oid uuid.UUID, status model.OrderStatus) error {
-	orderObj := model.Order{ID: oid}
-	return b.db.Model(orderObj).UpdateColumn("status", string(status)).Error
+func (b *basicOrderRepo) ListStaleOrders(ctx context.Context, status model.OrderStatus, before time.Time, limit int) (orders []model.Order, err error) {
+	err = b.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", string(status), before).
+		Order("updated_at").Limit(limit).
+		Find(&orders).Error
+	return
+}
+
+func (b *basicOrderRepo) ListStatusChanges(ctx context.Context, aid uint, afterID uint, limit int) (changes []model.OrderStatusChange, err error) {
+	err = b.db.WithContext(ctx).
+		Where("accnt_id = ? AND id > ?", aid, afterID).
+		Order("id").Limit(limit).Find(&changes).Error
+	return
+}
+
+func (b *basicOrderRepo) ListLateStatusChanges(ctx context.Context, aid uint, beforeID uint, since time.Time) (changes []model.OrderStatusChange, err error) {
+	err = b.db.WithContext(ctx).
+		Where("accnt_id = ? AND id < ? AND created_at >= ?", aid, beforeID, since).
+		Order("id").Find(&changes).Error
+	return
+}
+
+func (b *basicOrderRepo) LastStatusChangeID(ctx context.Context) (uint, error) {
+	var lastID uint
+	err := b.db.WithContext(ctx).Model(&model.OrderStatusChange{}).
+		Select("COALESCE(MAX(id), 0)").Scan(&lastID).Error
+	return lastID, err
+}
+
+func (b *basicOrderRepo) ListOrderStatusHistory(ctx context.Context, oid uuid.UUID) (changes []model.OrderStatusChange, err error) {
+	err = b.db.WithContext(ctx).Where("order_id = ?", oid).Order("id").Find(&changes).Error
+	return
+}
+
+func (b *basicOrderRepo) UpdateOrderStatus(ctx context.Context, oid uuid.UUID, status model.OrderStatus, cause model.StatusChangeCause) error {
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateOrderStatus(tx, oid, status, cause)
+	})
+}
+
+// updateOrderStatus sets the status only if the order can move to it from its
+// current status and logs the change. Setting the current status again is a no-op,
+// so that the redelivered events don't fail. Otherwise it returns ErrIllegalStatusTransition.
+func updateOrderStatus(tx *gorm.DB, oid uuid.UUID, status model.OrderStatus, cause model.StatusChangeCause) error {
+	var orderObj model.Order
+	if err := tx.Select("status", "accnt_id").First(&orderObj, "id = ?", oid).Error; err != nil {
+		return err
+	}
+	from := model.OrderStatus(orderObj.Status)
+	if from == status {
+		return nil
+	}
+	if !from.CanTransitTo(status) {
+		return &model.ErrIllegalStatusTransition{From: from, To: status}
+	}
+
+	// updated_at tells for how long the order is in its status
+	res := tx.Model(&model.Order{}).
+		Where("id = ? AND status = ?", oid, string(from)).
+		UpdateColumns(map[string]interface{}{"status": string(status), "updated_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// the status changed meanwhile, so check the transition again. This ends
+		// as there are no cycles in the graph of the status transitions.
+		return updateOrderStatus(tx, oid, status, cause)
+	}
+
+	return tx.Create(&model.OrderStatusChange{
+		OrderID: oid,
+		AccntID: orderObj.AccntID,
+		From:    string(from),
+		To:      string(status),
+
+		StatusChangeCause: cause,
+	}).Error
 }
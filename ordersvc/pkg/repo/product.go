@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+// ProductRepository persists the projection of the inventory products
+type ProductRepository interface {
+	// UpsertProduct creates the product or overwrites the one with the same ID,
+	// unless the stored product is at the same or a later version
+	UpsertProduct(ctx context.Context, p model.Product) error
+	// DeleteProduct marks the product deleted as of the version
+	DeleteProduct(ctx context.Context, pid uuid.UUID, version int) error
+	// ListProductByIDs returns the known products among the given IDs
+	ListProductByIDs(ctx context.Context, pids []uuid.UUID) ([]model.Product, error)
+}
+
+type basicProductRepo struct {
+	db *gorm.DB
+}
+
+func NewBasicProductRepo(db *gorm.DB) ProductRepository {
+	if db == nil {
+		return nil
+	}
+
+	// auto-migrate tables
+	db.AutoMigrate(&model.Product{})
+
+	return &basicProductRepo{
+		db: db,
+	}
+}
+
+// isNewerVersion guards the upserts of the products against the stale events
+var isNewerVersion = clause.Where{Exprs: []clause.Expression{
+	clause.Expr{SQL: "products.version < excluded.version"},
+}}
+
+func (b *basicProductRepo) UpsertProduct(ctx context.Context, p model.Product) error {
+	return b.db.WithContext(ctx).Clauses(clause.OnConflict{
+		UpdateAll: true,
+		Where:     isNewerVersion,
+	}).Create(&p).Error
+}
+
+func (b *basicProductRepo) DeleteProduct(ctx context.Context, pid uuid.UUID, version int) error {
+	// the product might not be known yet if its events are seen out of order
+	p := model.Product{ID: pid, Version: version, Deleted: true}
+	return b.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "deleted", "updated_at"}),
+		Where:     isNewerVersion,
+	}).Create(&p).Error
+}
+
+func (b *basicProductRepo) ListProductByIDs(ctx context.Context, pids []uuid.UUID) (products []model.Product, err error) {
+	err = b.db.WithContext(ctx).Where("id IN ?", pids).Find(&products).Error
+	return
+}
@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+)
+
+// ErrSagaConflict is returned when the saga got updated by another
+// handler meanwhile. The transition can be retried.
+var ErrSagaConflict = errors.New("saga: concurrent update")
+
+// SagaRepository persists the state machines of the order sagas
+type SagaRepository interface {
+	StartSaga(ctx context.Context, oid uuid.UUID, aid uint, reqID string) error
+	// CreateOrder creates a pending order and starts its saga in a single transaction,
+	// so that no order is left behind without a saga to coordinate it
+	CreateOrder(ctx context.Context, aid uint, lines []model.OrderLine, cause model.StatusChangeCause, reqID string) (uuid.UUID, error)
+	GetSaga(ctx context.Context, oid uuid.UUID) (model.Saga, error)
+	// TransitSaga moves the saga on the given trigger and sets the order status accordingly.
+	// It returns the saga after the transition, the state it moved from and the command to be issued.
+	// The cause is recorded along with both the saga transition and the order status change.
+	TransitSaga(ctx context.Context, oid uuid.UUID, t model.SagaTrigger, cause model.StatusChangeCause) (model.Saga, model.SagaState, model.SagaCommand, error)
+}
+
+type basicSagaRepo struct {
+	db *gorm.DB
+}
+
+func NewBasicSagaRepo(db *gorm.DB) SagaRepository {
+	if db == nil {
+		return nil
+	}
+
+	// auto-migrate tables
+	db.AutoMigrate(&model.Saga{}, &model.SagaTransition{})
+
+	return &basicSagaRepo{
+		db: db,
+	}
+}
+
+func (b *basicSagaRepo) StartSaga(ctx context.Context, oid uuid.UUID, aid uint, reqID string) error {
+	return startSaga(b.db.WithContext(ctx), oid, aid, reqID)
+}
+
+func (b *basicSagaRepo) CreateOrder(ctx context.Context, aid uint, lines []model.OrderLine,
+	cause model.StatusChangeCause, reqID string) (oid uuid.UUID, err error) {
+
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		oid, err = createOrder(tx, aid, lines, model.OrderStatusPending, cause)
+		if err != nil {
+			return err
+		}
+		return startSaga(tx, oid, aid, reqID)
+	})
+	return oid, err
+}
+
+// startSaga creates the saga of the order in the reserving state
+func startSaga(tx *gorm.DB, oid uuid.UUID, aid uint, reqID string) error {
+	sagaObj := model.Saga{
+		ID:      oid,
+		AccntID: aid,
+		State:   string(model.SagaStateReserving),
+		History: []model.SagaTransition{{
+			To:        string(model.SagaStateReserving),
+			Trigger:   string(model.SagaTriggerStarted),
+			RequestID: reqID,
+		}},
+	}
+	return tx.Create(&sagaObj).Error
+}
+
+func (b *basicSagaRepo) GetSaga(ctx context.Context, oid uuid.UUID) (model.Saga, error) {
+	var sagaObj model.Saga
+	err := b.db.WithContext(ctx).
+		Preload("History", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		First(&sagaObj, "id = ?", oid).Error
+	return sagaObj, err
+}
+
+func (b *basicSagaRepo) TransitSaga(ctx context.Context, oid uuid.UUID, t model.SagaTrigger,
+	cause model.StatusChangeCause) (model.Saga, model.SagaState, model.SagaCommand, error) {
+
+	var sagaObj model.Saga
+	var from model.SagaState
+	var cmd model.SagaCommand
+
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&sagaObj, "id = ?", oid).Error; err != nil {
+			return err
+		}
+		version := sagaObj.Version
+
+		var err error
+		from, cmd, err = sagaObj.Apply(t)
+		if err != nil {
+			return err
+		}
+		sagaObj.Version++
+
+		// optimistic lock, so that concurrent handlers can't both move the saga
+		res := tx.Model(&model.Saga{}).
+			Where("id = ? AND version = ?", oid, version).
+			Updates(map[string]interface{}{
+				"state":            sagaObj.State,
+				"version":          sagaObj.Version,
+				"paid":             sagaObj.Paid,
+				"awaiting_release": sagaObj.AwaitingRelease,
+				"awaiting_refund":  sagaObj.AwaitingRefund,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrSagaConflict
+		}
+
+		err = tx.Create(&model.SagaTransition{
+			SagaID:    oid,
+			From:      string(from),
+			To:        sagaObj.State,
+			Trigger:   string(t),
+			RequestID: cause.RequestID,
+			Reason:    cause.Reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return updateOrderStatus(tx, oid, model.SagaState(sagaObj.State).OrderStatus(), cause)
+	})
+	return sagaObj, from, cmd, err
+}
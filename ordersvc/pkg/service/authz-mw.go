@@ -6,6 +6,7 @@ import (
 
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
+	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	svcpe "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/policy-enforcer"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/google/uuid"
@@ -30,47 +31,137 @@ func (m *authzMW) HandlePolicyUpdatedEvent(ctx context.Context, t, sub, rtype, r
 	return m.next.HandlePolicyUpdatedEvent(ctx, t, sub, rtype, rid, act)
 }
 
+func (m *authzMW) BackfillACL(ctx context.Context) (int, error) {
+	return m.next.BackfillACL(ctx)
+}
+
 func (m *authzMW) HandleErrReservingProductEvent(ctx context.Context, oid uuid.UUID) error {
 	return m.next.HandleErrReservingProductEvent(ctx, oid)
 }
 
+func (m *authzMW) HandlePriceMismatchEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventPriceMismatchLine) error {
+	return m.next.HandlePriceMismatchEvent(ctx, oid, lines)
+}
+
 func (m *authzMW) HandleProductReservedEvent(ctx context.Context, oid uuid.UUID) error {
 	return m.next.HandleProductReservedEvent(ctx, oid)
 }
 
+func (m *authzMW) HandleReservationReleasedEvent(ctx context.Context, oid uuid.UUID) error {
+	return m.next.HandleReservationReleasedEvent(ctx, oid)
+}
+
+func (m *authzMW) HandleReservationExpiredEvent(ctx context.Context, oid uuid.UUID) error {
+	return m.next.HandleReservationExpiredEvent(ctx, oid)
+}
+
+func (m *authzMW) HandleRefundCompletedEvent(ctx context.Context, oid uuid.UUID) error {
+	return m.next.HandleRefundCompletedEvent(ctx, oid)
+}
+
+func (m *authzMW) HandleProductCreatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error {
+	return m.next.HandleProductCreatedEvent(ctx, pid, mid, name, price, qty, version)
+}
+
+func (m *authzMW) HandleProductUpdatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error {
+	return m.next.HandleProductUpdatedEvent(ctx, pid, mid, name, price, qty, version)
+}
+
+func (m *authzMW) HandleProductDeletedEvent(ctx context.Context, pid uuid.UUID, version int) error {
+	return m.next.HandleProductDeletedEvent(ctx, pid, version)
+}
+
 func (m *authzMW) HandlePaymentEvent(ctx context.Context, oid uuid.UUID, aid uint, status string) error {
 	return m.next.HandlePaymentEvent(ctx, oid, aid, status)
 }
 
-func (m *authzMW) CreateOrder(ctx context.Context, pid uuid.UUID, qty int) (uuid.UUID, error) {
+func (m *authzMW) CreateOrder(ctx context.Context, lines []dto.OrderLine) (uuid.UUID, error) {
 	claim := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "orders", "post", "*")
 	if !m.pe.Enforce(ctx, reqPolicy, nil) {
 		return uuid.Nil, ce.ErrInsufficientPerm
 	}
-	return m.next.CreateOrder(ctx, pid, qty)
+	return m.next.CreateOrder(ctx, lines)
+}
+
+func (m *authzMW) GetOrder(ctx context.Context, oid uuid.UUID) dto.GetOrderResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.GetOrderResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "orders", "get", oid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.GetOrderResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.GetOrder(ctx, oid)
+}
+
+func (m *authzMW) GetOrderHistory(ctx context.Context, oid uuid.UUID) dto.GetOrderHistoryResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.GetOrderHistoryResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "orders", "get", oid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.GetOrderHistoryResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.GetOrderHistory(ctx, oid)
 }
 
-func (m *authzMW) ListOrder(ctx context.Context, oids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListOrderResponse {
+func (m *authzMW) CancelOrder(ctx context.Context, oid uuid.UUID) dto.CancelOrderResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.CancelOrderResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "orders", "cancel", oid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.CancelOrderResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.CancelOrder(ctx, oid)
+}
+
+func (m *authzMW) StreamOrderStatus(ctx context.Context, req dto.StreamOrderStatusRequest) dto.StreamOrderStatusResponse {
+	// the stream has the orders of the caller only
+	if _, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim); !ok {
+		return dto.StreamOrderStatusResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	return m.next.StreamOrderStatus(ctx, req)
+}
+
+func (m *authzMW) SweepStaleOrders(ctx context.Context) (int, error) {
+	return m.next.SweepStaleOrders(ctx)
+}
+
+func (m *authzMW) ListOrder(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListOrderResponse {
 	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	if !ok {
 		return dto.ListOrderResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
-	rids := m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "orders", "*")
-	rids = append(rids, m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "orders", "get")...)
-	if len(rids) <= 0 {
+	// the callers list for themselves only
+	if sub != fmt.Sprint(claim.AccntID) {
 		return dto.ListOrderResponse{Err: ce.ErrInsufficientPerm}
 	}
-	for _, rid := range rids {
-		if rid == "*" {
-			return m.next.ListOrder(ctx, []uuid.UUID{}, qp)
-		}
-		id, err := uuid.Parse(rid)
-		if err != nil {
-			continue
-		}
-		oids = append(oids, id)
+	// who may get any order lists all of them, the others only the ones granted to them
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", sub, "orders", "get", "*")
+	if m.pe.Enforce(ctx, reqPolicy, nil) {
+		return m.next.ListOrder(ctx, "", qp)
 	}
+	return m.next.ListOrder(ctx, sub, qp)
+}
+
+func (m *authzMW) HandleSagaTimedOutEvent(ctx context.Context, sid uuid.UUID, state string) error {
+	return m.next.HandleSagaTimedOutEvent(ctx, sid, state)
+}
 
-	return m.next.ListOrder(ctx, oids, qp)
+func (m *authzMW) GetSaga(ctx context.Context, oid uuid.UUID) dto.GetSagaResponse {
+	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+	if !ok {
+		return dto.GetSagaResponse{Err: kitjwt.ErrTokenContextMissing}
+	}
+	// the saga of an order is visible to whom the order is
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", claim.AccntID, "orders", "get", oid)
+	if !m.pe.Enforce(ctx, reqPolicy, nil) {
+		return dto.GetSagaResponse{Err: ce.ErrInsufficientPerm}
+	}
+	return m.next.GetSaga(ctx, oid)
 }
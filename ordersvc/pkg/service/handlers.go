@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
@@ -39,55 +40,99 @@ func (svc *basicOrderService) HandleAccountCreatedEvent(ctx context.Context, acc
 }
 
 func (svc *basicOrderService) HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error {
+	if err := svc.projectPolicy(ctx, method, sub, rtype, rid, act); err != nil {
+		return err
+	}
 	return svc.ps.UpdatePolicy(method, sub, rtype, rid, act)
 }
 
+// projectPolicy keeps the ACL projection in step with the policies on a single order.
+// The wildcard policies are left to the policy enforcer.
+func (svc *basicOrderService) projectPolicy(ctx context.Context, method, sub, rtype, rid, act string) error {
+	oid, err := uuid.Parse(rid)
+	if rtype != "orders" || err != nil {
+		return nil
+	}
+	e := model.ACLEntry{Subject: sub, ResourceType: rtype, ResourceID: oid, Action: act}
+	if method == "delete" {
+		return svc.repo.RevokeACL(ctx, e)
+	}
+	return svc.repo.GrantACL(ctx, e)
+}
+
+// BackfillACL grants the policies which authzsvc holds on the resources of the service,
+// as the policy updates made before the projection was deployed are not in the streams
+// anymore. It is to be called before consuming the policy updates, so that the ones
+// made meanwhile apply on top of it. A policy already projected is granted again as a no-op.
+func (svc *basicOrderService) BackfillACL(ctx context.Context) (int, error) {
+	policies, err := svc.ps.ListPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range policies {
+		err := svc.projectPolicy(ctx, "put", p.Sub(), p.ResourceType(), p.ResourceID(), p.Action())
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(policies), nil
+}
+
 func (svc *basicOrderService) HandleErrReservingProductEvent(ctx context.Context, oid uuid.UUID) error {
-	return svc.repo.UpdateOrderStatus(ctx, oid, model.OrderStatusProductOutOfStock)
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerErrReservingProduct, "product could not be reserved")
+}
+
+// HandlePriceMismatchEvent fails the order whose quoted prices moved beyond the
+// tolerance of inventorysvc before its products could be reserved
+func (svc *basicOrderService) HandlePriceMismatchEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventPriceMismatchLine) error {
+	reason := "price changed:"
+	for _, l := range lines {
+		reason += fmt.Sprintf(" %v quoted at %.2f now at %.2f;", l.ProductID, l.QuotedPrice, l.Price)
+	}
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerPriceMismatch, strings.TrimSuffix(reason, ";"))
 }
 
 func (svc *basicOrderService) HandleProductReservedEvent(ctx context.Context, oid uuid.UUID) error {
-	return svc.repo.UpdateOrderStatus(ctx, oid, model.OrderStatusPaymentPending)
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerProductReserved, "")
 }
 
-func (svc *basicOrderService) HandlePaymentEvent(ctx context.Context, oid uuid.UUID, aid uint, status string) error {
-	eventPublisher := svcevent.NewEventPublisher()
-	var eventErr error
+func (svc *basicOrderService) HandleReservationReleasedEvent(ctx context.Context, oid uuid.UUID) error {
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerProductReleased, "")
+}
 
-	if status == "payment_successful" {
-		err := svc.repo.UpdateOrderStatus(ctx, oid, model.OrderStatusPaid)
-		if err != nil {
-			svc.cl.Error(ctx, err)
-			return err
-		}
-		eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
-			ctx, svcevent.EventOrderApproved,
-			svcevent.EventOrderApprovedPayload{
-				OID:     oid,
-				AccntID: aid,
-			},
-		))
-	} else {
-		err := svc.repo.UpdateOrderStatus(ctx, oid, model.OrderStatusFailed)
-		if err != nil {
-			svc.cl.Error(ctx, err)
-			return err
-		}
-		eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
-			ctx, svcevent.EventOrderCanceled,
-			svcevent.EventOrderCanceledPayload{
-				OID:     oid,
-				AccntID: aid,
-			},
-		))
-	}
+// HandleReservationExpiredEvent fails the order whose reservation inventorysvc released
+// as it was neither approved nor canceled in time, which refunds it if it got paid meanwhile
+func (svc *basicOrderService) HandleReservationExpiredEvent(ctx context.Context, oid uuid.UUID) error {
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerReservationExpired, "reservation expired")
+}
 
-	svc.cl.LogIfError(ctx, eventErr)
-	eventErr = eventPublisher.Publish(svc.nc)
-	svc.cl.LogIfError(ctx, eventErr)
-	if eventErr == nil {
-		svc.cl.Debug(ctx, fmt.Sprintf("published events: %v", eventPublisher.GetEventNames()))
-	}
+func (svc *basicOrderService) HandleRefundCompletedEvent(ctx context.Context, oid uuid.UUID) error {
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerRefundCompleted, "")
+}
 
-	return nil
+func (svc *basicOrderService) HandleProductCreatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error {
+	return svc.productRepo.UpsertProduct(ctx, model.Product{
+		ID:         pid,
+		MerchantID: mid,
+		Name:       name,
+		Price:      price,
+		Qty:        qty,
+		Version:    version,
+	})
+}
+
+func (svc *basicOrderService) HandleProductUpdatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error {
+	// an update carries the whole product, so it is applied the same as its creation
+	return svc.HandleProductCreatedEvent(ctx, pid, mid, name, price, qty, version)
+}
+
+func (svc *basicOrderService) HandleProductDeletedEvent(ctx context.Context, pid uuid.UUID, version int) error {
+	return svc.productRepo.DeleteProduct(ctx, pid, version)
+}
+
+func (svc *basicOrderService) HandlePaymentEvent(ctx context.Context, oid uuid.UUID, aid uint, status string) error {
+	if status == "payment_successful" {
+		return svc.advanceSaga(ctx, oid, model.SagaTriggerPaymentSuccessful, "")
+	}
+	return svc.advanceSaga(ctx, oid, model.SagaTriggerPaymentFailed, "payment failed")
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	svcpe "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/repo"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
@@ -20,12 +23,32 @@ type IOrderService interface {
 	// Handlers of the events
 	HandleAccountCreatedEvent(ctx context.Context, accntID uint, role string) error
 	HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error
+	// BackfillACL projects the policies granted before the ACL projection was deployed,
+	// see README.md. It returns the number of policies it went through.
+	BackfillACL(ctx context.Context) (int, error)
 	HandleErrReservingProductEvent(ctx context.Context, oid uuid.UUID) error
+	HandlePriceMismatchEvent(ctx context.Context, oid uuid.UUID, lines []svcevent.EventPriceMismatchLine) error
 	HandleProductReservedEvent(ctx context.Context, oid uuid.UUID) error
 	HandlePaymentEvent(ctx context.Context, oid uuid.UUID, aid uint, status string) error
+	HandleSagaTimedOutEvent(ctx context.Context, sid uuid.UUID, state string) error
+	HandleReservationReleasedEvent(ctx context.Context, oid uuid.UUID) error
+	HandleReservationExpiredEvent(ctx context.Context, oid uuid.UUID) error
+	HandleRefundCompletedEvent(ctx context.Context, oid uuid.UUID) error
+	HandleProductCreatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error
+	HandleProductUpdatedEvent(ctx context.Context, pid, mid uuid.UUID, name string, price float32, qty, version int) error
+	HandleProductDeletedEvent(ctx context.Context, pid uuid.UUID, version int) error
 
-	ListOrder(ctx context.Context, oids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListOrderResponse
-	CreateOrder(ctx context.Context, pid uuid.UUID, qty int) (uuid.UUID, error)
+	// ListOrder lists the orders granted to the subject, all of them if sub is empty
+	ListOrder(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListOrderResponse
+	GetOrder(ctx context.Context, oid uuid.UUID) dto.GetOrderResponse
+	GetOrderHistory(ctx context.Context, oid uuid.UUID) dto.GetOrderHistoryResponse
+	CreateOrder(ctx context.Context, lines []dto.OrderLine) (uuid.UUID, error)
+	CancelOrder(ctx context.Context, oid uuid.UUID) dto.CancelOrderResponse
+	StreamOrderStatus(ctx context.Context, req dto.StreamOrderStatusRequest) dto.StreamOrderStatusResponse
+	SweepStaleOrders(ctx context.Context) (int, error)
+
+	// saga service methods
+	GetSaga(ctx context.Context, oid uuid.UUID) dto.GetSagaResponse
 }
 
 type basicOrderService struct {
@@ -33,11 +56,31 @@ type basicOrderService struct {
 	repo repo.OrderRepository
 	nc   *nats.EncodedConn
 	ps   svcpe.PolicyStorage
+
+	// projection of the inventory products
+	productRepo repo.ProductRepository
+
+	// schedules the events which are to be fired later
+	scheduler *svcevent.Scheduler
+
+	sagaRepo     repo.SagaRepository
+	sagaTimeouts map[model.SagaState]time.Duration
+
+	// statuses in which the customer can cancel an order
+	cancelableStatuses []model.OrderStatus
+
+	// for how long an order can be in a status before the sweeper fails it
+	sweepThresholds map[model.OrderStatus]time.Duration
+
+	// how often the status streams look for the new status changes
+	streamPollInterval time.Duration
+	// for how long the status streams look back for the changes committed late
+	streamCommitWindow time.Duration
 }
 
 // NewBasicOrderService returns a naive, stateless implementation of OrderService
 func NewBasicOrderService() *basicOrderService {
-	return &basicOrderService{}
+	return &basicOrderService{streamPollInterval: time.Second, streamCommitWindow: 10 * time.Second}
 }
 
 type SvcConf func(*basicOrderService) error
@@ -69,6 +112,112 @@ func WithPolicyStorage(ps svcpe.PolicyStorage) SvcConf {
 	}
 }
 
+func WithScheduler(s *svcevent.Scheduler) SvcConf {
+	return func(svc *basicOrderService) error {
+		if s == nil {
+			return errors.New("event scheduler not provided")
+		}
+		svc.scheduler = s
+		return nil
+	}
+}
+
+func WithProductRepo(r repo.ProductRepository) SvcConf {
+	return func(svc *basicOrderService) error {
+		if r == nil {
+			return errors.New("product repo not provided")
+		}
+		svc.productRepo = r
+		return nil
+	}
+}
+
+func WithSagaRepo(r repo.SagaRepository) SvcConf {
+	return func(svc *basicOrderService) error {
+		if r == nil {
+			return errors.New("saga repo not provided")
+		}
+		svc.sagaRepo = r
+		return nil
+	}
+}
+
+// WithSagaTimeouts sets for how long the saga of an order waits for the reply of
+// its reserve and payment steps before compensating. It needs the scheduler.
+func WithSagaTimeouts(reserve, payment time.Duration) SvcConf {
+	return func(svc *basicOrderService) error {
+		if reserve <= 0 || payment <= 0 {
+			return errors.New("saga timeouts must be positive")
+		}
+		svc.sagaTimeouts = map[model.SagaState]time.Duration{
+			model.SagaStateReserving: reserve,
+			model.SagaStatePaying:    payment,
+		}
+		return nil
+	}
+}
+
+// WithCancelableStatuses sets the statuses in which the customer can cancel
+// an order. Orders which are past these statuses can't be canceled.
+func WithCancelableStatuses(statuses []string) SvcConf {
+	return func(svc *basicOrderService) error {
+		for _, s := range statuses {
+			status := model.OrderStatus(s)
+			if !status.CanTransitTo(model.OrderStatusCancelRequested) {
+				return fmt.Errorf("order in %s status can't be canceled", s)
+			}
+			svc.cancelableStatuses = append(svc.cancelableStatuses, status)
+		}
+		return nil
+	}
+}
+
+// WithSweepThresholds sets for how long an order can be in a status before the
+// sweeper fails it. Only the statuses from which an order can fail are allowed,
+// but for paid, which is only failed by the saga so that the payment is refunded.
+func WithSweepThresholds(thresholds map[string]time.Duration) SvcConf {
+	return func(svc *basicOrderService) error {
+		svc.sweepThresholds = map[model.OrderStatus]time.Duration{}
+		for s, threshold := range thresholds {
+			status := model.OrderStatus(s)
+			if status == model.OrderStatusPaid || !status.CanTransitTo(model.OrderStatusFailed) {
+				return fmt.Errorf("order in %s status can't be failed by the sweeper", s)
+			}
+			if threshold <= 0 {
+				return fmt.Errorf("sweep threshold of %s status must be positive", s)
+			}
+			svc.sweepThresholds[status] = threshold
+		}
+		return nil
+	}
+}
+
+// WithStreamPollInterval sets how often the order status streams look for the
+// new status changes in the log
+func WithStreamPollInterval(d time.Duration) SvcConf {
+	return func(svc *basicOrderService) error {
+		if d <= 0 {
+			return errors.New("stream poll interval must be positive")
+		}
+		svc.streamPollInterval = d
+		return nil
+	}
+}
+
+// WithStreamCommitWindow sets for how long after it is logged a status change may
+// still be committing. The IDs of the log are taken in the order of the inserts rather
+// than of the commits, so the streams read the changes logged within the window again
+// to find the ones committed after a later one was streamed.
+func WithStreamCommitWindow(d time.Duration) SvcConf {
+	return func(svc *basicOrderService) error {
+		if d <= 0 {
+			return errors.New("stream commit window must be positive")
+		}
+		svc.streamCommitWindow = d
+		return nil
+	}
+}
+
 // New returns a OrderService implementation with
 // all of the expected config/middleware wired in.
 func New(logger *cl.CustomLogger, mws []Middleware, svcconfs ...SvcConf) IOrderService {
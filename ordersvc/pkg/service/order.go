@@ -2,18 +2,47 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	svcconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
 	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func (svc *basicOrderService) CreateOrder(ctx context.Context, pid uuid.UUID, qty int) (uuid.UUID, error) {
+// maxOrderLines is the number of products which can be ordered at once
+const maxOrderLines = 50
+
+func (svc *basicOrderService) CreateOrder(ctx context.Context, lines []dto.OrderLine) (uuid.UUID, error) {
+	if len(lines) == 0 || len(lines) > maxOrderLines {
+		return uuid.Nil, ce.ErrInvalidOrderLines
+	}
+	ordered := map[uuid.UUID]bool{}
+	for _, l := range lines {
+		// a product must be ordered in a single line
+		if l.PID == uuid.Nil || l.Qty <= 0 || ordered[l.PID] {
+			return uuid.Nil, ce.ErrInvalidOrderLines
+		}
+		ordered[l.PID] = true
+	}
+	lineObjs, err := svc.quote(ctx, lines)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	eventLines := []svcevent.EventOrderLine{}
+	for _, l := range lineObjs {
+		eventLines = append(eventLines, svcevent.EventOrderLine{ProductID: l.ProductID, Qty: l.Qty, UnitPrice: l.UnitPrice})
+	}
+
+	// the order is created along with the saga which coordinates it from here on
 	claim := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
-	oid, err := svc.repo.CreateOrder(ctx, claim.AccntID, qty, pid, model.OrderStatusPending)
+	reqID, _ := ctx.Value(svcconf.C.ReqIDKey).(string)
+	oid, err := svc.sagaRepo.CreateOrder(ctx, claim.AccntID, lineObjs, statusChangeCause(ctx, "created by the customer"), reqID)
 	if err != nil {
 		return oid, err
 	}
@@ -26,8 +55,8 @@ func (svc *basicOrderService) CreateOrder(ctx context.Context, pid uuid.UUID, qt
 			OrderID:     oid,
 			OrderStatus: string(model.OrderStatusPending),
 			AccntID:     claim.AccntID,
-			ProductID:   pid,
-			Qty:         qty,
+			Lines:       eventLines,
+			Total:       model.TotalOf(lineObjs),
 		}))
 	svc.cl.LogIfError(ctx, eventErr)
 
@@ -55,6 +84,18 @@ func (svc *basicOrderService) CreateOrder(ctx context.Context, pid uuid.UUID, qt
 	))
 	svc.cl.LogIfError(ctx, eventErr)
 
+	// account must be able to cancel the newly created order
+	eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
+		ctx, svcevent.EventUpsertPolicy,
+		svcevent.EventUpsertPolicyPayload{
+			Sub:          fmt.Sprint(claim.AccntID),
+			ResourceType: "orders",
+			ResourceID:   oid.String(),
+			Action:       "cancel",
+		},
+	))
+	svc.cl.LogIfError(ctx, eventErr)
+
 	eventErr = eventPublisher.Publish(svc.nc)
 	svc.cl.LogIfError(ctx, eventErr)
 	if eventErr == nil {
@@ -62,18 +103,83 @@ func (svc *basicOrderService) CreateOrder(ctx context.Context, pid uuid.UUID, qt
 			"published events: %v", eventPublisher.GetEventNames()))
 	}
 
+	svc.scheduleSagaTimeout(ctx, oid, model.SagaStateReserving)
+
 	// dont send the event error to the client as
 	// client does not need to know about the event details
 	return oid, err
 }
 
-func (svc *basicOrderService) ListOrder(ctx context.Context, oids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListOrderResponse {
+// quote prices the lines at the current prices of the product projection and rejects
+// the ones of the products which are unknown or short of stock. inventorysvc still
+// has the final say on reserving, it rejects the order if the prices changed meanwhile.
+func (svc *basicOrderService) quote(ctx context.Context, lines []dto.OrderLine) ([]model.OrderLine, error) {
+	pids := []uuid.UUID{}
+	for _, l := range lines {
+		pids = append(pids, l.PID)
+	}
+	productObjs, err := svc.productRepo.ListProductByIDs(ctx, pids)
+	if err != nil {
+		return nil, err
+	}
+	products := map[uuid.UUID]model.Product{}
+	for _, p := range productObjs {
+		products[p.ID] = p
+	}
+
+	lineObjs := []model.OrderLine{}
+	for _, l := range lines {
+		p, ok := products[l.PID]
+		if !ok || p.Deleted {
+			return nil, fmt.Errorf("%w: %v", ce.ErrUnknownProduct, l.PID)
+		}
+		if p.Qty < l.Qty {
+			return nil, fmt.Errorf("%w: %v", ce.ErrProductOutOfStock, l.PID)
+		}
+		lineObjs = append(lineObjs, model.OrderLine{ProductID: l.PID, Qty: l.Qty, UnitPrice: p.Price})
+	}
+	return lineObjs, nil
+}
+
+func (svc *basicOrderService) CancelOrder(ctx context.Context, oid uuid.UUID) dto.CancelOrderResponse {
+	orderObj, err := svc.repo.GetOrderByID(ctx, oid)
+	if err != nil {
+		return dto.CancelOrderResponse{Err: err}
+	}
+
+	cancelable := false
+	for _, s := range svc.cancelableStatuses {
+		if model.OrderStatus(orderObj.Status) == s {
+			cancelable = true
+		}
+	}
+	if !cancelable {
+		return dto.CancelOrderResponse{Err: ce.ErrOrderNotCancelable}
+	}
+
+	// the saga undoes the completed steps and finishes the cancellation
+	err = svc.transitSaga(ctx, oid, model.SagaTriggerCancelRequested, "canceled by the customer")
+	var illegalSagaErr *model.ErrIllegalSagaTransition
+	var illegalStatusErr *model.ErrIllegalStatusTransition
+	if errors.As(err, &illegalSagaErr) || errors.As(err, &illegalStatusErr) {
+		// the order moved on meanwhile
+		err = ce.ErrOrderNotCancelable
+	}
+	if err != nil {
+		return dto.CancelOrderResponse{Err: err}
+	}
+
+	return dto.CancelOrderResponse{OID: oid, Status: string(model.OrderStatusCancelRequested)}
+}
+
+func (svc *basicOrderService) ListOrder(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListOrderResponse {
 	var orderObjs []model.Order
+	var page *dto.Page
 	var err error
-	if len(oids) > 0 {
-		orderObjs, err = svc.repo.ListOrderByIDs(ctx, oids, qp)
+	if sub != "" {
+		orderObjs, page, err = svc.repo.ListOrderGrantedTo(ctx, sub, qp)
 	} else {
-		orderObjs, err = svc.repo.ListOrder(ctx, qp)
+		orderObjs, page, err = svc.repo.ListOrder(ctx, qp)
 	}
 
 	if err != nil {
@@ -81,15 +187,82 @@ func (svc *basicOrderService) ListOrder(ctx context.Context, oids []uuid.UUID, q
 		return dto.ListOrderResponse{Err: err}
 	}
 
+	return dto.ListOrderResponse{Orders: svc.toOrderResponses(ctx, orderObjs), Page: page}
+}
+
+func (svc *basicOrderService) GetOrder(ctx context.Context, oid uuid.UUID) dto.GetOrderResponse {
+	orderObj, err := svc.repo.GetOrderByID(ctx, oid)
+	if err != nil {
+		return dto.GetOrderResponse{Err: err}
+	}
+	return svc.toOrderResponses(ctx, []model.Order{orderObj})[0]
+}
+
+func (svc *basicOrderService) GetOrderHistory(ctx context.Context, oid uuid.UUID) dto.GetOrderHistoryResponse {
+	changes, err := svc.repo.ListOrderStatusHistory(ctx, oid)
+	if err != nil {
+		return dto.GetOrderHistoryResponse{Err: err}
+	}
+	// every order has its creation logged
+	if len(changes) == 0 {
+		return dto.GetOrderHistoryResponse{Err: gorm.ErrRecordNotFound}
+	}
+
+	history := []dto.OrderStatusChangeResponse{}
+	for _, c := range changes {
+		history = append(history, dto.OrderStatusChangeResponse{
+			From:      c.From,
+			To:        c.To,
+			Time:      c.CreatedAt,
+			EventID:   c.EventID,
+			EventName: c.EventName,
+			RequestID: c.RequestID,
+			Reason:    c.Reason,
+		})
+	}
+	return dto.GetOrderHistoryResponse{OID: oid, History: history}
+}
+
+// toOrderResponses maps the orders to their responses, along with the details
+// of the ordered products known from the product projection
+func (svc *basicOrderService) toOrderResponses(ctx context.Context, orderObjs []model.Order) []dto.GetOrderResponse {
+	pids := []uuid.UUID{}
+	for _, o := range orderObjs {
+		for _, l := range o.Lines {
+			pids = append(pids, l.ProductID)
+		}
+	}
+	products := map[uuid.UUID]model.Product{}
+	if len(pids) > 0 {
+		productObjs, err := svc.productRepo.ListProductByIDs(ctx, pids)
+		// the orders are still worth returning without the product details
+		svc.cl.LogIfError(ctx, err)
+		for _, p := range productObjs {
+			products[p.ID] = p
+		}
+	}
+
 	var orders []dto.GetOrderResponse
 	for _, o := range orderObjs {
+		lines := []dto.OrderLineResponse{}
+		for _, l := range o.Lines {
+			line := dto.OrderLineResponse{PID: l.ProductID, Qty: l.Qty, UnitPrice: l.UnitPrice}
+			if p, ok := products[l.ProductID]; ok {
+				line.ProdName = p.Name
+				line.MerchantID = &p.MerchantID
+				// the orders created before the prices were quoted show the current price
+				if l.UnitPrice == 0 {
+					line.UnitPrice = p.Price
+				}
+			}
+			lines = append(lines, line)
+		}
 		orders = append(orders, dto.GetOrderResponse{
-			OID:      o.ID.String(),
-			Status:   o.Status,
-			Qty:      o.Qty,
-			ProdName: "", // call inventory svc to get product details
+			OID:    o.ID.String(),
+			Status: o.Status,
+			Lines:  lines,
+			Total:  o.Total,
 		})
 	}
-
-	return dto.ListOrderResponse{Orders: orders}
+	return orders
 }
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	svcconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The saga of an order issues its commands and consumes the replies as events:
+//   reserving:   EventOrderCreated asks inventorysvc to reserve the product,
+//                which replies with EventProductReserved or EventErrReservingProduct,
+//                or with EventPriceMismatch if the quoted prices are stale
+//   paying:      EventProductReserved asks paymentsvc to deduct the payable,
+//                which replies with EventPayment
+//   completed:   EventOrderApproved asks inventorysvc to remove the reservation
+//   compensated: EventOrderCanceled asks the services to undo their steps, again
+//                on a late EventPayment so that paymentsvc refunds it
+//   canceling:   EventOrderCanceled asks the services to undo their steps, inventorysvc
+//                replies with EventReservationReleased and paymentsvc with EventRefundCompleted
+// inventorysvc releases the reservations which outlive their TTL by itself and fires
+// EventReservationExpired, which compensates the saga still reserving or paying.
+// Each waiting state times out through a scheduled EventSagaTimedOut.
+
+// advanceSaga moves the saga of the order on the reply of a step
+func (svc *basicOrderService) advanceSaga(ctx context.Context, oid uuid.UUID, t model.SagaTrigger, reason string) error {
+	err := svc.transitSaga(ctx, oid, t, reason)
+
+	var illegalErr *model.ErrIllegalSagaTransition
+	if errors.As(err, &illegalErr) || errors.Is(err, gorm.ErrRecordNotFound) {
+		// a late/duplicate reply or the reply of an unknown order, nothing to do
+		svc.cl.Warn(ctx, fmt.Sprintf("saga-%s: ignoring %s [%v]", oid, t, err))
+		return nil
+	}
+	return err
+}
+
+// transitSaga moves the saga of the order on the trigger
+// and issues the command which the transition asks for
+func (svc *basicOrderService) transitSaga(ctx context.Context, oid uuid.UUID, t model.SagaTrigger, reason string) error {
+	sagaObj, from, cmd, err := svc.sagaRepo.TransitSaga(ctx, oid, t, statusChangeCause(ctx, reason))
+	if err != nil {
+		return err
+	}
+
+	to := model.SagaState(sagaObj.State)
+	svc.cl.Debug(ctx, fmt.Sprintf("saga-%s: %s -> %s on %s", oid, from, to, t))
+
+	if to != from {
+		// the step of the previous state is over, start the clock of the next one
+		svc.cancelSagaTimeout(ctx, oid)
+		svc.scheduleSagaTimeout(ctx, oid, to)
+	}
+
+	// the saga moved on already, so a command which can't be published
+	// is left to the scheduler to retry instead of the redelivered reply
+	e, err := svc.issueSagaCommand(ctx, sagaObj, cmd)
+	if err != nil && e != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("saga-%s: error issuing command, scheduling it [%v]", oid, err))
+		if svc.scheduler == nil {
+			return err
+		}
+		return svc.scheduler.Schedule(ctx, e, oid.String(), time.Now())
+	}
+	return err
+}
+
+// statusChangeCause tells what is changing the status of an order from the context:
+// the event being handled, if any, and the request which started it all
+func statusChangeCause(ctx context.Context, reason string) model.StatusChangeCause {
+	cause := model.StatusChangeCause{Reason: reason}
+	cause.RequestID, _ = ctx.Value(svcconf.C.ReqIDKey).(string)
+	if meta, ok := svcevent.EventMetaFromContext(ctx); ok {
+		cause.EventID = meta.ID
+		cause.EventName = meta.Name
+	}
+	return cause
+}
+
+// issueSagaCommand publishes the event of the command, if the command is issued by ordersvc.
+// It returns the event along with the publish error, so that the caller can retry it.
+func (svc *basicOrderService) issueSagaCommand(ctx context.Context, sagaObj model.Saga, cmd model.SagaCommand) (svcevent.IEvent, error) {
+	var e svcevent.IEvent
+	var err error
+
+	switch cmd {
+	case model.SagaCommandApproveOrder:
+		e, err = svcevent.NewEvent(
+			ctx, svcevent.EventOrderApproved,
+			svcevent.EventOrderApprovedPayload{OID: sagaObj.ID, AccntID: sagaObj.AccntID})
+	case model.SagaCommandCancelOrder:
+		e, err = svcevent.NewEvent(
+			ctx, svcevent.EventOrderCanceled,
+			svcevent.EventOrderCanceledPayload{OID: sagaObj.ID, AccntID: sagaObj.AccntID})
+	default:
+		// the other services issue the commands of the remaining steps themselves
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saga-%s: error creating command [%v]", sagaObj.ID, err)
+	}
+
+	err = e.Publish(svc.nc)
+	if err != nil {
+		return e, err
+	}
+	svc.cl.Debug(ctx, fmt.Sprintf("published events: %s", e.Name()))
+	return e, nil
+}
+
+// scheduleSagaTimeout schedules the timeout of the state if it waits for a reply
+func (svc *basicOrderService) scheduleSagaTimeout(ctx context.Context, oid uuid.UUID, state model.SagaState) {
+	timeout, ok := svc.sagaTimeouts[state]
+	if !ok || svc.scheduler == nil {
+		return
+	}
+
+	e, err := svcevent.NewEvent(
+		ctx, svcevent.EventSagaTimedOut,
+		svcevent.EventSagaTimedOutPayload{SagaID: oid, State: string(state)})
+	if err == nil {
+		err = svc.scheduler.Schedule(ctx, e, oid.String(), time.Now().Add(timeout))
+	}
+	if err != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("saga-%s: error scheduling timeout [%v]", oid, err))
+	}
+}
+
+func (svc *basicOrderService) cancelSagaTimeout(ctx context.Context, oid uuid.UUID) {
+	if svc.scheduler == nil {
+		return
+	}
+	_, err := svc.scheduler.Cancel(ctx, oid.String(), svcevent.EventSagaTimedOut)
+	if err != nil {
+		svc.cl.Error(ctx, fmt.Sprintf("saga-%s: error canceling timeout [%v]", oid, err))
+	}
+}
+
+func (svc *basicOrderService) HandleSagaTimedOutEvent(ctx context.Context, sid uuid.UUID, state string) error {
+	sagaObj, err := svc.sagaRepo.GetSaga(ctx, sid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// the step got its reply right before timing out
+	if sagaObj.State != state {
+		return nil
+	}
+
+	reason := fmt.Sprintf("no reply in %s state within %v", state, svc.sagaTimeouts[model.SagaState(state)])
+	return svc.advanceSaga(ctx, sid, model.SagaTriggerTimedOut, reason)
+}
+
+func (svc *basicOrderService) GetSaga(ctx context.Context, oid uuid.UUID) dto.GetSagaResponse {
+	sagaObj, err := svc.sagaRepo.GetSaga(ctx, oid)
+	if err != nil {
+		return dto.GetSagaResponse{Err: err}
+	}
+
+	resp := dto.GetSagaResponse{
+		SagaID:    sagaObj.ID,
+		State:     sagaObj.State,
+		CreatedAt: sagaObj.CreatedAt,
+		UpdatedAt: sagaObj.UpdatedAt,
+	}
+	for _, st := range sagaObj.History {
+		resp.History = append(resp.History, dto.SagaTransitionResponse{
+			From:      st.From,
+			To:        st.To,
+			Trigger:   st.Trigger,
+			RequestID: st.RequestID,
+			Reason:    st.Reason,
+			Time:      st.CreatedAt,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+)
+
+const streamBatchSize = 100
+
+// StreamOrderStatus polls the log of the order status changes for the ones of the
+// orders of the caller, so that the changes made by any replica reach the client.
+// A client resuming from its last seen event gets all the changes since then.
+//
+// The IDs of the log follow the inserts, so a change can be committed after a later
+// one was streamed. Every poll reads the changes logged within the commit window
+// again and streams the ones it has not streamed yet. A resuming client may get
+// some of the changes of the window again, which it can drop by their ID.
+func (svc *basicOrderService) StreamOrderStatus(ctx context.Context, req dto.StreamOrderStatusRequest) dto.StreamOrderStatusResponse {
+	claim := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+
+	// the changes streamed within the commit window
+	streamed := map[uint]time.Time{}
+
+	lastID := req.LastEventID
+	if !req.Resume {
+		var err error
+		lastID, err = svc.repo.LastStatusChangeID(ctx)
+		if err != nil {
+			return dto.StreamOrderStatusResponse{Err: err}
+		}
+		// the changes committed before connecting are not streamed
+		late, err := svc.repo.ListLateStatusChanges(ctx, claim.AccntID, lastID, time.Now().Add(-svc.streamCommitWindow))
+		if err != nil {
+			return dto.StreamOrderStatusResponse{Err: err}
+		}
+		for _, c := range late {
+			streamed[c.ID] = c.CreatedAt
+		}
+	}
+
+	events := make(chan dto.OrderStatusEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(svc.streamPollInterval)
+		defer ticker.Stop()
+
+		send := func(c model.OrderStatusChange) bool {
+			e := dto.OrderStatusEvent{ID: c.ID, OID: c.OrderID, From: c.From, Status: c.To, Time: c.CreatedAt}
+			select {
+			case events <- e:
+				streamed[c.ID] = c.CreatedAt
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			since := time.Now().Add(-svc.streamCommitWindow)
+			for id, createdAt := range streamed {
+				if createdAt.Before(since) {
+					delete(streamed, id)
+				}
+			}
+
+			late, err := svc.repo.ListLateStatusChanges(ctx, claim.AccntID, lastID, since)
+			if err != nil && ctx.Err() == nil {
+				svc.cl.Error(ctx, fmt.Sprintf("stream: error getting late status changes [%v]", err))
+			}
+			for _, c := range late {
+				if _, ok := streamed[c.ID]; ok {
+					continue
+				}
+				if !send(c) {
+					return
+				}
+			}
+
+			changes, err := svc.repo.ListStatusChanges(ctx, claim.AccntID, lastID, streamBatchSize)
+			if err != nil && ctx.Err() == nil {
+				svc.cl.Error(ctx, fmt.Sprintf("stream: error getting status changes [%v]", err))
+			}
+			for _, c := range changes {
+				if !send(c) {
+					return
+				}
+				lastID = c.ID
+			}
+			// there might be more of them already
+			if len(changes) == streamBatchSize {
+				continue
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return dto.StreamOrderStatusResponse{Events: events}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	svcconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
+	cl "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const sweepBatchSize = 100
+
+// sweptOrders counts the orders failed by the sweeper by the status they were
+// stuck in, along with the errors. It is exposed at /v1/ordersvc/_metrics.
+var sweptOrders = expvar.NewMap("ordersvc_swept_orders")
+
+// SweepStaleOrders fails the orders which are in a status for longer than its
+// threshold, e.g. because inventorysvc or paymentsvc was down past the retention
+// of the streams, so their saga never got the reply nor the scheduled timeout.
+func (svc *basicOrderService) SweepStaleOrders(ctx context.Context) (int, error) {
+	swept := 0
+	for status, threshold := range svc.sweepThresholds {
+		orders, err := svc.repo.ListStaleOrders(ctx, status, time.Now().Add(-threshold), sweepBatchSize)
+		if err != nil {
+			return swept, err
+		}
+
+		for _, orderObj := range orders {
+			reason := fmt.Sprintf("stale in %s status for more than %v", status, threshold)
+			ok, err := svc.failStaleOrder(ctx, orderObj, reason)
+			if err != nil {
+				sweptOrders.Add("errors", 1)
+				svc.cl.Error(ctx, fmt.Sprintf("sweeper: error failing order %s [%v]", orderObj.ID, err))
+				continue
+			}
+			if ok {
+				sweptOrders.Add(string(status), 1)
+				swept++
+			}
+		}
+	}
+	return swept, nil
+}
+
+// failStaleOrder times out the saga of the order, which fails the order and
+// asks the services to undo their steps. It returns false if the order moved on meanwhile.
+func (svc *basicOrderService) failStaleOrder(ctx context.Context, orderObj model.Order, reason string) (bool, error) {
+	err := svc.transitSaga(ctx, orderObj.ID, model.SagaTriggerTimedOut, reason)
+
+	var illegalSagaErr *model.ErrIllegalSagaTransition
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &illegalSagaErr):
+		return false, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return false, err
+	}
+
+	// the orders created before the sagas were introduced have none. The services are
+	// asked to undo their steps before failing the order, so that the order is swept
+	// again if the command can't be published, as undoing the steps is idempotent.
+	_, err = svc.issueSagaCommand(ctx, model.Saga{ID: orderObj.ID, AccntID: orderObj.AccntID}, model.SagaCommandCancelOrder)
+	if err != nil {
+		return false, err
+	}
+	err = svc.repo.UpdateOrderStatus(ctx, orderObj.ID, model.OrderStatusFailed, statusChangeCause(ctx, reason))
+	var illegalStatusErr *model.ErrIllegalStatusTransition
+	if errors.As(err, &illegalStatusErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	svc.cl.Info(ctx, fmt.Sprintf("order-%s: failed [%s]", orderObj.ID, reason))
+	return true, nil
+}
+
+// Sweeper periodically fails the stale orders, see IOrderService.SweepStaleOrders.
+// Replicas can sweep concurrently, as the saga of an order moves only once.
+type Sweeper struct {
+	cl       *cl.CustomLogger
+	svc      IOrderService
+	interval time.Duration
+
+	cancel chan struct{}
+}
+
+// NewSweeper returns a Sweeper which sweeps the stale orders every interval
+func NewSweeper(logger *cl.CustomLogger, svc IOrderService, interval time.Duration) (*Sweeper, error) {
+	if svc == nil {
+		return nil, errors.New("sweeper: service not provided")
+	}
+	if interval <= 0 {
+		return nil, errors.New("sweeper: interval must be positive")
+	}
+	return &Sweeper{
+		cl:       logger,
+		svc:      svc,
+		interval: interval,
+		cancel:   make(chan struct{}),
+	}, nil
+}
+
+// Execute sweeps the stale orders till the sweeper is interrupted
+func (s *Sweeper) Execute() error {
+	s.cl.Info(context.TODO(), "sweeper: initialised")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.cancel:
+			s.cl.Info(context.TODO(), "sweeper: closed")
+			return nil
+		case <-ticker.C:
+			ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, uuid.NewString())
+			n, err := s.svc.SweepStaleOrders(ctx)
+			if err != nil {
+				s.cl.Error(ctx, fmt.Sprintf("sweeper: %v", err))
+			}
+			if n > 0 {
+				s.cl.Info(ctx, fmt.Sprintf("sweeper: failed %d stale orders", n))
+			}
+		}
+	}
+}
+
+func (s *Sweeper) Interrupt(err error) {
+	close(s.cancel)
+}
@@ -16,6 +16,14 @@ func NewHTTPHandler(endpoints endpoint.Endpoints, options map[string][]kithttp.S
 
 	makeCreateOrderHandler(m, endpoints, options["CreateOrder"])
 	makeListOrderHandler(m, endpoints, options["ListOrder"])
+	// the stream must be matched before /orders/{id}
+	makeStreamOrderStatusHandler(m, endpoints, options["StreamOrderStatus"])
+	makeGetOrderHandler(m, endpoints, options["GetOrder"])
+	makeGetOrderHistoryHandler(m, endpoints, options["GetOrderHistory"])
+	makeCancelOrderHandler(m, endpoints, options["CancelOrder"])
+	makeGetSagaHandler(m, endpoints, options["GetSaga"])
+
+	makeListEventsHandler(m, options["ListEvents"])
 
 	return m
 }
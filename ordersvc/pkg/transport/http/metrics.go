@@ -0,0 +1,17 @@
+package http
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NewMetricsHandler returns a handler that exposes the expvar metrics of the service,
+// e.g. the orders failed by the sweeper. As expvar also exposes the command line and the memory
+// stats of the process, it is meant to be served on an internal listener only.
+func NewMetricsHandler() http.Handler {
+	m := mux.NewRouter()
+	m.Methods("GET").Path("/v1/ordersvc/_metrics").Handler(expvar.Handler())
+	return m
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/endpoint"
 	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/sorting"
+	"github.com/google/uuid"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
@@ -48,9 +50,58 @@ func makeListOrderHandler(m *mux.Router, endpoints endpoint.Endpoints, options [
 		))
 }
 
+// orderSorts are the fields which the orders can be sorted by
+var orderSorts = sorting.Fields{
+	"created_at": {Column: "created_at"},
+	"updated_at": {Column: "updated_at"},
+	"status":     {Column: "status"},
+}
+
 // decodeListOrderRequest is a transport/http.DecodeRequestFunc that decodes a
 // JSON-encoded request from the HTTP request body.
 func decodeListOrderRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
-	qp := processBasicQP(r)
-	return qp, nil
+	return processBasicQP(r, orderSorts, "updated_at__desc")
+}
+
+// makeGetOrderHandler creates the handler logic
+func makeGetOrderHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/orders/{id}").Handler(
+		kithttp.NewServer(
+			endpoints.GetOrderEndpoint,
+			decodeOrderIDFromPath,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// makeGetOrderHistoryHandler creates the handler logic
+func makeGetOrderHistoryHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/orders/{id}/history").Handler(
+		kithttp.NewServer(
+			endpoints.GetOrderHistoryEndpoint,
+			decodeOrderIDFromPath,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// makeCancelOrderHandler creates the handler logic
+func makeCancelOrderHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("POST").Path("/orders/{id}/cancel").Handler(
+		kithttp.NewServer(
+			endpoints.CancelOrderEndpoint,
+			decodeOrderIDFromPath,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
+
+// decodeOrderIDFromPath is a transport/http.DecodeRequestFunc that decodes
+// the order ID from the request path.
+func decodeOrderIDFromPath(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	oid, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, ce.ErrInvalidReqBody
+	}
+	return oid, nil
 }
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/endpoint"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// makeGetSagaHandler creates the handler logic
+func makeGetSagaHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/sagas/{id}").Handler(
+		kithttp.NewServer(
+			endpoints.GetSagaEndpoint,
+			decodeOrderIDFromPath,
+			encodeHTTPGenericResponse,
+			options...,
+		))
+}
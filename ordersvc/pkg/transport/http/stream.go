@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/endpoint"
+	ce "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/error"
+	kitep "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment,
+// so that the proxies in between don't drop the connection
+const streamKeepAlive = 15 * time.Second
+
+// makeStreamOrderStatusHandler creates the handler logic
+func makeStreamOrderStatusHandler(m *mux.Router, endpoints endpoint.Endpoints, options []kithttp.ServerOption) {
+	m.Methods("GET").Path("/orders/stream").Handler(
+		kithttp.NewServer(
+			endpoints.StreamOrderStatusEndpoint,
+			decodeStreamOrderStatusRequest,
+			encodeStreamOrderStatusResponse,
+			options...,
+		))
+}
+
+// decodeStreamOrderStatusRequest is a transport/http.DecodeRequestFunc that decodes
+// the ID of the last event seen by a reconnecting client from the Last-Event-ID
+// header, or from the last_event_id query param for the clients which can't set it.
+func decodeStreamOrderStatusRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
+	req := dto.StreamOrderStatusRequest{}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID == "" {
+		return req, nil
+	}
+
+	id, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return nil, ce.ErrInvalidReqBody
+	}
+	req.LastEventID = uint(id)
+	req.Resume = true
+	return req, nil
+}
+
+// encodeStreamOrderStatusResponse is a transport/http.EncodeResponseFunc that writes
+// the status changes as Server-Sent Events till the client goes away.
+func encodeStreamOrderStatusResponse(ctx context.Context, w stdhttp.ResponseWriter, response interface{}) error {
+	if f, ok := response.(kitep.Failer); ok && f.Failed() != nil {
+		ErrorEncoder(ctx, f.Failed(), w)
+		return nil
+	}
+	resp := response.(dto.StreamOrderStatusResponse)
+
+	flusher, ok := w.(stdhttp.Flusher)
+	if !ok {
+		return errors.New("streaming is not supported by the response writer")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(stdhttp.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, ok := <-resp.Events:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: status\ndata: %s\n\n", e.ID, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
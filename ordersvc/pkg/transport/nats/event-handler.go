@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	cl "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/service"
 	"github.com/nats-io/nats.go"
@@ -36,6 +37,7 @@ func (eh *EventHandler) Execute() error {
 		return err
 	}
 	eh.subcriptions = s
+	svcevent.Registry.SetSubscriptions(eh.nc.Conn, s)
 
 	eh.cl.Info(context.TODO(), "event handler: initialised")
 	<-eh.cancel
@@ -46,6 +48,7 @@ func (eh *EventHandler) Execute() error {
 func (eh *EventHandler) Interrupt(err error) {
 	eh.cl.Info(context.TODO(), "event handler: cleanup started")
 	close(eh.cancel)
+	svcevent.Registry.SetSubscriptions(nil, nil)
 	for _, s := range eh.subcriptions {
 		s.Unsubscribe()
 	}
@@ -3,12 +3,14 @@ package nats
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	svcconf "github.com/AyushSenapati/reactive-micro/ordersvc/conf"
 	svcevent "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/event"
 	pe "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/ordersvc/pkg/logger"
+	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/model"
 	"github.com/AyushSenapati/reactive-micro/ordersvc/pkg/service"
 	"github.com/nats-io/nats.go"
 )
@@ -17,21 +19,57 @@ type EventHandlerFuncs struct {
 	EventAccountCreatedHandler      nats.Handler
 	EventPolicyUpdatedHandler       nats.Handler
 	EventErrReservingProductHandler nats.Handler
+	EventPriceMismatchHandler       nats.Handler
 	EventProductReservedHandler     nats.Handler
 	EventPaymentHandler             nats.Handler
+	EventSagaTimedOutHandler        nats.Handler
+	EventReservationReleasedHandler nats.Handler
+	EventReservationExpiredHandler  nats.Handler
+	EventRefundCompletedHandler     nats.Handler
+	EventProductCreatedHandler      nats.Handler
+	EventProductUpdatedHandler      nats.Handler
+	EventProductDeletedHandler      nats.Handler
 }
 
 func getTargetSub(reqChan, svcName string) string {
 	return reqChan + "." + svcName
 }
 
+// ackInvalid validates the payload of the event and acks the message if it is invalid,
+// as an invalid payload would never be processed successfully, instead of letting it
+// be redelivered. It returns true if the message got acked.
+func ackInvalid(ctx context.Context, logger *cl.CustomLogger, m *nats.Msg, name svcevent.EventName, p interface{}) bool {
+	err := svcevent.ValidatePayload(name, p)
+	if err == nil {
+		return false
+	}
+	logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", name, err))
+	m.Ack()
+	return true
+}
+
+// isPermanentErr reports if handling an event would fail on its redeliveries as well,
+// e.g. a late event trying to move an order to a status which is no longer reachable
+func isPermanentErr(err error) bool {
+	var illegalErr *model.ErrIllegalStatusTransition
+	return errors.As(err, &illegalErr)
+}
+
 func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IOrderService) *EventHandlerFuncs {
 	return &EventHandlerFuncs{
 		EventAccountCreatedHandler:      makeEventAccountCreatedHandler(logger, svc),
 		EventPolicyUpdatedHandler:       makeEventPolicyUpdatedHandler(logger, svc),
 		EventErrReservingProductHandler: makeEventErrReservingProductHandler(logger, svc),
+		EventPriceMismatchHandler:       makeEventPriceMismatchHandler(logger, svc),
 		EventProductReservedHandler:     makeEventProductReservedHandler(logger, svc),
 		EventPaymentHandler:             makeEventPaymentHandler(logger, svc),
+		EventSagaTimedOutHandler:        makeEventSagaTimedOutHandler(logger, svc),
+		EventReservationReleasedHandler: makeEventReservationReleasedHandler(logger, svc),
+		EventReservationExpiredHandler:  makeEventReservationExpiredHandler(logger, svc),
+		EventRefundCompletedHandler:     makeEventRefundCompletedHandler(logger, svc),
+		EventProductCreatedHandler:      makeEventProductCreatedHandler(logger, svc),
+		EventProductUpdatedHandler:      makeEventProductUpdatedHandler(logger, svc),
+		EventProductDeletedHandler:      makeEventProductDeletedHandler(logger, svc),
 	}
 }
 
@@ -73,6 +111,17 @@ func (ehf *EventHandlerFuncs) GetSubscription(nc *nats.EncodedConn) (subscriptio
 	}
 	subscriptions = append(subscriptions, s)
 
+	// subscribe to EventPriceMismatch
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventPriceMismatch)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventPriceMismatchHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
 	// subscribe to EventProductReserved
 	t, err = svcevent.Registry.GetEventInfo(svcevent.EventProductReserved)
 	if err != nil {
@@ -95,6 +144,83 @@ func (ehf *EventHandlerFuncs) GetSubscription(nc *nats.EncodedConn) (subscriptio
 	}
 	subscriptions = append(subscriptions, s)
 
+	// subscribe to EventSagaTimedOut
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventSagaTimedOut)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventSagaTimedOutHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventReservationReleased
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventReservationReleased)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventReservationReleasedHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventReservationExpired
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventReservationExpired)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventReservationExpiredHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventRefundCompleted
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventRefundCompleted)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventRefundCompletedHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventProductCreated
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventProductCreated)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventProductCreatedHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventProductUpdated
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventProductUpdated)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventProductUpdatedHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
+	// subscribe to EventProductDeleted
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventProductDeleted)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventProductDeletedHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
 	return
 }
 
@@ -105,6 +231,7 @@ func makeEventAccountCreatedHandler(logger *cl.CustomLogger, svc service.IOrderS
 
 		json.Unmarshal(m.Data, &e)
 		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
 		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
 
 		encodedPayload, err := json.Marshal(e.Payload)
@@ -119,6 +246,10 @@ func makeEventAccountCreatedHandler(logger *cl.CustomLogger, svc service.IOrderS
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventAccountCreated, p) {
+			return
+		}
+
 		err = svc.HandleAccountCreatedEvent(ctx, p.AccntID, p.Role)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventAccountCreated] err: %v", err))
@@ -135,6 +266,7 @@ func makeEventPolicyUpdatedHandler(logger *cl.CustomLogger, svc service.IOrderSe
 
 		json.Unmarshal(m.Data, &e)
 		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
 		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
 
 		encodedPayload, err := json.Marshal(e.Payload)
@@ -149,6 +281,10 @@ func makeEventPolicyUpdatedHandler(logger *cl.CustomLogger, svc service.IOrderSe
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventPolicyUpdated, p) {
+			return
+		}
+
 		err = svc.HandlePolicyUpdatedEvent(ctx, p.Method, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if (err == nil) || (err == pe.ErrUnsupportedRtype) || (err == pe.ErrSubNotCached) {
 			m.Ack() // if no error occurred processing event ack it
@@ -165,6 +301,7 @@ func makeEventErrReservingProductHandler(logger *cl.CustomLogger, svc service.IO
 
 		json.Unmarshal(m.Data, &e)
 		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
 		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
 
 		encodedPayload, err := json.Marshal(e.Payload)
@@ -179,9 +316,54 @@ func makeEventErrReservingProductHandler(logger *cl.CustomLogger, svc service.IO
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventErrReservingProduct, p) {
+			return
+		}
+
 		err = svc.HandleErrReservingProductEvent(ctx, p.OrderID)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventErrReservingProduct] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventPriceMismatchHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventPriceMismatchPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventPriceMismatch] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventPriceMismatch] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventPriceMismatch, p) {
+			return
+		}
+
+		err = svc.HandlePriceMismatchEvent(ctx, p.OrderID, p.Lines)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventPriceMismatch] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
 			return
 		}
 		m.Ack()
@@ -195,6 +377,7 @@ func makeEventProductReservedHandler(logger *cl.CustomLogger, svc service.IOrder
 
 		json.Unmarshal(m.Data, &e)
 		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
 		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
 
 		encodedPayload, err := json.Marshal(e.Payload)
@@ -209,9 +392,16 @@ func makeEventProductReservedHandler(logger *cl.CustomLogger, svc service.IOrder
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventProductReserved, p) {
+			return
+		}
+
 		err = svc.HandleProductReservedEvent(ctx, p.OrderID)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventProductReserved] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
 			return
 		}
 		m.Ack()
@@ -225,6 +415,7 @@ func makeEventPaymentHandler(logger *cl.CustomLogger, svc service.IOrderService)
 
 		json.Unmarshal(m.Data, &e)
 		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
 		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
 
 		encodedPayload, err := json.Marshal(e.Payload)
@@ -239,9 +430,282 @@ func makeEventPaymentHandler(logger *cl.CustomLogger, svc service.IOrderService)
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventPayment, p) {
+			return
+		}
+
 		err = svc.HandlePaymentEvent(ctx, p.OrderID, p.AccntID, p.Status)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventPayment] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventSagaTimedOutHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventSagaTimedOutPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventSagaTimedOut] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventSagaTimedOut] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventSagaTimedOut, p) {
+			return
+		}
+
+		err = svc.HandleSagaTimedOutEvent(ctx, p.SagaID, p.State)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventSagaTimedOut] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventReservationReleasedHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventReservationReleasedPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationReleased] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationReleased] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventReservationReleased, p) {
+			return
+		}
+
+		err = svc.HandleReservationReleasedEvent(ctx, p.OrderID)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationReleased] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventReservationExpiredHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventReservationExpiredPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationExpired] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationExpired] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventReservationExpired, p) {
+			return
+		}
+
+		err = svc.HandleReservationExpiredEvent(ctx, p.OrderID)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventReservationExpired] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventRefundCompletedHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventRefundCompletedPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRefundCompleted] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRefundCompleted] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventRefundCompleted, p) {
+			return
+		}
+
+		err = svc.HandleRefundCompletedEvent(ctx, p.OrderID)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventRefundCompleted] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventProductCreatedHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventProductCreatedPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductCreated] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductCreated] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventProductCreated, p) {
+			return
+		}
+
+		err = svc.HandleProductCreatedEvent(ctx, p.ProductID, p.MerchantID, p.Name, p.Price, p.Qty, p.Version)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductCreated] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventProductUpdatedHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventProductUpdatedPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductUpdated] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductUpdated] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventProductUpdated, p) {
+			return
+		}
+
+		err = svc.HandleProductUpdatedEvent(ctx, p.ProductID, p.MerchantID, p.Name, p.Price, p.Qty, p.Version)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductUpdated] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
+			return
+		}
+		m.Ack()
+	}
+}
+
+func makeEventProductDeletedHandler(logger *cl.CustomLogger, svc service.IOrderService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventProductDeletedPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		ctx = svcevent.WithEventMeta(ctx, e.Meta)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductDeleted] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductDeleted] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventProductDeleted, p) {
+			return
+		}
+
+		err = svc.HandleProductDeletedEvent(ctx, p.ProductID, p.Version)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventProductDeleted] err: %v", err))
+			if isPermanentErr(err) {
+				m.Ack()
+			}
 			return
 		}
 		m.Ack()
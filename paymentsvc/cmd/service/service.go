@@ -18,7 +18,9 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
 	svcep "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/endpoint"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/idempotency"
 	svcpe "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/policy-enforcer"
 	cl "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/logger"
 	svcrepo "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/repo"
@@ -38,7 +40,7 @@ var httpAddr = fs.String("http-addr", ":8085", "HTTP listen address")
 var securedMethods = []string{"RechargeWallet", "ListTransactions"}
 
 // holds the name of all the endpoints that ther service supports
-var allMethods = []string{"RechargeWallet", "ListTransactions"}
+var allMethods = []string{"RechargeWallet", "ListTransactions", "ListEvents"}
 
 // holds the database table names that the service is dealing with
 var allResourceTypes = []string{"wallets", "transactions"}
@@ -102,10 +104,27 @@ func Run() {
 	}
 
 	// initialise endpoint
-	eps := svcep.New(svc, getEndpointMW(confObj))
+	idempotencyStore := idempotency.NewGormStore(db, confObj.Idempotency.TTL, confObj.Idempotency.Lease)
+	eps := svcep.New(svc, getEndpointMW(confObj, idempotencyStore))
+
+	// initialise the purger of the expired idempotency keys
+	purger, err := idempotency.NewPurger(logger, idempotencyStore, confObj.Idempotency.PurgeInterval)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error initialising idempotency purger [%v]", err))
+		return
+	}
+
+	// project the policies granted before the ACL projection was deployed
+	n, err := svc.BackfillACL(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("error backfilling the acl projection [%v]", err))
+		return
+	}
+	logger.Info(ctx, fmt.Sprintf("backfilled the acl projection with %d policies", n))
 
 	g := &run.Group{}
 	initEventHandler(logger, svc, nc, g)
+	g.Add(purger.Execute, purger.Interrupt)
 	initHttpHandler(logger, eps, g)
 	initCancelInterrupt(g)
 	err = g.Run()
@@ -129,9 +148,13 @@ func getServiceMiddleware(c *svcconf.Config, ps svcpe.PolicyStorage) (mw []servi
 	return
 }
 
-func getEndpointMW(c *svcconf.Config) (mw map[string][]kitep.Middleware) {
+func getEndpointMW(c *svcconf.Config, is idempotency.Store) (mw map[string][]kitep.Middleware) {
 	mw = map[string][]kitep.Middleware{}
 
+	// replay the retries of the requests made with an Idempotency-Key,
+	// the jwt token parsing middleware wraps it to tell whose key it is
+	mw["RechargeWallet"] = append(mw["RechargeWallet"], svcep.NewIdempotencyMW(is, "RechargeWallet", dto.RechargeWalletResponse{}))
+
 	// enforce jwt token parsing middleware on secure endpoints
 	for _, method := range securedMethods {
 		mw[method] = append(
@@ -151,6 +174,7 @@ func initHttpHandler(logger *cl.CustomLogger, endpoints svcep.Endpoints, g *run.
 		options[method] = append(
 			options[method], kithttp.ServerBefore(kitjwt.HTTPToContext()))
 	}
+	options["RechargeWallet"] = append(options["RechargeWallet"], kithttp.ServerBefore(idempotency.HTTPToContext()))
 
 	httpHandler := httptransport.NewHTTPHandler(endpoints, options)
 	nl, err := net.Listen("tcp", *httpAddr)
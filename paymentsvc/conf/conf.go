@@ -29,6 +29,11 @@ var (
 			"access_kid":        "id_at",
 			"refresh_kid":       "id_rt",
 		},
+		"idempotency": map[string]interface{}{
+			"ttl":            time.Hour * 24,
+			"purge_interval": time.Hour,
+			"lease":          time.Minute,
+		},
 	}
 )
 
@@ -64,6 +69,14 @@ type Config struct {
 		AccessKID       string        `mapstructure:"access_kid"`
 		RefreshKID      string        `mapstructure:"refresh_kid"`
 	} `mapstructure:"auth"`
+
+	// Idempotency configures for how long the Idempotency-Key of a request is kept,
+	// how often the expired keys are purged and for how long a running request holds its key
+	Idempotency struct {
+		TTL           time.Duration `mapstructure:"ttl"`
+		PurgeInterval time.Duration `mapstructure:"purge_interval"`
+		Lease         time.Duration `mapstructure:"lease"`
+	} `mapstructure:"idempotency"`
 }
 
 func (c *Config) Load(confFname string) error {
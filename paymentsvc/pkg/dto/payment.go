@@ -20,15 +20,18 @@ func (resp RechargeWalletResponse) Failed() error {
 }
 
 type TransactionResponse struct {
-	ID         uuid.UUID `json:"txn_id"`
-	ExecutedAt time.Time `json:"executed_at"`
-	Amount     float32   `json:"amount"`
-	MadeBy     uint      `json:"made_by,omitempty"`
-	IsCredit   bool      `json:"is_credit"`
+	ID         uuid.UUID  `json:"txn_id"`
+	ExecutedAt time.Time  `json:"executed_at"`
+	Amount     float32    `json:"amount"`
+	MadeBy     uint       `json:"made_by,omitempty"`
+	IsCredit   bool       `json:"is_credit"`
+	OrderID    *uuid.UUID `json:"order_id,omitempty"`
+	RefundOf   *uuid.UUID `json:"refund_of,omitempty"`
 }
 
 type ListTransactionsResponse struct {
 	Transactions []TransactionResponse `json:"transactions,omitempty"`
+	Page         *Page                 `json:"page,omitempty"`
 	Err          error                 `json:"err,omitempty"`
 }
 
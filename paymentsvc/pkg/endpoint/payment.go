@@ -2,12 +2,12 @@ package endpoint
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/service"
 	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
-	"github.com/google/uuid"
 )
 
 func makeRechargeWalletEndpoint(s service.IPaymentService) endpoint.Endpoint {
@@ -29,6 +29,10 @@ func makeRechargeWalletEndpoint(s service.IPaymentService) endpoint.Endpoint {
 func makeListTransactionsEndpoint(s service.IPaymentService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		qp, _ := request.(*dto.BasicQueryParam)
-		return s.ListTransactions(ctx, []uuid.UUID{}, qp), nil
+		claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
+		if !ok {
+			return dto.ListTransactionsResponse{Err: kitjwt.ErrTokenContextMissing}, nil
+		}
+		return s.ListTransactions(ctx, fmt.Sprint(claim.AccntID), qp), nil
 	}
 }
@@ -0,0 +1,22 @@
+package event
+
+import "github.com/google/uuid"
+
+const EventOrderCanceled EventName = "EventOrderCanceled"
+
+// register the event to the registry
+func init() {
+	Registry.register(EventOrderCanceled, EventInfo{
+		ReqChan: "ordersvc.EventOrderCanceled",
+		Payload: EventOrderCanceledPayload{},
+		isValidPayload: func(i interface{}) bool {
+			_, ok := i.(EventOrderCanceledPayload)
+			return ok
+		},
+	})
+}
+
+type EventOrderCanceledPayload struct {
+	OID     uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
+}
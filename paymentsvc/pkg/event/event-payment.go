@@ -8,6 +8,7 @@ const EventPayment EventName = "EventPayment"
 func init() {
 	Registry.register(EventPayment, EventInfo{
 		ReqChan: "paymentsvc.EventPayment",
+		Payload: EventPaymentPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventPaymentPayload)
 			return ok
@@ -16,7 +17,7 @@ func init() {
 }
 
 type EventPaymentPayload struct {
-	OrderID uuid.UUID `json:"order_id"`
-	AccntID uint      `json:"account_id"`
-	Status  string    `json:"status"` // can be on of [payment_successful / payment_failed]
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
+	Status  string    `json:"status" validate:"oneof=payment_successful|payment_failed"` // can be on of [payment_successful / payment_failed]
 }
@@ -6,6 +6,7 @@ const EventPolicyUpdated EventName = "EventPolicyUpdated"
 func init() {
 	Registry.register(EventPolicyUpdated, EventInfo{
 		ReqChan: "authzsvc.EventPolicyUpdated",
+		Payload: EventPolicyUpdatedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventPolicyUpdatedPayload)
 			return ok
@@ -14,9 +15,9 @@ func init() {
 }
 
 type EventPolicyUpdatedPayload struct {
-	Method       string `json:"method"` // could be put/delete
-	Sub          string `json:"subject"`
-	ResourceType string `json:"resource_type"`
-	ResourceID   string `json:"resource_id"`
-	Action       string `json:"action"`
+	Method       string `json:"method" validate:"oneof=put|delete"` // could be put/delete
+	Sub          string `json:"subject" validate:"required"`
+	ResourceType string `json:"resource_type" validate:"required"`
+	ResourceID   string `json:"resource_id" validate:"required"`
+	Action       string `json:"action" validate:"required"`
 }
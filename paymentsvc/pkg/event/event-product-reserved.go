@@ -8,6 +8,7 @@ const EventProductReserved EventName = "EventProductReserved"
 func init() {
 	Registry.register(EventProductReserved, EventInfo{
 		ReqChan: "inventorysvc.EventProductReserved",
+		Payload: EventProductReservedPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventProductReservedPayload)
 			return ok
@@ -16,7 +17,7 @@ func init() {
 }
 
 type EventProductReservedPayload struct {
-	OrderID uuid.UUID `json:"order_id"`
-	AccntID uint      `json:"account_id"`
-	Payble  float32   `json:"payble"`
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+	AccntID uint      `json:"account_id" validate:"required"`
+	Payble  float32   `json:"payble" validate:"min=0"`
 }
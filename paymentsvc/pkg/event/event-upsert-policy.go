@@ -6,6 +6,7 @@ const EventUpsertPolicy EventName = "EventUpsertPolicy"
 func init() {
 	Registry.register(EventUpsertPolicy, EventInfo{
 		ReqChan: "authzsvc.EventUpsertPolicy",
+		Payload: EventUpsertPolicyPayload{},
 		isValidPayload: func(i interface{}) bool {
 			_, ok := i.(EventUpsertPolicyPayload)
 			return ok
@@ -14,8 +15,8 @@ func init() {
 }
 
 type EventUpsertPolicyPayload struct {
-	Sub          string `json:"subject"`
-	ResourceType string `json:"resource_type"`
-	ResourceID   string `json:"resource_id"`
-	Action       string `json:"action"`
+	Sub          string `json:"subject" validate:"required"`
+	ResourceType string `json:"resource_type" validate:"required"`
+	ResourceID   string `json:"resource_id" validate:"required"`
+	Action       string `json:"action" validate:"required"`
 }
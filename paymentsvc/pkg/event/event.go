@@ -3,6 +3,7 @@ package event
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	svcconf "github.com/AyushSenapati/reactive-micro/paymentsvc/conf"
@@ -22,11 +23,19 @@ var Registry = &EventRegistry{
 // It helps in verifying the event name and getting their request/response channel
 type EventRegistry struct {
 	Registry map[EventName]EventInfo
+
+	// active subscriptions of the service, see SetSubscriptions
+	mu   sync.RWMutex
+	nc   *nats.Conn
+	subs []*nats.Subscription
 }
 
 type EventInfo struct {
-	ReqChan        string
-	RespChan       string
+	ReqChan  string
+	RespChan string
+	// Payload is the zero value of the payload struct of the event.
+	// It describes the fields which the service writes/reads for the event
+	Payload        interface{}
 	isValidPayload func(interface{}) bool
 }
 
@@ -116,6 +125,11 @@ func NewEvent(ctx context.Context, name EventName, payload interface{}) (IEvent,
 		return nil, fmt.Errorf("payload checker is not set for event: %s", name)
 	}
 
+	// check the field level validation rules of the payload
+	if err := ValidatePayload(name, payload); err != nil {
+		return nil, err
+	}
+
 	e := &Event{Meta: getEventMeta(ctx, string(name)), Payload: payload}
 
 	return e, nil
@@ -132,7 +146,7 @@ func NewEventPublisher() *EventPublisher {
 
 func (ep *EventPublisher) AddEvent(e IEvent, err error) error {
 	if e == nil || err != nil {
-		err := fmt.Errorf("event publisher: err adding event [err: %v]", err)
+		err := fmt.Errorf("event publisher: err adding event [err: %w]", err)
 		return err
 	}
 	ep.events = append(ep.events, e)
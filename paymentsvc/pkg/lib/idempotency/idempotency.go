@@ -0,0 +1,113 @@
+// Package idempotency lets the clients retry a mutating request safely by sending
+// an Idempotency-Key header. The first request made with a key is executed and
+// its response is stored, its retries get the stored response replayed.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	kitep "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+// Header is the HTTP header carrying the idempotency key
+const Header = "Idempotency-Key"
+
+// maxKeyLen is the longest idempotency key accepted
+const maxKeyLen = 255
+
+var (
+	// ErrInvalidKey is returned when the idempotency key is too long
+	ErrInvalidKey = errors.New("idempotency: invalid key")
+
+	// ErrKeyReused is returned when a key is sent again along with a different request
+	ErrKeyReused = errors.New("idempotency: key is already used for another request")
+
+	// ErrRequestInProgress is returned when a key is sent again while its first request is still being processed
+	ErrRequestInProgress = errors.New("idempotency: request with the same key is in progress")
+)
+
+type keyCtxKey struct{}
+
+// HTTPToContext moves the idempotency key from the request header to the context
+func HTTPToContext() kithttp.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		key := r.Header.Get(Header)
+		if key == "" {
+			return ctx
+		}
+		return context.WithValue(ctx, keyCtxKey{}, key)
+	}
+}
+
+// SubjectFunc returns whom the request is made by, the keys of the different subjects don't clash
+type SubjectFunc func(ctx context.Context) (string, bool)
+
+// NewMiddleware returns an endpoint middleware which executes a request made with an
+// idempotency key only once. It must be wrapped by the middleware which authenticates
+// the subject. The response is replayed as the type of the given prototype, only the
+// successful responses are stored so that the failed requests can be retried.
+func NewMiddleware(store Store, method string, subject SubjectFunc, prototype interface{}) kitep.Middleware {
+	respType := reflect.TypeOf(prototype)
+
+	return func(next kitep.Endpoint) kitep.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			key, _ := ctx.Value(keyCtxKey{}).(string)
+			if key == "" {
+				return next(ctx, request)
+			}
+			if len(key) > maxKeyLen {
+				return nil, ErrInvalidKey
+			}
+			sub, ok := subject(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+
+			// the same key must come with the same request
+			body, err := json.Marshal(request)
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(append([]byte(method+"\n"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			rec, claimed, err := store.Begin(ctx, sub, key, fingerprint)
+			if err != nil {
+				return nil, err
+			}
+			if !claimed {
+				if rec.Fingerprint != fingerprint {
+					return nil, ErrKeyReused
+				}
+				if !rec.Completed {
+					return nil, ErrRequestInProgress
+				}
+				resp := reflect.New(respType)
+				if err := json.Unmarshal(rec.Response, resp.Interface()); err != nil {
+					return nil, err
+				}
+				return resp.Elem().Interface(), nil
+			}
+
+			resp, err := next(ctx, request)
+			if f, ok := resp.(kitep.Failer); err != nil || (ok && f.Failed() != nil) {
+				store.Release(ctx, sub, key)
+				return resp, err
+			}
+
+			// the request is done even if its response can't be stored, so the key stays
+			// claimed and its retries are refused till it expires instead of executed again
+			if data, err := json.Marshal(resp); err == nil {
+				store.Complete(ctx, sub, key, data)
+			}
+			return resp, nil
+		}
+	}
+}
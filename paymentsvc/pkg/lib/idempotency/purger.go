@@ -0,0 +1,67 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Logger is the part of the service logger the Purger logs with
+type Logger interface {
+	Info(ctx context.Context, msg interface{})
+	Error(ctx context.Context, msg interface{})
+}
+
+// Purger periodically deletes the expired keys of the store, which are otherwise
+// only replaced when the same key is used again. Replicas can purge concurrently.
+type Purger struct {
+	logger   Logger
+	store    Store
+	interval time.Duration
+
+	cancel chan struct{}
+}
+
+// NewPurger returns a Purger which purges the expired keys of the store every interval
+func NewPurger(logger Logger, store Store, interval time.Duration) (*Purger, error) {
+	if store == nil {
+		return nil, errors.New("idempotency purger: store not provided")
+	}
+	if interval <= 0 {
+		return nil, errors.New("idempotency purger: interval must be positive")
+	}
+	return &Purger{
+		logger:   logger,
+		store:    store,
+		interval: interval,
+		cancel:   make(chan struct{}),
+	}, nil
+}
+
+// Execute purges the expired keys till the purger is interrupted
+func (p *Purger) Execute() error {
+	p.logger.Info(context.TODO(), "idempotency purger: initialised")
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.cancel:
+			p.logger.Info(context.TODO(), "idempotency purger: closed")
+			return nil
+		case <-ticker.C:
+			n, err := p.store.Purge(context.TODO(), time.Now())
+			if err != nil {
+				p.logger.Error(context.TODO(), fmt.Sprintf("idempotency purger: %v", err))
+			}
+			if n > 0 {
+				p.logger.Info(context.TODO(), fmt.Sprintf("idempotency purger: purged %d expired keys", n))
+			}
+		}
+	}
+}
+
+func (p *Purger) Interrupt(err error) {
+	close(p.cancel)
+}
@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Record is the outcome of a request made with an idempotency key by a subject
+type Record struct {
+	Subject     string `gorm:"primaryKey"`
+	Key         string `gorm:"column:idempotency_key;primaryKey"`
+	Fingerprint string
+	// Response is the JSON of the response, set once the request succeeds
+	Response  []byte
+	Completed bool
+	// ClaimedAt is when the request which is running with the key claimed it
+	ClaimedAt time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// Store keeps the idempotency keys till they expire
+type Store interface {
+	// Begin claims the key for the request unless it is claimed already, in which
+	// case it returns the record of the request which claimed it first. A key whose
+	// request is still running past the lease is claimed again, as its process died.
+	Begin(ctx context.Context, subject, key, fingerprint string) (rec Record, claimed bool, err error)
+	// Complete stores the response of the request which claimed the key
+	Complete(ctx context.Context, subject, key string, response []byte) error
+	// Release frees the key of a request which failed, so that it can be retried
+	Release(ctx context.Context, subject, key string) error
+	// Purge deletes the keys which expired by the given time and returns how many it deleted
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type gormStore struct {
+	db    *gorm.DB
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewGormStore returns a Store keeping the keys in the idempotency_keys table for the given TTL.
+// The lease must outlast the requests, as a request running for longer loses its key.
+func NewGormStore(db *gorm.DB, ttl, lease time.Duration) Store {
+	if db == nil {
+		return nil
+	}
+
+	// auto-migrate tables
+	db.AutoMigrate(&Record{})
+
+	return &gormStore{db: db, ttl: ttl, lease: lease}
+}
+
+func (s *gormStore) Begin(ctx context.Context, subject, key, fingerprint string) (Record, bool, error) {
+	now := time.Now()
+	rec := Record{Subject: subject, Key: key, Fingerprint: fingerprint, ClaimedAt: now, ExpiresAt: now.Add(s.ttl)}
+	claimed := false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// an expired key can be used again, as well as a key whose request never
+		// completed nor released it within the lease
+		err := tx.Where("subject = ? AND idempotency_key = ?", subject, key).
+			Where(tx.Where("expires_at <= ?", now).
+				Or("completed = ? AND claimed_at <= ?", false, now.Add(-s.lease))).
+			Delete(&Record{}).Error
+		if err != nil {
+			return err
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			claimed = true
+			return nil
+		}
+		return tx.First(&rec, "subject = ? AND idempotency_key = ?", subject, key).Error
+	})
+	return rec, claimed, err
+}
+
+func (s *gormStore) Complete(ctx context.Context, subject, key string, response []byte) error {
+	return s.db.WithContext(ctx).Model(&Record{}).
+		Where("subject = ? AND idempotency_key = ?", subject, key).
+		Updates(map[string]interface{}{"response": response, "completed": true}).Error
+}
+
+func (s *gormStore) Release(ctx context.Context, subject, key string) error {
+	return s.db.WithContext(ctx).
+		Where("subject = ? AND idempotency_key = ? AND completed = ?", subject, key, false).
+		Delete(&Record{}).Error
+}
+
+func (s *gormStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at <= ?", before).Delete(&Record{})
+	return res.RowsAffected, res.Error
+}
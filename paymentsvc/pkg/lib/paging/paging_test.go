@@ -0,0 +1,63 @@
+package paging
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/sorting"
+)
+
+type row struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Price     float32
+	Qty       uint
+}
+
+func TestCursor(t *testing.T) {
+	tx := &gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{}}}
+	keys := []sorting.Key{
+		{Column: "created_at", Desc: true}, {Column: "price"}, {Column: "t.qty"}, {Column: "id"}}
+	r := row{ID: uuid.New(), CreatedAt: time.Now().UTC(), Price: 0.1, Qty: 3}
+
+	s, err := encode(tx, keys, reflect.ValueOf(r), true)
+	if err != nil {
+		t.Fatalf("encode: unexpected err [%v]", err)
+	}
+	c, err := decode(s, keys)
+	if err != nil {
+		t.Fatalf("decode: unexpected err [%v]", err)
+	}
+	if !c.Backward {
+		t.Error("decode: want a backward cursor")
+	}
+
+	where, args, err := after(keys, c)
+	if err != nil {
+		t.Fatalf("after: unexpected err [%v]", err)
+	}
+	// backward the directions of the keys are reversed
+	wantWhere := "((created_at > ?) OR (created_at = ? AND price < ?) OR " +
+		"(created_at = ? AND price = ? AND t.qty < ?) OR (created_at = ? AND price = ? AND t.qty = ? AND id < ?))"
+	if where != wantWhere {
+		t.Errorf("after: want %s, got %s", wantWhere, where)
+	}
+	want := []interface{}{r.CreatedAt, float64(r.Price), int64(r.Qty), r.ID}
+	if got := args[len(args)-4:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("after: want args %v, got %v", want, got)
+	}
+
+	// a cursor is valid only for the order it was issued for
+	if _, err := decode(s, keys[1:]); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("decode with other keys: want ErrInvalidCursor, got %v", err)
+	}
+	if _, err := decode("not-a-cursor", keys); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("decode garbage: want ErrInvalidCursor, got %v", err)
+	}
+}
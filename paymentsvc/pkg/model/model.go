@@ -17,4 +17,10 @@ type Transaction struct {
 	Amount     float32
 	MadeBy     uint
 	IsCredit   bool
+
+	// OrderID is the order the transaction was made for, if any
+	OrderID *uuid.UUID `gorm:"index"`
+	// RefundOf is the debit transaction which this transaction refunds.
+	// A debit can be refunded only once.
+	RefundOf *uuid.UUID `gorm:"uniqueIndex"`
 }
@@ -3,24 +3,52 @@ package repo
 import (
 	"context"
 	"errors"
-	"fmt"
-	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/paging"
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
 )
 
-var ErrInsufficientBalance = errors.New("insufficient balance")
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrTXNotFound          = errors.New("transaction not found")
+)
 
 // PaymentRepository defines all the DB operations that the service supports
 type PaymentRepository interface {
 	EnableWallet(ctx context.Context, aid uint, balance float32) error
-	ExecuteTX(ctx context.Context, aid uint, amount float32, isCredit bool) (uuid.UUID, error)
-	ListTxnsByIDs(ctx context.Context, txids []uuid.UUID, qp *dto.BasicQueryParam) ([]model.Transaction, error)
-	ListTxns(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Transaction, error)
+	// ExecuteTX debits/credits the wallet of the account. A refund (see RefundOf)
+	// is executed only once, the ID of the existing refund is returned on retries.
+	ExecuteTX(ctx context.Context, aid uint, amount float32, isCredit bool, opts ...TXOption) (uuid.UUID, error)
+	GetOrderDebit(ctx context.Context, oid uuid.UUID) (model.Transaction, error)
+	// ListTxnsGrantedTo lists the transactions the subject may get as per the ACL projection
+	ListTxnsGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) ([]model.Transaction, *dto.Page, error)
+	ListTxns(ctx context.Context, qp *dto.BasicQueryParam) ([]model.Transaction, *dto.Page, error)
+
+	// GrantACL and RevokeACL keep the ACL projection in step with the policy events
+	GrantACL(ctx context.Context, e model.ACLEntry) error
+	RevokeACL(ctx context.Context, e model.ACLEntry) error
+}
+
+// TXOption sets the optional details of a transaction
+type TXOption func(*model.Transaction)
+
+// ForOrder links the transaction to the order it is made for
+func ForOrder(oid uuid.UUID) TXOption {
+	return func(txo *model.Transaction) {
+		txo.OrderID = &oid
+	}
+}
+
+// RefundOf marks the transaction as the refund of the given debit transaction
+func RefundOf(txid uuid.UUID) TXOption {
+	return func(txo *model.Transaction) {
+		txo.RefundOf = &txid
+	}
 }
 
 type basicPaymentRepo struct {
@@ -33,38 +61,29 @@ func NewBasicOrderRepo(db *gorm.DB) PaymentRepository {
 	}
 
 	// auto-migrate tables
-	db.AutoMigrate(&model.Wallet{}, &model.Transaction{})
+	db.AutoMigrate(&model.Wallet{}, &model.Transaction{}, &model.ACLEntry{})
 
 	return &basicPaymentRepo{
 		db: db,
 	}
 }
 
-func orderBy(orderBy []string) func(tx *gorm.DB) *gorm.DB {
-	return func(tx *gorm.DB) *gorm.DB {
-		for _, o := range orderBy {
-			tx = tx.Order(o)
-		}
-		return tx
-	}
+// txnFilters are the fields which the transactions can be filtered by
+var txnFilters = filter.Fields{
+	"id":          {Column: "id", Kind: filter.UUID},
+	"amount":      {Column: "amount", Kind: filter.Number},
+	"is_credit":   {Column: "is_credit", Kind: filter.Bool},
+	"order_id":    {Column: "order_id", Kind: filter.UUID},
+	"refund_of":   {Column: "refund_of", Kind: filter.UUID},
+	"executed_at": {Column: "executed_at", Kind: filter.Time},
 }
 
-func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB {
-		if page == 0 {
-			page = 1
-		}
-
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
-		}
-
-		offset := (page - 1) * pageSize
-		return db.Offset(offset).Limit(pageSize)
+// filterBy applies the filters of the query params on the allowed fields
+func filterBy(fields filter.Fields, qp *dto.BasicQueryParam) func(tx *gorm.DB) *gorm.DB {
+	if qp == nil {
+		return func(tx *gorm.DB) *gorm.DB { return tx }
 	}
+	return fields.Scope(qp.Filter.Conds)
 }
 
 func (b *basicPaymentRepo) EnableWallet(ctx context.Context, aid uint, balance float32) error {
@@ -72,14 +91,32 @@ func (b *basicPaymentRepo) EnableWallet(ctx context.Context, aid uint, balance f
 	return b.db.Create(wo).Error
 }
 
-func (b *basicPaymentRepo) ExecuteTX(ctx context.Context, aid uint, amount float32, isCredit bool) (uuid.UUID, error) {
+func (b *basicPaymentRepo) ExecuteTX(ctx context.Context, aid uint, amount float32, isCredit bool, opts ...TXOption) (uuid.UUID, error) {
 	txid := uuid.New()
 	var err error
 
+	txo := model.Transaction{ID: txid, Amount: amount, MadeBy: aid, IsCredit: isCredit}
+	for _, opt := range opts {
+		opt(&txo)
+	}
+
 	err = b.db.Transaction(func(tx *gorm.DB) error {
 		wo := model.Wallet{AccntID: aid}
 		var result *gorm.DB
 
+		if txo.RefundOf != nil {
+			// the debit is refunded already, e.g. the refund is being retried
+			var refund model.Transaction
+			result = tx.Where("refund_of = ?", *txo.RefundOf).Limit(1).Find(&refund)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				txid = refund.ID
+				return nil
+			}
+		}
+
 		if isCredit {
 			result = tx.Model(&wo).Where("accnt_id = ?", aid).Update("balance", gorm.Expr("balance + ?", amount))
 		} else {
@@ -93,7 +130,6 @@ func (b *basicPaymentRepo) ExecuteTX(ctx context.Context, aid uint, amount float
 			return result.Error
 		}
 
-		txo := model.Transaction{ID: txid, Amount: amount, MadeBy: aid, IsCredit: isCredit}
 		err = tx.Create(&txo).Error
 		return err
 	})
@@ -101,26 +137,35 @@ func (b *basicPaymentRepo) ExecuteTX(ctx context.Context, aid uint, amount float
 	return txid, err
 }
 
-func (b *basicPaymentRepo) ListTxns(ctx context.Context, qp *dto.BasicQueryParam) (txs []model.Transaction, err error) {
-	if qp != nil {
-		err = b.db.Scopes(
-			orderBy(qp.Filter.OrederBy),
-			Paginate(qp.Paginator.Page, qp.Paginator.PageSize),
-		).Find(&txs).Error
-	} else {
-		err = b.db.Find(&txs).Error
+func (b *basicPaymentRepo) GetOrderDebit(ctx context.Context, oid uuid.UUID) (model.Transaction, error) {
+	var txo model.Transaction
+	result := b.db.WithContext(ctx).Where(
+		"order_id = ? and is_credit = ?", oid, false).Limit(1).Find(&txo)
+	if result.Error != nil {
+		return txo, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return txo, ErrTXNotFound
+	}
+	return txo, nil
+}
+
+func (b *basicPaymentRepo) ListTxns(ctx context.Context, qp *dto.BasicQueryParam) (txns []model.Transaction, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Transaction{}).Scopes(filterBy(txnFilters, qp))
+	if qp == nil {
+		err = tx.Find(&txns).Error
+		return
 	}
+	page, err = paging.Find(tx, qp.PageQuery("id"), &txns)
 	return
 }
 
-func (b *basicPaymentRepo) ListTxnsByIDs(ctx context.Context, txnids []uuid.UUID, qp *dto.BasicQueryParam) (txns []model.Transaction, err error) {
-	values := []string{}
-	for _, txnid := range txnids {
-		values = append(values, fmt.Sprintf("('%s')", txnid.String()))
+func (b *basicPaymentRepo) ListTxnsGrantedTo(ctx context.Context, sub string, qp *dto.BasicQueryParam) (txns []model.Transaction, page *dto.Page, err error) {
+	tx := b.db.Model(&model.Transaction{}).Scopes(grantedTo(b.db, sub, "transactions", "id"), filterBy(txnFilters, qp))
+	if qp == nil {
+		err = tx.Find(&txns).Error
+		return
 	}
-	q := fmt.Sprintf(
-		"select * from transactions t where t.id = any ( values %s )",
-		strings.Join(values, ","))
-	err = b.db.Debug().Raw(q, values).Scan(&txns).Error
+	page, err = paging.Find(tx, qp.PageQuery("id"), &txns)
 	return
 }
@@ -30,10 +30,18 @@ func (m *authzMW) HandlePolicyUpdatedEvent(ctx context.Context, t, sub, rtype, r
 	return m.next.HandlePolicyUpdatedEvent(ctx, t, sub, rtype, rid, act)
 }
 
+func (m *authzMW) BackfillACL(ctx context.Context) (int, error) {
+	return m.next.BackfillACL(ctx)
+}
+
 func (m *authzMW) HandleProductReservedEvent(ctx context.Context, oid uuid.UUID, aid uint, payble float32) error {
 	return m.next.HandleProductReservedEvent(ctx, oid, aid, payble)
 }
 
+func (m *authzMW) HandleOrderCanceledEvent(ctx context.Context, oid uuid.UUID, aid uint) error {
+	return m.next.HandleOrderCanceledEvent(ctx, oid, aid)
+}
+
 func (m *authzMW) RechargeWallet(ctx context.Context, aid uint, amount float32) (uuid.UUID, error) {
 	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", aid, "transactions", "post", "*")
 	if !m.pe.Enforce(ctx, reqPolicy, nil) {
@@ -42,30 +50,20 @@ func (m *authzMW) RechargeWallet(ctx context.Context, aid uint, amount float32)
 	return m.next.RechargeWallet(ctx, aid, amount)
 }
 
-func (m *authzMW) ListTransactions(ctx context.Context, txids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListTransactionsResponse {
+func (m *authzMW) ListTransactions(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListTransactionsResponse {
 	claim, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*dto.CustomClaim)
 	if !ok {
 		return dto.ListTransactionsResponse{Err: kitjwt.ErrTokenContextMissing}
 	}
-
-	// get possible transaction IDs that can be retrived by the authenticated subject
-	rids := m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "transactions", "*")
-	rids = append(rids, m.pe.GetResourceIDs(ctx, fmt.Sprint(claim.AccntID), "transactions", "get")...)
-
-	if len(rids) <= 0 {
+	// the callers list for themselves only
+	if sub != fmt.Sprint(claim.AccntID) {
 		return dto.ListTransactionsResponse{Err: ce.ErrInsufficientPerm}
 	}
 
-	for _, rid := range rids {
-		if rid == "*" {
-			return m.next.ListTransactions(ctx, []uuid.UUID{}, qp)
-		}
-		id, err := uuid.Parse(rid)
-		if err != nil {
-			continue
-		}
-		txids = append(txids, id)
+	// who may get any transaction lists all of them, the others only the ones granted to them
+	reqPolicy := fmt.Sprintf("%v:%s:%s:%v", sub, "transactions", "get", "*")
+	if m.pe.Enforce(ctx, reqPolicy, nil) {
+		return m.next.ListTransactions(ctx, "", qp)
 	}
-
-	return m.next.ListTransactions(ctx, txids, qp)
+	return m.next.ListTransactions(ctx, sub, qp)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	svcevent "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/event"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/model"
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/repo"
 	"github.com/google/uuid"
 )
@@ -37,11 +38,46 @@ func (svc *basicPaymentService) HandleAccountCreatedEvent(ctx context.Context, a
 }
 
 func (svc *basicPaymentService) HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error {
+	if err := svc.projectPolicy(ctx, method, sub, rtype, rid, act); err != nil {
+		return err
+	}
 	return svc.ps.UpdatePolicy(method, sub, rtype, rid, act)
 }
 
+// projectPolicy keeps the ACL projection in step with the policies on a single transaction.
+// The wildcard policies are left to the policy enforcer.
+func (svc *basicPaymentService) projectPolicy(ctx context.Context, method, sub, rtype, rid, act string) error {
+	txid, err := uuid.Parse(rid)
+	if rtype != "transactions" || err != nil {
+		return nil
+	}
+	e := model.ACLEntry{Subject: sub, ResourceType: rtype, ResourceID: txid, Action: act}
+	if method == "delete" {
+		return svc.repo.RevokeACL(ctx, e)
+	}
+	return svc.repo.GrantACL(ctx, e)
+}
+
+// BackfillACL grants the policies which authzsvc holds on the resources of the service,
+// as the policy updates made before the projection was deployed are not in the streams
+// anymore. It is to be called before consuming the policy updates, so that the ones
+// made meanwhile apply on top of it. A policy already projected is granted again as a no-op.
+func (svc *basicPaymentService) BackfillACL(ctx context.Context) (int, error) {
+	policies, err := svc.ps.ListPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range policies {
+		err := svc.projectPolicy(ctx, "put", p.Sub(), p.ResourceType(), p.ResourceID(), p.Action())
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(policies), nil
+}
+
 func (svc *basicPaymentService) HandleProductReservedEvent(ctx context.Context, oid uuid.UUID, aid uint, payble float32) error {
-	txid, err := svc.repo.ExecuteTX(ctx, aid, payble, false)
+	txid, err := svc.repo.ExecuteTX(ctx, aid, payble, false, repo.ForOrder(oid))
 
 	// time.Sleep(10 * time.Second)
 	eventPublisher := svcevent.NewEventPublisher()
@@ -94,3 +130,53 @@ func (svc *basicPaymentService) HandleProductReservedEvent(ctx context.Context,
 
 	return err
 }
+
+func (svc *basicPaymentService) HandleOrderCanceledEvent(ctx context.Context, oid uuid.UUID, aid uint) error {
+	debit, err := svc.repo.GetOrderDebit(ctx, oid)
+	if err == repo.ErrTXNotFound {
+		// nothing was paid for the order
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// credit the payable back to the wallet which paid it
+	txid, err := svc.repo.ExecuteTX(
+		ctx, debit.MadeBy, debit.Amount, true, repo.ForOrder(oid), repo.RefundOf(debit.ID))
+	if err != nil {
+		return err
+	}
+
+	eventPublisher := svcevent.NewEventPublisher()
+	eventErr := eventPublisher.AddEvent(svcevent.NewEvent(
+		ctx, svcevent.EventUpsertPolicy,
+		svcevent.EventUpsertPolicyPayload{
+			Sub:          fmt.Sprint(debit.MadeBy),
+			ResourceType: "transactions",
+			ResourceID:   txid.String(),
+			Action:       "get",
+		},
+	))
+	svc.cl.LogIfError(ctx, eventErr)
+
+	eventErr = eventPublisher.AddEvent(svcevent.NewEvent(
+		ctx, svcevent.EventRefundCompleted,
+		svcevent.EventRefundCompletedPayload{
+			OrderID: oid,
+			AccntID: debit.MadeBy,
+			TXID:    txid,
+			Amount:  debit.Amount,
+		},
+	))
+	svc.cl.LogIfError(ctx, eventErr)
+
+	// on failure the event is redelivered and the existing refund is published again
+	eventErr = eventPublisher.Publish(svc.nc)
+	if eventErr != nil {
+		return eventErr
+	}
+	svc.cl.Debug(ctx, fmt.Sprintf("published events: %v", eventPublisher.GetEventNames()))
+
+	return nil
+}
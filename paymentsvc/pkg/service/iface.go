@@ -20,10 +20,15 @@ type IPaymentService interface {
 	// Handlers of the events
 	HandleAccountCreatedEvent(ctx context.Context, accntID uint, role string) error
 	HandlePolicyUpdatedEvent(ctx context.Context, method, sub, rtype, rid, act string) error
+	// BackfillACL projects the policies granted before the ACL projection was deployed,
+	// see README.md. It returns the number of policies it went through.
+	BackfillACL(ctx context.Context) (int, error)
 	HandleProductReservedEvent(ctx context.Context, oid uuid.UUID, aid uint, payble float32) error
+	HandleOrderCanceledEvent(ctx context.Context, oid uuid.UUID, aid uint) error
 
 	RechargeWallet(ctx context.Context, aid uint, amount float32) (uuid.UUID, error)
-	ListTransactions(ctx context.Context, txids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListTransactionsResponse
+	// ListTransactions lists the transactions granted to the subject, all of them if sub is empty
+	ListTransactions(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListTransactionsResponse
 }
 
 type basicPaymentService struct {
@@ -42,14 +42,15 @@ func (svc *basicPaymentService) RechargeWallet(ctx context.Context, aid uint, am
 	return txid, err
 }
 
-func (svc *basicPaymentService) ListTransactions(ctx context.Context, txids []uuid.UUID, qp *dto.BasicQueryParam) dto.ListTransactionsResponse {
+func (svc *basicPaymentService) ListTransactions(ctx context.Context, sub string, qp *dto.BasicQueryParam) dto.ListTransactionsResponse {
 	var txObjs []model.Transaction
+	var page *dto.Page
 	var err error
 
-	if len(txids) > 0 {
-		txObjs, err = svc.repo.ListTxnsByIDs(ctx, txids, qp)
+	if sub != "" {
+		txObjs, page, err = svc.repo.ListTxnsGrantedTo(ctx, sub, qp)
 	} else {
-		txObjs, err = svc.repo.ListTxns(ctx, qp)
+		txObjs, page, err = svc.repo.ListTxns(ctx, qp)
 	}
 
 	if err != nil {
@@ -65,8 +66,10 @@ func (svc *basicPaymentService) ListTransactions(ctx context.Context, txids []uu
 			Amount:     o.Amount,
 			MadeBy:     o.MadeBy,
 			IsCredit:   o.IsCredit,
+			OrderID:    o.OrderID,
+			RefundOf:   o.RefundOf,
 		})
 	}
 
-	return dto.ListTransactionsResponse{Transactions: txs}
+	return dto.ListTransactionsResponse{Transactions: txs, Page: page}
 }
@@ -17,6 +17,10 @@ import (
 
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
 	ce "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/filter"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/idempotency"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/paging"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/sorting"
 )
 
 func ErrorEncoder(_ context.Context, err error, w stdhttp.ResponseWriter) {
@@ -52,7 +56,19 @@ func err2code(err error) int {
 		return stdhttp.StatusBadRequest
 	}
 
+	// these are wrapped along with the filter or orderby key they are about
+	if errors.Is(err, filter.ErrInvalidFilter) || errors.Is(err, sorting.ErrInvalidSort) ||
+		errors.Is(err, paging.ErrInvalidCursor) {
+		return stdhttp.StatusBadRequest
+	}
+
 	switch err {
+	case idempotency.ErrInvalidKey:
+		return stdhttp.StatusBadRequest
+	case idempotency.ErrKeyReused:
+		return stdhttp.StatusUnprocessableEntity
+	case idempotency.ErrRequestInProgress:
+		return stdhttp.StatusConflict
 	case io.ErrUnexpectedEOF, io.EOF, ce.ErrInvalidReqBody, &json.UnmarshalTypeError{}:
 		return stdhttp.StatusBadRequest
 	case ce.ErrWrongCred, ce.ErrTokenExpired, kitjwt.ErrTokenContextMissing, kitjwt.ErrTokenExpired:
@@ -76,30 +92,43 @@ func encodeHTTPGenericResponse(ctx context.Context, w stdhttp.ResponseWriter, re
 	return json.NewEncoder(w).Encode(response)
 }
 
-func processBasicQP(r *stdhttp.Request) *dto.BasicQueryParam {
+// basicQPs are the query params which are not filters
+var basicQPs = map[string]bool{"cursor": true, "page_size": true, "total": true, "orderby": true}
+
+func processBasicQP(r *stdhttp.Request, sortable sorting.Fields, defaultOrderBy string) (*dto.BasicQueryParam, error) {
 	l := dto.NewBasicQueryParam()
-	if pageNo := r.FormValue("page"); pageNo != "" {
-		if v, err := strconv.Atoi(pageNo); err == nil {
-			l.Paginator.Page = v
-		}
-	}
+	l.Paginator.Cursor = r.FormValue("cursor")
 	if pageSize := r.FormValue("page_size"); pageSize != "" {
 		if v, err := strconv.Atoi(pageSize); err == nil {
 			l.Paginator.PageSize = v
 		}
 	}
+	// counting all the records is opt-in, as it costs a query over all the pages
+	if total := r.FormValue("total"); total != "" {
+		l.Paginator.Total, _ = strconv.ParseBool(total)
+	}
 	orderBy := r.FormValue("orderby")
 	if orderBy == "" {
-		l.Filter.OrederBy = append(l.Filter.OrederBy, "updated_at desc")
-	} else {
-		fields := strings.Split(orderBy, ",")
-		for _, f := range fields {
-			fo := strings.Split(f, "__")
-			if len(fo) > 2 {
-				continue
+		orderBy = defaultOrderBy
+	}
+	keys, err := sortable.Parse(orderBy)
+	if err != nil {
+		return nil, err
+	}
+	l.Filter.OrderBy = keys
+
+	// every other query param is a filter on the field of its name
+	for field, exprs := range r.URL.Query() {
+		if basicQPs[field] {
+			continue
+		}
+		for _, expr := range exprs {
+			c, err := filter.Parse(field, expr)
+			if err != nil {
+				return nil, err
 			}
-			l.Filter.OrederBy = append(l.Filter.OrederBy, strings.Join(fo, " "))
+			l.Filter.Conds = append(l.Filter.Conds, c)
 		}
 	}
-	return l
+	return l, nil
 }
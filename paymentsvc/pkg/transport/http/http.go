@@ -17,5 +17,7 @@ func NewHTTPHandler(endpoints endpoint.Endpoints, options map[string][]kithttp.S
 	makeRechargeWalletHandler(m, endpoints, options["RechargeWallet"])
 	makeListTransactionsHandler(m, endpoints, options["ListTransactions"])
 
+	makeListEventsHandler(m, options["ListEvents"])
+
 	return m
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/dto"
 	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/endpoint"
 	ce "github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/error"
+	"github.com/AyushSenapati/reactive-micro/paymentsvc/pkg/lib/sorting"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 )
@@ -47,9 +48,14 @@ func makeListTransactionsHandler(m *mux.Router, endpoints endpoint.Endpoints, op
 	)
 }
 
+// txnSorts are the fields which the transactions can be sorted by
+var txnSorts = sorting.Fields{
+	"executed_at": {Column: "executed_at"},
+	"amount":      {Column: "amount"},
+}
+
 // decodeListTransactionsRequest is a transport/http.DecodeRequestFunc that decodes a
 // JSON-encoded request from the HTTP request body.
 func decodeListTransactionsRequest(_ context.Context, r *stdhttp.Request) (interface{}, error) {
-	qp := processBasicQP(r)
-	return qp, nil
+	return processBasicQP(r, txnSorts, "executed_at__desc")
 }
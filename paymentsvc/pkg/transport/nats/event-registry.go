@@ -17,17 +17,32 @@ type EventHandlerFuncs struct {
 	EventAccountCreatedHandler  nats.Handler
 	EventPolicyUpdatedHandler   nats.Handler
 	EventProductReservedHandler nats.Handler
+	EventOrderCanceledHandler   nats.Handler
 }
 
 func getTargetSub(reqChan, svcName string) string {
 	return reqChan + "." + svcName
 }
 
+// ackInvalid validates the payload of the event and acks the message if it is invalid,
+// as an invalid payload would never be processed successfully, instead of letting it
+// be redelivered. It returns true if the message got acked.
+func ackInvalid(ctx context.Context, logger *cl.CustomLogger, m *nats.Msg, name svcevent.EventName, p interface{}) bool {
+	err := svcevent.ValidatePayload(name, p)
+	if err == nil {
+		return false
+	}
+	logger.Error(ctx, fmt.Sprintf("event handler [%s] err: %v", name, err))
+	m.Ack()
+	return true
+}
+
 func initEventHandlerFuncs(logger *cl.CustomLogger, svc service.IPaymentService) *EventHandlerFuncs {
 	return &EventHandlerFuncs{
 		EventAccountCreatedHandler:  makeEventAccountCreatedHandler(logger, svc),
 		EventPolicyUpdatedHandler:   makeEventPolicyUpdatedHandler(logger, svc),
 		EventProductReservedHandler: makeEventProductReservedHandler(logger, svc),
+		EventOrderCanceledHandler:   makeEventOrderCanceledHandler(logger, svc),
 	}
 }
 
@@ -69,6 +84,17 @@ func (ehf *EventHandlerFuncs) GetSubscription(nc *nats.EncodedConn) (subscriptio
 	}
 	subscriptions = append(subscriptions, s)
 
+	// subscribe to EventOrderCanceled
+	t, err = svcevent.Registry.GetEventInfo(svcevent.EventOrderCanceled)
+	if err != nil {
+		return
+	}
+	s, err = nc.Subscribe(getTargetSub(t.ReqChan, targetSvc), ehf.EventOrderCanceledHandler)
+	if err != nil {
+		return
+	}
+	subscriptions = append(subscriptions, s)
+
 	return
 }
 
@@ -93,6 +119,10 @@ func makeEventAccountCreatedHandler(logger *cl.CustomLogger, svc service.IPaymen
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventAccountCreated, p) {
+			return
+		}
+
 		err = svc.HandleAccountCreatedEvent(ctx, p.AccntID, p.Role)
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventAccountCreated] err: %v", err))
@@ -123,6 +153,10 @@ func makeEventPolicyUpdatedHandler(logger *cl.CustomLogger, svc service.IPayment
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventPolicyUpdated, p) {
+			return
+		}
+
 		err = svc.HandlePolicyUpdatedEvent(ctx, p.Method, p.Sub, p.ResourceType, p.ResourceID, p.Action)
 		if (err == nil) || (err == pe.ErrUnsupportedRtype) || (err == pe.ErrSubNotCached) {
 			m.Ack() // if no error occurred processing event ack it
@@ -153,6 +187,10 @@ func makeEventProductReservedHandler(logger *cl.CustomLogger, svc service.IPayme
 			return
 		}
 
+		if ackInvalid(ctx, logger, m, svcevent.EventProductReserved, p) {
+			return
+		}
+
 		err = svc.HandleProductReservedEvent(ctx, p.OrderID, p.AccntID, float32(p.Payble))
 		if err != nil {
 			logger.Error(ctx, fmt.Sprintf("event handler [EventProductReserved] err: %v", err))
@@ -161,3 +199,37 @@ func makeEventProductReservedHandler(logger *cl.CustomLogger, svc service.IPayme
 		m.Ack()
 	}
 }
+
+func makeEventOrderCanceledHandler(logger *cl.CustomLogger, svc service.IPaymentService) nats.Handler {
+	return func(m *nats.Msg) {
+		var e svcevent.Event
+		var p svcevent.EventOrderCanceledPayload
+
+		json.Unmarshal(m.Data, &e)
+		ctx := context.WithValue(context.Background(), svcconf.C.ReqIDKey, e.Meta.RequestID)
+		logger.Debug(ctx, fmt.Sprintf("event info: %s", string(m.Data)))
+
+		encodedPayload, err := json.Marshal(e.Payload)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderCanceled] err: %v", err))
+			return
+		}
+
+		err = json.Unmarshal(encodedPayload, &p)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderCanceled] err: %v", err))
+			return
+		}
+
+		if ackInvalid(ctx, logger, m, svcevent.EventOrderCanceled, p) {
+			return
+		}
+
+		err = svc.HandleOrderCanceledEvent(ctx, p.OID, p.AccntID)
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("event handler [EventOrderCanceled] err: %v", err))
+			return
+		}
+		m.Ack()
+	}
+}